@@ -0,0 +1,130 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// App orchestrates a two-phase boot on a container: register modules, validate the wiring, warmup eager
+// singletons, run hooks, and then block until shutdown
+//
+//	app := ioc.NewApp(userModule{}, httpModule{})
+//	app.Invoke(func(server *http.Server) { go server.ListenAndServe() })
+//	if err := app.Run(context.Background()); err != nil {
+//		log.Fatal(err)
+//	}
+type App struct {
+	container Container
+	modules   []Registerable
+	preStart  []any
+	invokes   []any
+	postStart []any
+}
+
+// NewApp create an App with modules, the container of App defers eager singletons to the warmup phase
+func NewApp(modules ...Registerable) *App {
+	return &App{
+		container: New(WithDeferredEager()),
+		modules:   modules,
+	}
+}
+
+// Container return the container of App
+func (app *App) Container() Container {
+	return app.container
+}
+
+// Load add modules to App, they are registered when App starts
+func (app *App) Load(modules ...Registerable) *App {
+	app.modules = append(app.modules, modules...)
+	return app
+}
+
+// PreStart add a hook invoked after warmup and before Invoke hooks, its args are injected by container,
+// it can return an error to abort the boot
+func (app *App) PreStart(hook any) *App {
+	app.preStart = append(app.preStart, hook)
+	return app
+}
+
+// Invoke add a hook invoked during start, its args are injected by container, it can return an error to
+// abort the boot
+func (app *App) Invoke(hook any) *App {
+	app.invokes = append(app.invokes, hook)
+	return app
+}
+
+// PostStart add a hook invoked after all Invoke hooks, its args are injected by container,
+// it can return an error to abort the boot
+func (app *App) PostStart(hook any) *App {
+	app.postStart = append(app.postStart, hook)
+	return app
+}
+
+// Start boot the App like StartContext with context.Background()
+func (app *App) Start() error {
+	return app.StartContext(context.Background())
+}
+
+// StartContext boot the App: register modules, Validate, Warmup, run PreStart and Invoke hooks, start runners
+// (see Runner) in dependency order with ctx and run PostStart hooks
+func (app *App) StartContext(ctx context.Context) error {
+	if err := app.container.Load(app.modules...); err != nil {
+		return err
+	}
+
+	if err := app.container.Validate(); err != nil {
+		return fmt.Errorf("validate failed: %w", err)
+	}
+
+	if err := app.container.Warmup(); err != nil {
+		return err
+	}
+
+	if err := app.runHooks("pre-start", app.preStart); err != nil {
+		return err
+	}
+
+	if err := app.runHooks("invoke", app.invokes); err != nil {
+		return err
+	}
+
+	if err := app.container.StartRunners(ctx); err != nil {
+		return err
+	}
+
+	return app.runHooks("post-start", app.postStart)
+}
+
+func (app *App) runHooks(stage string, hooks []any) error {
+	for _, hook := range hooks {
+		if err := app.container.Resolve(hook); err != nil {
+			return fmt.Errorf("%s hook failed: %w", stage, err)
+		}
+	}
+
+	return nil
+}
+
+// Stop stop the runners of App in reverse start order and release the singletons of its container
+func (app *App) Stop(ctx context.Context) error {
+	return errors.Join(app.container.StopRunners(ctx), app.container.Shutdown(ctx))
+}
+
+// Run start the App (runners are started with ctx) and block until ctx is done or the process receives
+// SIGINT/SIGTERM, then stop the App
+func (app *App) Run(ctx context.Context) error {
+	if err := app.StartContext(ctx); err != nil {
+		return errors.Join(err, app.Stop(context.Background()))
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	<-ctx.Done()
+	return app.Stop(context.Background())
+}
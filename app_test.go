@@ -0,0 +1,141 @@
+package ioc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/mylxsw/go-ioc"
+)
+
+type eagerModule struct {
+	created *[]string
+}
+
+func (m eagerModule) Register(binder ioc.Binder) error {
+	return binder.Singleton(ioc.WithOptions(func(repo *UserRepo) *UserService {
+		*m.created = append(*m.created, "service")
+		return &UserService{repo: repo}
+	}, ioc.WithEager()))
+}
+
+func TestApp(t *testing.T) {
+	steps := make([]string, 0)
+	app := ioc.NewApp(userModule{}, eagerModule{created: &steps})
+	app.PostStart(func() { steps = append(steps, "post-start") })
+	app.Invoke(func(service *UserService) error {
+		steps = append(steps, "invoke")
+		if service.GetUser() != expectedValue {
+			return errors.New("unexpected user")
+		}
+		return nil
+	})
+	app.PreStart(func() { steps = append(steps, "pre-start") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := app.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(steps) != "[service pre-start invoke post-start]" {
+		t.Errorf("test failed: %v", steps)
+	}
+}
+
+func TestAppValidate(t *testing.T) {
+	created := make([]string, 0)
+	app := ioc.NewApp(eagerModule{created: &created})
+
+	err := app.Start()
+	if !errors.Is(err, ioc.ErrArgsNotInstanced) || len(created) != 0 {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type recordRunner struct {
+	name  string
+	steps *[]string
+}
+
+func (r *recordRunner) Start(context.Context) error {
+	*r.steps = append(*r.steps, "start "+r.name)
+	return nil
+}
+
+func (r *recordRunner) Stop(context.Context) error {
+	*r.steps = append(*r.steps, "stop "+r.name)
+	return nil
+}
+
+type httpServer struct{ *recordRunner }
+
+type appKey string
+
+type ctxRunner struct {
+	ctx context.Context
+}
+
+func (r *ctxRunner) Start(ctx context.Context) error {
+	r.ctx = ctx
+	return nil
+}
+
+func (r *ctxRunner) Stop(context.Context) error { return nil }
+
+// TestAppRunnerContext 测试 Run 使用调用方的 ctx 启动 Runner
+func TestAppRunnerContext(t *testing.T) {
+	runner := &ctxRunner{}
+	app := ioc.NewApp()
+	app.Container().MustSingleton(func() *ctxRunner { return runner })
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), appKey("name"), "app"))
+	cancel()
+
+	if err := app.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if runner.ctx == nil || runner.ctx.Value(appKey("name")) != "app" {
+		t.Errorf("test failed: runner should be started with the ctx of Run")
+	}
+}
+
+type queueWorker struct{ *recordRunner }
+
+func TestRunners(t *testing.T) {
+	steps := make([]string, 0)
+
+	c := ioc.New()
+	c.MustSingleton(func(repo *UserRepo, queue *queueWorker) *httpServer {
+		return &httpServer{&recordRunner{name: "http", steps: &steps}}
+	})
+	c.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	c.MustSingleton(func(repo *UserRepo) *queueWorker { return &queueWorker{&recordRunner{name: "queue", steps: &steps}} })
+
+	graph := c.Graph()
+	if fmt.Sprint(graph.StartOrder) != "[*ioc_test.queueWorker *ioc_test.httpServer]" {
+		t.Errorf("test failed: %v", graph.StartOrder)
+	}
+
+	for _, node := range graph.Nodes {
+		if node.Key == reflect.TypeOf(&httpServer{}) && fmt.Sprint(node.Dependencies) != "[*ioc_test.UserRepo *ioc_test.queueWorker]" {
+			t.Errorf("test failed: %v", node.Dependencies)
+		}
+	}
+
+	if err := c.StartRunners(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.StopRunners(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(steps) != "[start queue start http stop http stop queue]" {
+		t.Errorf("test failed: %v", steps)
+	}
+}
@@ -0,0 +1,37 @@
+package ioc
+
+import "context"
+
+// Future is a handle of an instance resolved in background
+type Future struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// Done return a channel which is closed when the resolution finished
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait block until the resolution finished or ctx is done, it's safe to call Wait multiple times
+func (f *Future) Wait(ctx context.Context) (any, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// GetAsync resolve instance by key in background and return a future handle of it, so independent slow
+// singletons can be kicked off early and awaited where needed
+func (impl *container) GetAsync(key any) *Future {
+	f := &Future{done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		f.value, f.err = impl.Get(key)
+	}()
+
+	return f
+}
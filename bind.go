@@ -1,34 +1,63 @@
 package ioc
 
-import "reflect"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
 
-// BindValue bind a value to container
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// BindValue bind a value to container, value can be wrapped by WithOptions/WithCondition
 func (impl *container) BindValue(key string, value interface{}) error {
+	if err := impl.checkStrictValueBinding(key); err != nil {
+		return err
+	}
+
 	return impl.bindValueOverride(key, value, false)
 }
 
 // HasBoundValue return whether the kay has bound to a value
 func (impl *container) HasBoundValue(key string) bool {
-	impl.lock.RLock()
-	defer impl.lock.RUnlock()
-
-	_, ok := impl.entities[key]
-	return ok
+	return impl.lookupEntity([]any{key}, nil) != nil
 }
 
 func (impl *container) bindValueOverride(key string, value interface{}, override bool) error {
+	entity, err := impl.newValueEntity(key, value, override)
+	if err != nil || entity == nil {
+		return err
+	}
+
+	return impl.register(entity)
+}
+
+// newValueEntity create an entity for value bound to key, nil entity is returned if the condition of value
+// is not matched
+func (impl *container) newValueEntity(key string, value interface{}, override bool) (*Entity, error) {
 	if value == nil {
-		return buildInvalidArgsError("value is nil")
+		return nil, buildInvalidArgsError("value is nil")
 	}
 
 	if key == "" || key == "@" {
-		return buildInvalidArgsError("key can not be empty or reserved words(@)")
+		return nil, buildInvalidArgsError("key can not be empty or reserved words(@)")
 	}
 
-	impl.lock.Lock()
-	defer impl.lock.Unlock()
+	var opts []BindOption
+	if cond, ok := value.(conditional); ok {
+		matched, err := cond.matched(impl, key)
+		if err != nil || !matched {
+			return nil, err
+		}
+
+		value, opts = cond.init, cond.opts
+		if value == nil {
+			return nil, buildInvalidArgsError("value is nil")
+		}
+	}
 
-	entity := Entity{
+	entity := &Entity{
 		initializeFunc: nil,
 		key:            key,
 		typ:            reflect.TypeOf(value),
@@ -38,22 +67,19 @@ func (impl *container) bindValueOverride(key string, value interface{}, override
 		prototype:      false,
 	}
 
-	if v, ok := impl.entities[key]; ok {
-		if !v.overridable {
-			return buildRepeatedBindError("key repeated, overridable is not allowed for this key")
-		}
-
-		impl.entities[key] = &entity
-		return nil
+	for _, opt := range opts {
+		opt(entity)
 	}
 
-	impl.entities[key] = &entity
-
-	return nil
+	return entity, nil
 }
 
 // BindValueOverride bind a value to container, if key already exist, then replace it
 func (impl *container) BindValueOverride(key string, value interface{}) error {
+	if err := impl.checkStrictValueBinding(key); err != nil {
+		return err
+	}
+
 	return impl.bindValueOverride(key, value, true)
 }
 
@@ -67,22 +93,35 @@ func (impl *container) MustBindValue(key string, value interface{}) {
 	impl.Must(impl.BindValue(key, value))
 }
 
-// HasBound return whether a key's type has bound to an object
+// HasBound return whether a key has bound to an object, key is normalized in the same way as Get
 func (impl *container) HasBound(key interface{}) bool {
-	keyTyp := reflect.ValueOf(key).Type()
-
-	impl.lock.RLock()
-	defer impl.lock.RUnlock()
+	if !reflect.ValueOf(key).IsValid() {
+		return false
+	}
 
-	_, ok := impl.entities[keyTyp]
-	return ok
+	lookupKeys, _ := impl.resolveLookupKeys(key)
+	return impl.lookupEntity(lookupKeys, nil) != nil
 }
 
 // BindWithKey bind a initialize for object with a key
 // initialize func(...) (value, error)
+//
+// key can be a reflect.Type, such as reflect.TypeOf((*UserRepo)(nil)).Elem(), frameworks generating bindings
+// programmatically don't need dummy values to derive types. The value initialize creates must be assignable
+// to the type, and a func assignable to a func type is bound as the value instead of a constructor. A pointer
+// to an interface, such as new(UserRepo), is the interface type, so the binding is resolved like Get does and
+// a constructor whose value does not implement the interface fails at bind time
 func (impl *container) BindWithKey(key interface{}, initialize interface{}, prototype bool, override bool) error {
+	if !reflect.ValueOf(key).IsValid() {
+		return buildInvalidArgsError("key is nil")
+	}
+
+	if typ := reflect.TypeOf(key); typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Interface {
+		key = typ.Elem()
+	}
+
 	if _, ok := initialize.(Conditional); !ok {
-		initialize = WithCondition(initialize, func() bool { return true })
+		initialize = conditional{init: initialize}
 	}
 
 	initF := initialize.(Conditional).getInitFunc()
@@ -91,23 +130,42 @@ func (impl *container) BindWithKey(key interface{}, initialize interface{}, prot
 		return buildInvalidArgsError("initialize is nil")
 	}
 
-	if err := impl.isValidKeyKind(reflect.TypeOf(key).Kind()); err != nil {
+	if err := impl.isValidKey(key); err != nil {
 		return err
 	}
 
+	keyType, isTypeKey := key.(reflect.Type)
+
 	initializeType := reflect.ValueOf(initF).Type()
-	if initializeType.Kind() == reflect.Func {
-		if initializeType.NumOut() <= 0 {
-			return buildInvalidArgsError("expect func return values count greater than 0, but got 0")
+	if initializeType.Kind() == reflect.Func && !(isTypeKey && keyType.Kind() == reflect.Func && initializeType.AssignableTo(keyType)) {
+		if err := checkInitializeType(initializeType); err != nil {
+			return err
+		}
+
+		if isTypeKey && !initializeType.Out(0).AssignableTo(keyType) {
+			return buildKeyTypeError(initializeType.Out(0), keyType)
 		}
 
 		return impl.bindWithOverride(key, initializeType.Out(0), initialize, prototype, override)
 	}
 
-	initFunc := WithCondition(func() interface{} { return initF }, initialize.(Conditional).matched)
+	if isTypeKey && !initializeType.AssignableTo(keyType) {
+		return buildKeyTypeError(initializeType, keyType)
+	}
+
+	initFunc := valueConditional(initF, initialize.(Conditional))
 	return impl.bindWithOverride(key, initializeType, initFunc, prototype, override)
 }
 
+// buildKeyTypeError report the value of type typ can not be bound to the type key keyType
+func buildKeyTypeError(typ reflect.Type, keyType reflect.Type) error {
+	if keyType.Kind() == reflect.Interface {
+		return buildInvalidArgsError(fmt.Sprintf("%v does not implement key type %v", typ, keyType))
+	}
+
+	return buildInvalidArgsError(fmt.Sprintf("%v is not assignable to key type %v", typ, keyType))
+}
+
 // MustBindWithKey bind a initialize for object with a key, if failed then panic
 func (impl *container) MustBindWithKey(key interface{}, initialize interface{}, prototype bool, override bool) {
 	impl.Must(impl.BindWithKey(key, initialize, prototype, override))
@@ -116,37 +174,62 @@ func (impl *container) MustBindWithKey(key interface{}, initialize interface{},
 // Bind bind a initialize for object
 // initialize func(...) (value, error)
 func (impl *container) Bind(initialize interface{}, prototype bool, override bool) error {
+	entity, err := impl.newBindingEntity(initialize, prototype, override)
+	if err != nil || entity == nil {
+		return err
+	}
+
+	return impl.register(entity)
+}
+
+// newBindingEntity create an entity for initialize, the key of entity is the type of value initialize created,
+// nil entity is returned if the condition of initialize is not matched
+func (impl *container) newBindingEntity(initialize interface{}, prototype bool, override bool) (*Entity, error) {
 	if _, ok := initialize.(Conditional); !ok {
-		initialize = conditional{init: initialize, on: func() bool { return true }}
+		initialize = conditional{init: initialize}
 	}
 
 	initF := initialize.(Conditional).getInitFunc()
 
 	if !reflect.ValueOf(initF).IsValid() {
-		return buildInvalidArgsError("initialize is nil")
+		return nil, buildInvalidArgsError("initialize is nil")
 	}
 
 	initializeType := reflect.ValueOf(initF).Type()
 	if initializeType.Kind() == reflect.Func {
-		if initializeType.NumOut() <= 0 {
-			return buildInvalidArgsError("expect func return values count greater than 0, but got 0")
+		if err := checkInitializeType(initializeType); err != nil {
+			return nil, err
 		}
 
 		typ := initializeType.Out(0)
 
 		if err := impl.isValidKeyKind(typ.Kind()); err != nil {
-			return err
+			return nil, err
 		}
 
-		return impl.bindWithOverride(typ, typ, initialize, prototype, override)
+		return impl.buildEntity(typ, typ, initialize, prototype, override)
 	}
 
 	if err := impl.isValidKeyKind(initializeType.Kind()); err != nil {
-		return err
+		return nil, err
+	}
+
+	initFunc := valueConditional(initF, initialize.(Conditional))
+	return impl.buildEntity(initializeType, initializeType, initFunc, prototype, override)
+}
+
+// checkInitializeType check the initialize func returns (T) or (T, error)
+func checkInitializeType(initializeType reflect.Type) error {
+	switch {
+	case initializeType.Kind() != reflect.Func:
+		return buildInvalidArgsError(fmt.Sprintf("initialize must be a func, got %v", initializeType))
+	case initializeType.NumOut() == 0 || initializeType.NumOut() > 2:
+		return buildInvalidArgsError(fmt.Sprintf("expect func returns 1 or 2 values, but got %d, hint: return (T) or (T, error)", initializeType.NumOut()))
+	case initializeType.NumOut() == 2 && !initializeType.Out(1).Implements(errorType):
+		return buildInvalidArgsError(fmt.Sprintf("the second return value of func is %v, hint: return (T) or (T, error)", initializeType.Out(1)))
 	}
 
-	initFunc := WithCondition(func() interface{} { return initF }, initialize.(Conditional).getOnCondition())
-	return impl.bindWithOverride(initializeType, initializeType, initFunc, prototype, override)
+	return nil
 }
 
 // MustBind bind a initialize, if failed then panic
@@ -155,35 +238,278 @@ func (impl *container) MustBind(initialize interface{}, prototype bool, override
 }
 
 func (impl *container) bindWithOverride(key interface{}, typ reflect.Type, initialize interface{}, prototype bool, override bool) error {
+	entity, err := impl.buildEntity(key, typ, initialize, prototype, override)
+	if err != nil || entity == nil {
+		return err
+	}
+
+	return impl.register(entity)
+}
+
+// buildEntity create an entity and apply its options, nil entity is returned if the condition is not matched
+func (impl *container) buildEntity(key interface{}, typ reflect.Type, initialize interface{}, prototype bool, override bool) (*Entity, error) {
 	var entity *Entity
 	if cond, ok := initialize.(Conditional); ok {
-		matched, err := cond.matched(impl)
+		matched, err := cond.matched(impl, key)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if !matched {
-			return nil
+			return nil, nil
 		}
 
 		entity = impl.newEntity(key, typ, cond.getInitFunc(), prototype, override)
+		for _, opt := range cond.getOptions() {
+			opt(entity)
+		}
 	} else {
 		entity = impl.newEntity(key, typ, initialize, prototype, override)
 	}
 
+	return entity, nil
+}
+
+// register validate the entity and save it to container, eager singletons are instantiated immediately
+func (impl *container) register(entity *Entity) error {
+	if err := impl.applyRegistrationHooks(entity); err != nil {
+		return err
+	}
+
+	saved, err := impl.save(entity)
+	impl.notifyChanges()
+	if err != nil || !saved {
+		return err
+	}
+
+	if entity.eager && !entity.prototype && !impl.deferEager {
+		if _, err := entity.Value(nil); err != nil {
+			return fmt.Errorf("eager initialize %v failed: %w", entity.key, err)
+		}
+	}
+
+	return nil
+}
+
+// registerAll validate all entities before saving any of them, so either all of them are saved or none,
+// eager singletons are not instantiated
+func (impl *container) registerAll(entities []*Entity) error {
+	for _, entity := range entities {
+		if err := impl.applyRegistrationHooks(entity); err != nil {
+			return err
+		}
+	}
+
+	defer impl.notifyChanges()
+
 	impl.lock.Lock()
 	defer impl.lock.Unlock()
 
-	if v, ok := impl.entities[entity.key]; ok {
-		if !v.overridable {
-			return buildRepeatedBindError("key repeated, overridable is not allowed for this key")
+	for _, entity := range entities {
+		if err := impl.checkSavable(entity); err != nil {
+			return err
+		}
+	}
+
+	for _, entity := range entities {
+		impl.storeEntity(entity)
+	}
+
+	return nil
+}
+
+// Warmup instantiate all eager singletons (see WithEager) of current container which are not instantiated yet,
+// in registration order
+func (impl *container) Warmup() error {
+	for _, e := range impl.sortedEntities() {
+		if !e.eager || e.prototype {
+			continue
+		}
+
+		if _, err := e.Value(nil); err != nil {
+			return fmt.Errorf("eager initialize %v failed: %w", e.key, err)
 		}
+	}
+
+	return nil
+}
+
+// save validate the entity and save it to container, a conflict with an existing binding while loading a
+// module is settled by the conflict resolver (see WithConflictResolver), false is returned if the entity is
+// dropped by the resolver
+func (impl *container) save(entity *Entity) (bool, error) {
+	impl.lock.Lock()
+	err := impl.checkSavable(entity)
+	if err == nil {
+		impl.storeEntity(entity)
+	}
+
+	var conflict *Conflict
+	if errors.Is(err, ErrRepeatedBind) && impl.conflictResolver != nil && entity.module != "" {
+		conflict = &Conflict{Key: entity.key, Existing: impl.entities[entity.key].info(), Module: entity.module}
+	}
+	impl.lock.Unlock()
 
-		impl.entities[key] = entity
-		return nil
+	if conflict == nil {
+		return err == nil, err
 	}
 
-	impl.entities[key] = entity
+	return impl.settleConflict(entity, *conflict, err)
+}
+
+// checkSavable check whether the entity can be saved to container, caller must hold the lock
+func (impl *container) checkSavable(entity *Entity) error {
+	if err := impl.checkRegistrable(entity); err != nil {
+		return err
+	}
+
+	if v, ok := impl.entities[entity.key]; ok && !v.overridable && !v.builtin {
+		if v.module != "" || entity.module != "" {
+			return buildRepeatedBindError(fmt.Sprintf("key=%v is bound by %s, can not be bound again by %s", entity.key, moduleDesc(v.module), moduleDesc(entity.module)))
+		}
+
+		return buildRepeatedBindError("key repeated, overridable is not allowed for this key")
+	}
 
 	return nil
 }
+
+// checkRegistrable check whether the entity can be saved to container regardless of the overridable flag
+// of the existing binding, caller must hold the lock
+func (impl *container) checkRegistrable(entity *Entity) error {
+	if impl.frozen {
+		return buildFrozenError(fmt.Sprintf("key=%v can not be bound after Freeze", entity.key))
+	}
+
+	if err := impl.checkStrictEntity(entity); err != nil {
+		return err
+	}
+
+	if err := impl.checkThreadSafety(entity); err != nil {
+		return err
+	}
+
+	if err := impl.checkKeyCollision(entity.key); err != nil {
+		return err
+	}
+
+	if impl.reserved[entity.key] && !entity.privileged {
+		return buildReservedKeyError(fmt.Sprintf("key=%v is reserved, it can only be bound with Privileged option", entity.key))
+	}
+
+	if v, ok := impl.entities[entity.key]; ok && v.builtin && !v.overridable && !entity.privileged {
+		return buildBuiltinBindingError(fmt.Sprintf("key=%v is bound by container itself and can not be overridden", entity.key))
+	}
+
+	return nil
+}
+
+// PushOverride temporarily replace the binding of initialize's key (even if it's not overridable) and return a
+// function which reinstates the previous binding, the new binding keeps the scope (singleton or prototype) of
+// the previous one. Nested overrides must be restored in reverse order
+//
+//	restore, err := c.PushOverride(func() UserRepo { return &mockUserRepo{} })
+//	defer restore()
+func (impl *container) PushOverride(initialize interface{}) (restore func(), err error) {
+	entity, err := impl.newBindingEntity(initialize, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if entity == nil {
+		return func() {}, nil
+	}
+
+	defer impl.notifyChanges()
+
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
+	if err := impl.checkRegistrable(entity); err != nil {
+		return nil, err
+	}
+
+	previous, exist := impl.entities[entity.key]
+	if exist {
+		entity.prototype = previous.prototype
+	}
+
+	// the override is temporary, so it bypasses the binding stack
+	impl.registered++
+	entity.index = impl.registered
+	impl.entities[entity.key] = entity
+	impl.bumpGeneration()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			defer impl.notifyChanges()
+
+			impl.lock.Lock()
+			defer impl.lock.Unlock()
+
+			if impl.entities[entity.key] != entity {
+				return
+			}
+
+			if exist {
+				impl.entities[entity.key] = previous
+			} else {
+				delete(impl.entities, entity.key)
+			}
+			impl.bumpGeneration()
+		})
+	}, nil
+}
+
+// MustPushOverride temporarily replace the binding like PushOverride, if failed, panic it
+func (impl *container) MustPushOverride(initialize interface{}) (restore func()) {
+	restore, err := impl.PushOverride(initialize)
+	impl.Must(err)
+
+	return restore
+}
+
+// ReplaceContext replace the built-in context.Context binding of current container, it's the only way to
+// change the context after the container created, rebinding context.Context directly returns ErrBuiltinBinding
+func (impl *container) ReplaceContext(ctx context.Context) error {
+	if ctx == nil {
+		return buildInvalidArgsError("ctx is nil")
+	}
+
+	entity := impl.newEntity(contextType, contextType, func() context.Context { return ctx }, false, false)
+	entity.builtin, entity.privileged = true, true
+
+	if err := impl.register(entity); err != nil {
+		return err
+	}
+
+	impl.ctx.Store(&ctx)
+	return nil
+}
+
+// storeEntity save entity to container and assign its registration index, if the container keeps a binding
+// stack (see WithBindingStack), the entity is pushed to the stack and the highest priority one takes effect,
+// caller must hold the lock
+func (impl *container) storeEntity(entity *Entity) {
+	impl.registered++
+	entity.index = impl.registered
+	impl.bumpGeneration()
+
+	if impl.stacks == nil || entity.builtin {
+		impl.entities[entity.key] = entity
+		return
+	}
+
+	impl.stacks[entity.key] = pushVersion(impl.stacks[entity.key], entity)
+	impl.entities[entity.key] = impl.stacks[entity.key][0]
+}
+
+// valueConditional create a conditional for a value binding, which shares the conditions and options of cond
+func valueConditional(value interface{}, cond Conditional) Conditional {
+	return conditional{
+		init: func() interface{} { return value },
+		on:   cond.getOnConditions(),
+		opts: cond.getOptions(),
+	}
+}
@@ -0,0 +1,96 @@
+package ioc
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+// Value keys bound by BindBuildInfo
+const (
+	BuildPathKey      = "build.path"
+	BuildVersionKey   = "build.version"
+	BuildRevisionKey  = "build.revision"
+	BuildTimeKey      = "build.time"
+	BuildModifiedKey  = "build.modified"
+	BuildGoVersionKey = "build.go_version"
+)
+
+// BuildInfo is the build metadata of current binary read from runtime/debug.ReadBuildInfo, VCS fields are
+// only available when the binary is built by `go build` in a VCS checkout (see -buildvcs)
+type BuildInfo struct {
+	Path      string    // the path of main module
+	Version   string    // the version of main module, "(devel)" if it's not built from a tagged module
+	Revision  string    // the VCS revision (vcs.revision)
+	Time      time.Time // the time of VCS revision (vcs.time), zero if unknown
+	Modified  bool      // whether the source tree had local modifications (vcs.modified)
+	GoVersion string    // the version of Go toolchain which built the binary
+}
+
+// CurrentBuildInfo return the build metadata of current binary, false if it's not available (such as a
+// binary built without module support)
+func CurrentBuildInfo() (BuildInfo, bool) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return BuildInfo{}, false
+	}
+
+	build := BuildInfo{Path: info.Main.Path, Version: info.Main.Version, GoVersion: info.GoVersion}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.time":
+			build.Time, _ = time.Parse(time.RFC3339, setting.Value)
+		case "vcs.modified":
+			build.Modified, _ = strconv.ParseBool(setting.Value)
+		}
+	}
+
+	return build, true
+}
+
+// BindBuildInfo bind the build metadata of current binary (see CurrentBuildInfo), BuildInfo is bound as a
+// singleton, and every known field is bound as a value with Build*Key, such as BuildVersionKey (the time is
+// formatted as RFC3339). Only BuildInfo with GoVersion is bound if the build metadata is not available
+func (impl *container) BindBuildInfo() error {
+	return impl.bindBuildInfo(impl)
+}
+
+// bindBuildInfo bind the build metadata through b
+func (impl *container) bindBuildInfo(b Binder) error {
+	build, _ := CurrentBuildInfo()
+	if build.GoVersion == "" {
+		build.GoVersion = runtime.Version()
+	}
+
+	if err := b.Singleton(func() BuildInfo { return build }); err != nil {
+		return err
+	}
+
+	values := []struct {
+		key   string
+		value any
+		known bool
+	}{
+		{BuildPathKey, build.Path, build.Path != ""},
+		{BuildVersionKey, build.Version, build.Version != ""},
+		{BuildRevisionKey, build.Revision, build.Revision != ""},
+		{BuildTimeKey, build.Time.Format(time.RFC3339), !build.Time.IsZero()},
+		{BuildModifiedKey, build.Modified, build.Revision != ""},
+		{BuildGoVersionKey, build.GoVersion, true},
+	}
+
+	for _, v := range values {
+		if !v.known {
+			continue
+		}
+
+		if err := bindBuiltinValue(b, v.key, v.value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package ioc
+
+import "reflect"
+
+// resolveArg resolve the instance of type T like the args of Resolve
+func resolveArg[T any](r Resolver) (T, error) {
+	var res T
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	var val any
+	if impl, ok := containerOf(r); ok {
+		v, err := impl.instanceOfType(typ, nil)
+		if err != nil {
+			return res, err
+		}
+
+		val = v.Interface()
+	} else {
+		v, err := r.Get(typ)
+		if err != nil {
+			return res, wrapArgNotInstancedError(err)
+		}
+
+		val = v
+	}
+
+	if val != nil {
+		res = val.(T)
+	}
+
+	return res, nil
+}
+
+// CheckedResolve1 inject 1 arg for callback with compile-time signature check. CheckedResolve1 to
+// CheckedResolve6 inject args like Resolve, but the signature of callback is checked at compile time, and
+// the args are resolved by their static types without analyzing the callback by reflection
+//
+//	err := ioc.CheckedResolve2(c, func(repo *UserRepo, logger *slog.Logger) error { ... })
+func CheckedResolve1[T1 any](r Resolver, callback func(T1) error) error {
+	a1, err := resolveArg[T1](r)
+	if err != nil {
+		return err
+	}
+
+	return callback(a1)
+}
+
+// CheckedResolve2 inject 2 args for callback with compile-time signature check
+func CheckedResolve2[T1, T2 any](r Resolver, callback func(T1, T2) error) error {
+	a1, err := resolveArg[T1](r)
+	if err != nil {
+		return err
+	}
+
+	a2, err := resolveArg[T2](r)
+	if err != nil {
+		return err
+	}
+
+	return callback(a1, a2)
+}
+
+// CheckedResolve3 inject 3 args for callback with compile-time signature check
+func CheckedResolve3[T1, T2, T3 any](r Resolver, callback func(T1, T2, T3) error) error {
+	a1, err := resolveArg[T1](r)
+	if err != nil {
+		return err
+	}
+
+	a2, err := resolveArg[T2](r)
+	if err != nil {
+		return err
+	}
+
+	a3, err := resolveArg[T3](r)
+	if err != nil {
+		return err
+	}
+
+	return callback(a1, a2, a3)
+}
+
+// CheckedResolve4 inject 4 args for callback with compile-time signature check
+func CheckedResolve4[T1, T2, T3, T4 any](r Resolver, callback func(T1, T2, T3, T4) error) error {
+	a1, err := resolveArg[T1](r)
+	if err != nil {
+		return err
+	}
+
+	a2, err := resolveArg[T2](r)
+	if err != nil {
+		return err
+	}
+
+	a3, err := resolveArg[T3](r)
+	if err != nil {
+		return err
+	}
+
+	a4, err := resolveArg[T4](r)
+	if err != nil {
+		return err
+	}
+
+	return callback(a1, a2, a3, a4)
+}
+
+// CheckedResolve5 inject 5 args for callback with compile-time signature check
+func CheckedResolve5[T1, T2, T3, T4, T5 any](r Resolver, callback func(T1, T2, T3, T4, T5) error) error {
+	a1, err := resolveArg[T1](r)
+	if err != nil {
+		return err
+	}
+
+	a2, err := resolveArg[T2](r)
+	if err != nil {
+		return err
+	}
+
+	a3, err := resolveArg[T3](r)
+	if err != nil {
+		return err
+	}
+
+	a4, err := resolveArg[T4](r)
+	if err != nil {
+		return err
+	}
+
+	a5, err := resolveArg[T5](r)
+	if err != nil {
+		return err
+	}
+
+	return callback(a1, a2, a3, a4, a5)
+}
+
+// CheckedResolve6 inject 6 args for callback with compile-time signature check
+func CheckedResolve6[T1, T2, T3, T4, T5, T6 any](r Resolver, callback func(T1, T2, T3, T4, T5, T6) error) error {
+	a1, err := resolveArg[T1](r)
+	if err != nil {
+		return err
+	}
+
+	a2, err := resolveArg[T2](r)
+	if err != nil {
+		return err
+	}
+
+	a3, err := resolveArg[T3](r)
+	if err != nil {
+		return err
+	}
+
+	a4, err := resolveArg[T4](r)
+	if err != nil {
+		return err
+	}
+
+	a5, err := resolveArg[T5](r)
+	if err != nil {
+		return err
+	}
+
+	a6, err := resolveArg[T6](r)
+	if err != nil {
+		return err
+	}
+
+	return callback(a1, a2, a3, a4, a5, a6)
+}
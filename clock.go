@@ -0,0 +1,67 @@
+package ioc
+
+import (
+	"reflect"
+	"time"
+)
+
+// Clock is the time abstraction bound in container by default, components depending on Clock instead of
+// the time package can be tested with a fake clock (see ioctest.UseFakeClock)
+type Clock interface {
+	// Now return the current time
+	Now() time.Time
+	// After wait for the duration d to elapse and then send the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+	// Ticker create a Ticker which sends the current time on its channel every period d
+	Ticker(d time.Duration) Ticker
+}
+
+// Ticker is the ticker created by Clock
+type Ticker interface {
+	// C return the channel on which the ticks are delivered
+	C() <-chan time.Time
+	// Stop turn off the ticker, no more ticks will be sent
+	Stop()
+	// Reset stop the ticker and reset its period to d
+	Reset(d time.Duration)
+}
+
+var clockType = reflect.TypeOf((*Clock)(nil)).Elem()
+
+// clock return the Clock bound in container, the real clock if it's absent
+func (impl *container) clock() Clock {
+	if val, err := impl.lookupInstance(clockType, nil); err == nil {
+		if clock, ok := val.(Clock); ok && clock != nil {
+			return clock
+		}
+	}
+
+	return RealClock()
+}
+
+// now return the current time of the Clock bound in container, the built-in clock is not resolved, so reading
+// the time of it is not counted as a resolution
+func (impl *container) now() time.Time {
+	if e := impl.findEntity(clockType); e == nil || e.builtin {
+		return time.Now()
+	}
+
+	return impl.clock().Now()
+}
+
+// RealClock return a Clock backed by the time package
+func RealClock() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Ticker(d time.Duration) Ticker          { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
@@ -1,72 +1,170 @@
 package ioc
 
 import (
-	"errors"
+	"fmt"
 	"reflect"
 )
 
 type Conditional interface {
 	getInitFunc() interface{}
-	getOnCondition() interface{}
-	matched(cc Container) (bool, error)
+	getOnConditions() []interface{}
+	getOptions() []BindOption
+	matched(cc Container, key any) (bool, error)
+}
+
+// ConditionError is returned when a condition of a binding (see WithCondition) failed, it matches
+// ErrConditionFailed and the error of condition by errors.Is
+type ConditionError struct {
+	// Key the key of binding
+	Key any
+	// Condition the signature of condition func, such as "func(*app.Config) (bool, error)"
+	Condition string
+	// Err the error returned by the condition, or the error resolving its args
+	Err error
+}
+
+func (e *ConditionError) Error() string {
+	return fmt.Sprintf("%v: condition %s of key=%v: %v", ErrConditionFailed, e.Condition, e.Key, e.Err)
+}
+
+func (e *ConditionError) Unwrap() []error {
+	return []error{ErrConditionFailed, e.Err}
+}
+
+// buildConditionError is an error object represent the condition on of the binding of key failed with err
+func buildConditionError(key any, on any, err error) error {
+	return &ConditionError{Key: key, Condition: reflect.TypeOf(on).String(), Err: err}
 }
 
 type conditional struct {
 	init interface{}
-	on   interface{}
+	on   []interface{}
+	opts []BindOption
 }
 
 // WithCondition 创建 Conditional 接口实例
 // init 参数为传递个 Singleton/Prototype 方法的实例创建方法
-// onCondition 参数支持两种形式
+// onCondition 参数支持三种形式
 //   - `onCondition(依赖注入参数列表...) bool`
-//   - `onCondition(依赖注入参数列表...) (bool, error)`
+//   - `onCondition(依赖注入参数列表...) (bool, error)`，返回的 error 不为 nil 时绑定失败
+//   - `onCondition(依赖注入参数列表...) error`，返回 nil 表示条件满足，否则条件不满足（不绑定）
+//
+// onCondition 的签名不合法时 panic，panic 的值为 ErrInvalidArgs 错误
 func WithCondition(init interface{}, onCondition interface{}) Conditional {
+	if err := checkConditionType(onCondition); err != nil {
+		panic(err)
+	}
+
+	cond := toConditional(init)
+	cond.on = append(cond.on, onCondition)
+	return cond
+}
+
+// conditionSignatures describe the supported signatures of conditions in error messages
+const conditionSignatures = "expect func(...) bool, func(...) (bool, error) or func(...) error"
+
+// checkConditionType check onCondition is a func returns (bool), (bool, error) or (error)
+func checkConditionType(onCondition interface{}) error {
 	if onCondition == nil {
-		panic("invalid argument onCondition: can not be nil [onCondition() bool or onCondition() (bool, error)]")
+		return buildInvalidArgsError("invalid argument onCondition: can not be nil, " + conditionSignatures)
 	}
+
 	onType := reflect.TypeOf(onCondition)
 	if onType.Kind() != reflect.Func {
-		panic("invalid argument onCondition: must be a func [onCondition() bool or onCondition() (bool, error)]")
+		return buildInvalidArgsError(fmt.Sprintf("invalid argument onCondition: got %v, %s", onType, conditionSignatures))
 	}
 
-	argCount := onType.NumOut()
-	if argCount != 1 && argCount != 2 {
-		panic("invalid argument onCondition: onCondition() bool or onCondition() (bool, error)")
+	valid := false
+	switch onType.NumOut() {
+	case 1:
+		valid = onType.Out(0).Kind() == reflect.Bool || onType.Out(0) == errorType
+	case 2:
+		valid = onType.Out(0).Kind() == reflect.Bool && onType.Out(1) == errorType
 	}
 
-	if onType.Out(0).Kind() != reflect.Bool {
-		panic("invalid argument onCondition: return value must be bool [onCondition() bool or onCondition() (bool, error)]")
+	if !valid {
+		return buildInvalidArgsError(fmt.Sprintf("invalid argument onCondition: %v returns unsupported values, %s", onType, conditionSignatures))
 	}
 
-	// onCondition() (bool, error)
-	if argCount == 2 {
-		if onType.Out(1) != reflect.TypeOf(errors.New("")) {
-			panic("invalid argument onCondition: the second return value must be error [onCondition() (bool, error)]")
+	return nil
+}
+
+// WithOptions 为实例创建方法 init 添加绑定选项，返回的 Conditional 可以直接传递给 Singleton/Prototype 等方法
+//
+//	c.MustSingleton(ioc.WithOptions(NewPlugin, ioc.WithPriority(10)))
+//
+// WithOptions 与 WithCondition 可以相互嵌套使用
+func WithOptions(init interface{}, opts ...BindOption) Conditional {
+	cond := toConditional(init)
+	cond.opts = append(cond.opts, opts...)
+	return cond
+}
+
+// toConditional convert init to a conditional, if init is already a conditional, a copy of it will be returned
+func toConditional(init interface{}) conditional {
+	if cond, ok := init.(conditional); ok {
+		return conditional{
+			init: cond.init,
+			on:   append([]interface{}{}, cond.on...),
+			opts: append([]BindOption{}, cond.opts...),
 		}
 	}
 
-	return conditional{init: init, on: onCondition}
+	return conditional{init: init}
 }
 
 func (cond conditional) getInitFunc() interface{} {
 	return cond.init
 }
 
-func (cond conditional) getOnCondition() interface{} {
+func (cond conditional) getOnConditions() []interface{} {
 	return cond.on
 }
 
-func (cond conditional) matched(cc Container) (bool, error) {
-	res, err := cc.Call(cond.on)
-	if err != nil {
-		return false, err
+func (cond conditional) getOptions() []BindOption {
+	return cond.opts
+}
+
+func (cond conditional) matched(cc Container, key any) (bool, error) {
+	for _, on := range cond.on {
+		res, err := cc.Call(on)
+		if err != nil {
+			return false, buildConditionError(key, on, err)
+		}
+
+		matched, err := conditionResult(res)
+		if err != nil {
+			return false, buildConditionError(key, on, err)
+		}
+
+		if !matched {
+			return false, nil
+		}
 	}
 
-	if len(res) == 2 {
-		matched, err := res[0], res[1]
-		return matched.(bool), err.(error)
+	return true, nil
+}
+
+// conditionResult interpret the results of a condition, a condition returning only an error is matched if the
+// error is nil
+func conditionResult(res []interface{}) (bool, error) {
+	if len(res) == 0 {
+		return false, buildInvalidArgsError("condition returns nothing, " + conditionSignatures)
+	}
+
+	// the bool can be of a type defined on bool
+	first := reflect.ValueOf(res[0])
+	if len(res) == 1 {
+		if first.IsValid() && first.Kind() == reflect.Bool {
+			return first.Bool(), nil
+		}
+
+		return res[0] == nil, nil
+	}
+
+	if err, ok := res[1].(error); ok && err != nil {
+		return false, err
 	}
 
-	return res[0].(bool), nil
+	return first.IsValid() && first.Bool(), nil
 }
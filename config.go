@@ -0,0 +1,263 @@
+package ioc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ConfigSource provides values for PopulateConfig, path is the dotted path of a field, such as "db.max_conns"
+// for the field tagged with `mapstructure:"max_conns"` of the struct field `mapstructure:"db"`
+type ConfigSource interface {
+	// Lookup return the value of path, ok is false if the source has no such value
+	Lookup(r Resolver, path string) (val any, ok bool, err error)
+}
+
+// ConfigSourceFunc is a func implements ConfigSource
+type ConfigSourceFunc func(r Resolver, path string) (any, bool, error)
+
+// Lookup return the value of path
+func (f ConfigSourceFunc) Lookup(r Resolver, path string) (any, bool, error) {
+	return f(r, path)
+}
+
+// FromEnv return a ConfigSource reading environment variables, the name of variable is prefix (joined) followed
+// by the path in upper case with dots replaced by underscores, such as APP_DB_MAX_CONNS for "db.max_conns" with
+// prefix "APP_"
+func FromEnv(prefix ...string) ConfigSource {
+	p := strings.Join(prefix, "")
+	return ConfigSourceFunc(func(_ Resolver, path string) (any, bool, error) {
+		val, ok := os.LookupEnv(p + strings.ToUpper(strings.ReplaceAll(path, ".", "_")))
+		return val, ok, nil
+	})
+}
+
+// FromValues return a ConfigSource reading the values bound by BindValue in the container, the key of value is
+// prefix followed by the path, such as "app.db.max_conns" for "db.max_conns" with prefix "app."
+func FromValues(prefix string) ConfigSource {
+	return ConfigSourceFunc(func(r Resolver, path string) (any, bool, error) {
+		if !r.HasBoundValue(prefix + path) {
+			return nil, false, nil
+		}
+
+		val, err := r.Get(prefix + path)
+		return val, err == nil, err
+	})
+}
+
+// FromFile return a ConfigSource reading the JSON file name, nested objects are addressed by dotted paths, keys
+// are matched case-insensitively. The file is read once by the first lookup, a missing file is an error
+func FromFile(name string) ConfigSource {
+	var values map[string]any
+	var loadErr error
+	var once sync.Once
+
+	return ConfigSourceFunc(func(_ Resolver, path string) (any, bool, error) {
+		once.Do(func() { values, loadErr = loadConfigFile(name) })
+
+		if loadErr != nil {
+			return nil, false, loadErr
+		}
+
+		val, ok := values[strings.ToLower(path)]
+		return val, ok, nil
+	})
+}
+
+// loadConfigFile decode the JSON file name into flat values keyed by lower case dotted paths
+func loadConfigFile(name string) (map[string]any, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("read config file %s failed: %w", name, err)
+	}
+
+	var tree map[string]any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("decode config file %s failed: %w", name, err)
+	}
+
+	values := make(map[string]any)
+	flattenConfig(values, "", tree)
+
+	return values, nil
+}
+
+// flattenConfig save the leaves of tree into values by their dotted paths
+func flattenConfig(values map[string]any, prefix string, tree map[string]any) {
+	for k, v := range tree {
+		path := strings.ToLower(prefix + k)
+		if sub, ok := v.(map[string]any); ok {
+			flattenConfig(values, path+".", sub)
+			continue
+		}
+
+		values[path] = v
+	}
+}
+
+// PopulateConfig fill the struct cfgPtr points to from sources, and bind cfgPtr as a singleton of its type, so
+// consumers inject the config directly. For every field, the first source (in the order of sources) having its
+// path wins, fields no source has keep their values, so defaults can be set before populating. Fields are named
+// by `mapstructure` tags (the lower case field name if absent), `mapstructure:"-"` skips the field, and
+// `mapstructure:",squash"` (or an embedded struct without tag) populates a struct as if its fields belong to the
+// parent. String values are
+// converted to bool, numbers and time.Duration, slices split string values by commas
+//
+//	var cfg struct {
+//		Listen string `mapstructure:"listen"`
+//		DB     struct {
+//			DSN      string `mapstructure:"dsn"`
+//			MaxConns int    `mapstructure:"max_conns"`
+//		} `mapstructure:"db"`
+//	}
+//	err := c.PopulateConfig(&cfg, ioc.FromEnv("APP_"), ioc.FromValues("app."), ioc.FromFile("config.json"))
+func (impl *container) PopulateConfig(cfgPtr any, sources ...ConfigSource) error {
+	return impl.populateConfig(impl, cfgPtr, sources)
+}
+
+// populateConfig fill the struct cfgPtr points to from sources, and bind cfgPtr through b
+func (impl *container) populateConfig(b Binder, cfgPtr any, sources []ConfigSource) error {
+	v := reflect.ValueOf(cfgPtr)
+	if !v.IsValid() || v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return buildInvalidArgsError(fmt.Sprintf("cfgPtr must be a non-nil pointer to struct, got %T", cfgPtr))
+	}
+
+	for i, source := range sources {
+		if source == nil {
+			return buildInvalidArgsError(fmt.Sprintf("config source #%d is nil", i))
+		}
+	}
+
+	if err := impl.populateStruct(v.Elem(), "", sources); err != nil {
+		return err
+	}
+
+	return b.Singleton(cfgPtr)
+}
+
+// populateStruct fill the exported fields of struct v, prefix is the dotted path of v
+func (impl *container) populateStruct(v reflect.Value, prefix string, sources []ConfigSource) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		// fields of embedded structs are promoted, so they are populated even if the struct is unexported
+		if !field.IsExported() && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		name, squash := configFieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+		if squash && fv.Kind() == reflect.Struct {
+			if err := impl.populateStruct(fv, prefix, sources); err != nil {
+				return err
+			}
+			continue
+		}
+
+		path := prefix + name
+		if fv.Kind() == reflect.Struct {
+			if err := impl.populateStruct(fv, path+".", sources); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if fv.Kind() == reflect.Ptr && fv.Type().Elem().Kind() == reflect.Struct {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+
+			if err := impl.populateStruct(fv.Elem(), path+".", sources); err != nil {
+				return err
+			}
+			continue
+		}
+
+		val, ok, err := impl.lookupConfig(path, sources)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		converted, err := convertConfigValue(val, fv.Type())
+		if err != nil {
+			return fmt.Errorf("config %s: %w", path, err)
+		}
+
+		fv.Set(converted)
+	}
+
+	return nil
+}
+
+// lookupConfig return the value of path from the first source having it
+func (impl *container) lookupConfig(path string, sources []ConfigSource) (any, bool, error) {
+	for _, source := range sources {
+		val, ok, err := source.Lookup(impl, path)
+		if err != nil {
+			return nil, false, fmt.Errorf("config %s: %w", path, err)
+		}
+
+		if ok {
+			return val, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+// configFieldName return the name of field in config paths and whether it's squashed
+func configFieldName(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup("mapstructure")
+	if !ok {
+		return strings.ToLower(field.Name), field.Anonymous
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+
+	return name, opts == "squash"
+}
+
+// convertConfigValue convert val to typ like convertValue, slices are converted element by element from slices
+// of values or comma separated strings
+func convertConfigValue(val any, typ reflect.Type) (reflect.Value, error) {
+	if typ.Kind() != reflect.Slice || !reflect.ValueOf(val).IsValid() || reflect.TypeOf(val).AssignableTo(typ) {
+		return convertValue(val, typ)
+	}
+
+	var items []any
+	switch vv := val.(type) {
+	case string:
+		for _, item := range strings.Split(vv, ",") {
+			items = append(items, strings.TrimSpace(item))
+		}
+	case []any:
+		items = vv
+	default:
+		return reflect.Value{}, buildValueConversionError(fmt.Sprintf("can not convert %T to %v", val, typ))
+	}
+
+	res := reflect.MakeSlice(typ, len(items), len(items))
+	for i, item := range items {
+		converted, err := convertValue(item, typ.Elem())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("item #%d: %w", i, err)
+		}
+
+		res.Index(i).Set(converted)
+	}
+
+	return res, nil
+}
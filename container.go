@@ -2,10 +2,15 @@ package ioc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	mrand "math/rand"
 	"reflect"
+	"sort"
+	"strings"
 	"sync"
-	"unsafe"
+	"sync/atomic"
 )
 
 // container is a dependency injection container
@@ -14,6 +19,47 @@ type container struct {
 
 	entities map[any]*Entity
 	parent   Container
+	reserved map[any]bool // keys which can only be bound by privileged bindings
+
+	registered int // registration counter, used to order entities
+
+	maxLookupDepth    int
+	keyCollisionCheck bool
+	constructorSem    chan struct{}            // limit the count of constructors running concurrently
+	deferEager        bool                     // eager singletons are instantiated by Warmup instead of bind time
+	callMemoization   bool                     // args of the same type share one instance in a single Call/Resolve
+	stacks            map[any][]*Entity        // all registrations of keys ordered by priority, nil if binding stack is disabled
+	started           []startedRunner          // runners started by StartRunners, in start order
+	generation        atomic.Uint64            // increased on every mutation of bindings
+	notified          atomic.Uint64            // the generation reported to change listeners, see notifyChanges
+	changeListeners   []changeListener         // listeners of OnChange, guarded by lock
+	changeSeq         int                      // id of the latest change listener
+	instantiations    atomic.Uint64            // count of singletons instantiated, orders disposal in Shutdown
+	parentCache       parentCache              // cache of lookups from ancestors
+	stats             containerStats           // counters of container behavior
+	profiler          atomic.Pointer[profiler] // recorder of resolutions, nil if profiling is disabled
+	observer          func(Resolution)         // called after every resolution of bindings, see WithResolutionObserver
+	scope             ScopeInfo
+	strict            bool // enforce the rules of strict mode, see WithStrictMode
+	frozen            bool // no binding changes are allowed after Freeze
+	registrationHooks []RegistrationHook
+	bootstrapped      bool // built-in bindings are registered
+	captiveCheck      bool // Validate reports captive dependencies as errors
+	sources           []BindingSource
+	manifest          *Manifest // bindings of environments, see WithManifest
+	fallback          Resolver  // consulted when the key is missed by current container and its ancestors
+	conflictResolver  ConflictResolver
+	keyMatchers       []KeyMatcher                    // consulted when the built-in rules miss, see WithKeyMatchers
+	loadedModules     map[any]bool                    // guards of modules loaded by Load, see moduleGuard, guarded by lock
+	ctx               atomic.Pointer[context.Context] // the bound context of root container or ReplaceContext, see resolutionContext
+	sharedKeys        map[any]bool                    // singletons shared with siblings through the parent, see WithSharedCache
+	shared            sharedCache                     // singletons shared by children
+	fastSingletons    sync.Map                        // reflect.Type -> fastEntry, see fastGet
+	closed            atomic.Bool                     // no resolutions are allowed after Shutdown
+	factories         map[string]any                  // constructors registered by RegisterFactory, guarded by lock
+	detached          bool                            // not shut down by the Shutdown of parent, see WithDetached
+	tracked           atomic.Bool                     // registered in the children of parent, see track
+	children          map[*container]uint64           // children shut down before current container, guarded by lock
 }
 
 func (impl *container) P(initialize any) error {
@@ -92,52 +138,173 @@ func (impl *container) MustSingletonWithKeyOverride(key interface{}, initialize
 	impl.Must(impl.SingletonWithKeyOverride(key, initialize))
 }
 
-// New create a new container
-func New() Container {
+// newContainer create a container and apply all options
+func newContainer(parent Container, opts ...Option) *container {
+	// the binder passed to modules is not a container itself, its container is the parent
+	if cc, ok := containerOf(parent); ok {
+		parent = cc
+	}
+
 	impl := &container{
 		entities: make(map[any]*Entity),
+		parent:   parent,
+		reserved: make(map[any]bool),
 	}
 
-	impl.MustSingleton(func() Container { return impl })
-	impl.MustSingleton(func() context.Context { return context.Background() })
-	impl.MustSingleton(func() Binder { return impl })
-	impl.MustSingleton(func() Resolver { return impl })
+	for _, opt := range opts {
+		opt(impl)
+	}
+	impl.initScope()
 
 	return impl
 }
 
-// NewWithContext create a new container with context support
-func NewWithContext(ctx context.Context) Container {
-	cc := &container{
-		entities: make(map[any]*Entity, 0),
-	}
+// hierarchyLock serialize the changes of container hierarchy
+var hierarchyLock sync.Mutex
 
-	cc.MustSingleton(func() Container { return cc })
-	cc.MustSingleton(func() context.Context { return ctx })
-	cc.MustSingleton(func() Binder { return cc })
-	cc.MustSingleton(func() Resolver { return cc })
+// New create a new container
+func New(opts ...Option) Container {
+	impl := newContainer(nil, opts...)
+	impl.bindBuiltins(context.Background())
+
+	return impl
+}
+
+// NewWithContext create a new container with context support
+func NewWithContext(ctx context.Context, opts ...Option) Container {
+	cc := newContainer(nil, opts...)
+	cc.bindBuiltins(ctx)
 
 	return cc
 }
 
 // Extend create a new container, and it's parent is supplied container
 // If it can not find a binding from current container, it will search from parents
-func Extend(c Container) Container {
-	cc := &container{
-		entities: make(map[any]*Entity, 0),
-		parent:   c,
-	}
+func Extend(c Container, opts ...Option) Container {
+	cc := newContainer(c, opts...)
 
 	cc.MustSingleton(func() Container {
 		return cc
 	})
+	cc.MustSingleton(func() ScopeInfo { return cc.Scope() })
+	cc.markBuiltins()
 
 	return cc
 }
 
-// ExtendFrom extend from a parent container
+// NewChild create a child container of current container, it's equivalent to Extend(c, opts...) and safe to
+// call concurrently with other operations on current container
+func (impl *container) NewChild(opts ...Option) Container {
+	return Extend(impl, opts...)
+}
+
+// bindBuiltins bind the built-in objects of a root container
+func (impl *container) bindBuiltins(ctx context.Context) {
+	impl.ctx.Store(&ctx)
+	impl.MustSingleton(func() Container { return impl })
+	impl.MustSingleton(func() context.Context { return ctx })
+	impl.MustSingleton(func() Binder { return impl })
+	impl.MustSingleton(func() Resolver { return impl })
+	impl.MustSingletonOverride(func() *slog.Logger { return slog.Default() })
+	impl.MustSingletonOverride(func() EventBus { return NewEventBus() })
+	impl.MustSingletonOverride(func() Clock { return RealClock() })
+	impl.MustSingletonOverride(func() mrand.Source { return defaultRandSource() })
+	impl.MustSingletonOverride(func() IDGenerator { return UUIDGenerator() })
+	impl.MustSingleton(func() ScopeInfo { return impl.Scope() })
+
+	impl.markBuiltins()
+}
+
+// markBuiltins mark all entities currently registered as built-in
+func (impl *container) markBuiltins() {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
+	for _, e := range impl.entities {
+		e.builtin = true
+	}
+
+	impl.bootstrapped = true
+}
+
+// ExtendFrom extend from a parent container, if parent is current container or one of its descendants,
+// the error is logged and the parent is not changed, use TryExtendFrom to handle the error
 func (impl *container) ExtendFrom(parent Container) {
+	if err := impl.TryExtendFrom(parent); err != nil {
+		impl.logger().Error("extend from parent failed", "error", err)
+	}
+}
+
+// MustExtendFrom extend from a parent container like TryExtendFrom, if failed, panic it
+func (impl *container) MustExtendFrom(parent Container) {
+	impl.Must(impl.TryExtendFrom(parent))
+}
+
+// TryExtendFrom extend from a parent container, if parent is current container or one of
+// its descendants, ErrParentCycle will be returned
+func (impl *container) TryExtendFrom(parent Container) error {
+	defer impl.notifyChanges()
+
+	if cc, ok := containerOf(parent); ok {
+		parent = cc
+	}
+
+	// serialize hierarchy changes, so concurrent TryExtendFrom calls can not create a cycle together
+	hierarchyLock.Lock()
+	defer hierarchyLock.Unlock()
+
+	visited := make(map[Container]bool)
+	for p := parent; p != nil; p = p.Parent() {
+		if p == Container(impl) {
+			return buildParentCycleError("the parent container is current container or one of its descendants")
+		}
+
+		if visited[p] {
+			return buildParentCycleError("the parent container has a circular hierarchy")
+		}
+
+		visited[p] = true
+	}
+
+	// the cascading Shutdown follows the new parent
+	if impl.tracked.Load() {
+		if old, ok := impl.Parent().(*container); ok {
+			old.removeChild(impl)
+		}
+
+		if p, ok := parent.(*container); ok {
+			p.addChild(impl)
+		} else {
+			impl.tracked.Store(false)
+		}
+	}
+
+	impl.lock.Lock()
 	impl.parent = parent
+	impl.lock.Unlock()
+
+	bumpHierarchyGeneration()
+	impl.bumpGeneration()
+
+	return nil
+}
+
+// Parent return the parent container, nil if current container is a root container
+func (impl *container) Parent() Container {
+	impl.lock.RLock()
+	defer impl.lock.RUnlock()
+
+	return impl.parent
+}
+
+// Ancestors return all ancestors of current container, ordered from the nearest parent to the root
+func (impl *container) Ancestors() []Container {
+	ancestors := make([]Container, 0)
+	for parent := impl.Parent(); parent != nil; parent = parent.Parent() {
+		ancestors = append(ancestors, parent)
+	}
+
+	return ancestors
 }
 
 // Must if err is not nil, panic it
@@ -215,8 +382,8 @@ func (impl *container) newEntityWrapper(initialize interface{}, prototype bool)
 	}
 
 	initializeType := reflect.ValueOf(initialize).Type()
-	if initializeType.NumOut() <= 0 {
-		return nil, buildInvalidArgsError("expect func return values count greater than 0, but got 0")
+	if err := checkInitializeType(initializeType); err != nil {
+		return nil, err
 	}
 
 	typ := initializeType.Out(0)
@@ -247,8 +414,8 @@ func (impl *container) AutoWire(valPtr interface{}) error {
 	}
 
 	valRef := reflect.ValueOf(valPtr)
-	if valRef.Kind() != reflect.Ptr {
-		return buildInvalidArgsError("valPtr must be a pointer to struct valPtr")
+	if valRef.Kind() != reflect.Ptr || valRef.IsNil() || valRef.Elem().Kind() != reflect.Struct {
+		return buildInvalidArgsError(fmt.Sprintf("valPtr must be a non-nil pointer to struct, got %T", valPtr))
 	}
 
 	structValue := valRef.Elem()
@@ -260,38 +427,140 @@ func (impl *container) AutoWire(valPtr interface{}) error {
 			continue
 		}
 
-		if tag == "@" {
-			val, err := impl.instanceOfType(field.Type, nil)
+		if tag == "@" && field.Type.Kind() == reflect.Func {
+			fn, err := impl.funcFieldValue(field.Type)
+			if err != nil {
+				return fmt.Errorf("%v: %w", field.Name, err)
+			}
+
+			if err := setField(structValue.Field(i), fn); err != nil {
+				return fmt.Errorf("%v: %w", field.Name, err)
+			}
+		} else if tag == "@" {
+			var val reflect.Value
+			var err error
+			if field.Type.Kind() == reflect.Interface {
+				val, err = impl.autowireInterface(field.Type)
+			} else {
+				val, err = impl.instanceOfType(field.Type, nil)
+			}
+
 			if err != nil {
-				return fmt.Errorf("%v: %v", field.Name, err)
+				return fmt.Errorf("%v: %w", field.Name, err)
 			}
 
-			fieldVal := structValue.Field(i)
-			reflect.NewAt(fieldVal.Type(), unsafe.Pointer(fieldVal.UnsafeAddr())).Elem().Set(val)
+			if err := setField(structValue.Field(i), val); err != nil {
+				return fmt.Errorf("%v: %w", field.Name, err)
+			}
+		} else if strings.HasPrefix(tag, "@") {
+			val, err := impl.autowireNamed(tag[1:], field.Type)
+			if err != nil {
+				return fmt.Errorf("%v: %w", field.Name, err)
+			}
+
+			if err := setField(structValue.Field(i), val); err != nil {
+				return fmt.Errorf("%v: %w", field.Name, err)
+			}
 		} else {
 			val, err := impl.lookupInstance(tag, nil)
 			if err != nil {
-				return fmt.Errorf("%v: %v", field.Name, err)
+				return fmt.Errorf("%v: %w", field.Name, err)
 			}
 
-			fieldVal := structValue.Field(i)
-			reflect.NewAt(fieldVal.Type(), unsafe.Pointer(fieldVal.UnsafeAddr())).Elem().Set(reflect.ValueOf(val))
+			converted, err := impl.convertBoundValue(tag, val, field.Type)
+			if err != nil {
+				return fmt.Errorf("%v: %w", field.Name, err)
+			}
+
+			if err := setField(structValue.Field(i), converted); err != nil {
+				return fmt.Errorf("%v: %w", field.Name, err)
+			}
 		}
 	}
 
 	return nil
 }
 
+// ResolveInto fill all fields (public and private) of the struct valPtr points to by their types, no tags are
+// required, fields tagged with `autowire:"-"` are skipped. It's lighter than AutoWire for ad-hoc aggregation
+//
+//	var deps struct {
+//		DB    *sql.DB
+//		Cache Cache
+//	}
+//	err := c.ResolveInto(&deps)
+func (impl *container) ResolveInto(valPtr interface{}) error {
+	valRef := reflect.ValueOf(valPtr)
+	if !valRef.IsValid() || valRef.Kind() != reflect.Ptr || valRef.IsNil() || valRef.Elem().Kind() != reflect.Struct {
+		return buildInvalidArgsError(fmt.Sprintf("valPtr must be a non-nil pointer to struct, got %T", valPtr))
+	}
+
+	structValue := valRef.Elem()
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.Tag.Get("autowire") == "-" {
+			continue
+		}
+
+		val, err := impl.instanceOfType(field.Type, nil)
+		if err != nil {
+			return fmt.Errorf("%v: %w", field.Name, err)
+		}
+
+		if err := setField(structValue.Field(i), val); err != nil {
+			return fmt.Errorf("%v: %w", field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// MustResolveInto fill all fields of the struct valPtr points to like ResolveInto, if failed, panic it
+func (impl *container) MustResolveInto(valPtr interface{}) {
+	impl.Must(impl.ResolveInto(valPtr))
+}
+
+// funcFieldValue create a container-backed implementation for a function-typed field, every call of the
+// function resolves the binding of typ from container and delegates to it
+func (impl *container) funcFieldValue(typ reflect.Type) (reflect.Value, error) {
+	if _, err := impl.Lookup(typ); err != nil {
+		return reflect.Value{}, buildArgNotInstancedError(err.Error())
+	}
+
+	return makeFunc(typ, func(args []reflect.Value) []reflect.Value {
+		fn, err := impl.instanceOfType(typ, nil)
+		if err != nil {
+			panic(err)
+		}
+
+		if typ.IsVariadic() {
+			return fn.CallSlice(args)
+		}
+
+		return fn.Call(args)
+	})
+}
+
 // Resolve inject args for func by callback
 // callback func(...)
 func (impl *container) Resolve(callback interface{}) error {
-	results, err := impl.Call(callback)
+	callbackValue, ok := callback.(reflect.Value)
+	if !ok {
+		callbackValue = reflect.ValueOf(callback)
+	}
+
+	if !callbackValue.IsValid() {
+		return buildInvalidArgsError("callback is nil")
+	}
+
+	returnValues, err := impl.invoke(callbackValue, nil)
 	if err != nil {
 		return err
 	}
 
-	if len(results) == 1 && results[0] != nil {
-		if err, ok := results[0].(error); ok {
+	if len(returnValues) == 1 {
+		if err, ok := returnValues[0].Interface().(error); ok && err != nil {
 			return err
 		}
 	}
@@ -315,12 +584,147 @@ func (impl *container) CallWithProvider(callback interface{}, provider EntitiesP
 		return nil, buildInvalidArgsError("callback is nil")
 	}
 
-	args, err := impl.funcArgs(callbackValue.Type(), provider)
+	returnValues, err := impl.invoke(callbackValue, provider)
 	if err != nil {
 		return nil, err
 	}
 
-	returnValues := callbackValue.Call(args)
+	results := make([]interface{}, len(returnValues))
+	for index, val := range returnValues {
+		results[index] = val.Interface()
+	}
+
+	return results, nil
+}
+
+// invoke call the callback with args injected, args are kept in pooled buffers
+func (impl *container) invoke(callbackValue reflect.Value, provider EntitiesProvider) ([]reflect.Value, error) {
+	if callbackValue.Kind() != reflect.Func {
+		return nil, buildInvalidArgsError(fmt.Sprintf("callback must be a func, got %v", callbackValue.Type()))
+	}
+
+	// only the context of CallWithContext aborts the callback, callbacks still run after the context of
+	// container is done (such as cleanups on shutdown)
+	ctx, _ := providedContext(provider)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("(%v) %w", callbackValue.Type(), err)
+	}
+
+	argsFunc := impl.funcArgs
+	if impl.callMemoization {
+		argsFunc = impl.memoizedFuncArgs
+	}
+
+	args, err := argsFunc(callbackValue.Type(), provider)
+	if err != nil {
+		return nil, err
+	}
+	defer args.release()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("(%v) %w", callbackValue.Type(), err)
+	}
+
+	return callbackValue.Call(args.values), nil
+}
+
+// resolutionContextKey is the key of the entity carrying the context of CallWithContext in provider
+type resolutionContextKey struct{}
+
+// CallWithContext call the callback like Call, the resolution of its args aborts between constructors once
+// ctx is done, ctx.Err() wrapped with the key being created is returned. ctx is not injected into constructors,
+// they receive the context of container as usual
+func (impl *container) CallWithContext(ctx context.Context, callback any) ([]any, error) {
+	if ctx == nil {
+		return nil, buildInvalidArgsError("ctx is nil")
+	}
+
+	entity := impl.newEntity(resolutionContextKey{}, contextType, nil, false, false)
+	entity.value = ctx
+
+	return impl.CallWithProvider(callback, func() []*Entity { return []*Entity{entity} })
+}
+
+// providedContext return the context of CallWithContext carried by provider, context.Background() if absent
+func providedContext(provider EntitiesProvider) (context.Context, bool) {
+	if provider != nil {
+		for _, e := range provider() {
+			if ctx, ok := e.value.(context.Context); ok && e.key == (resolutionContextKey{}) {
+				return ctx, true
+			}
+		}
+	}
+
+	return context.Background(), false
+}
+
+// resolutionContext return the context which aborts the resolution: the one of CallWithContext carried by
+// provider, or the context bound to the nearest container (see NewWithContext and ReplaceContext)
+func (impl *container) resolutionContext(provider EntitiesProvider) context.Context {
+	if ctx, ok := providedContext(provider); ok {
+		return ctx
+	}
+
+	for cc := impl; cc != nil; {
+		if ctx := cc.ctx.Load(); ctx != nil {
+			return *ctx
+		}
+
+		parent, ok := cc.Parent().(*container)
+		if !ok {
+			break
+		}
+
+		cc = parent
+	}
+
+	return context.Background()
+}
+
+// CallWithDefaults call the callback like Call, but args whose types are not bound in container are substituted
+// by defaults instead of failing, so scripts/CLIs can invoke functions even when some dependencies are missing.
+// A nil default is the zero value of its type
+//
+//	results, err := c.CallWithDefaults(run, map[reflect.Type]any{
+//		reflect.TypeOf((*Logger)(nil)).Elem(): nopLogger{},
+//	})
+func (impl *container) CallWithDefaults(callback interface{}, defaults map[reflect.Type]any) ([]interface{}, error) {
+	callbackValue := reflect.ValueOf(callback)
+	if !callbackValue.IsValid() || callbackValue.Kind() != reflect.Func {
+		return nil, buildInvalidArgsError(fmt.Sprintf("callback must be a func, got %T", callback))
+	}
+
+	callbackType := callbackValue.Type()
+	args := acquireArgs(callbackType.NumIn())
+	defer args.release()
+
+	for i := range args.values {
+		argType := callbackType.In(i)
+		val, err := impl.instanceOfType(argType, nil)
+		if err == nil {
+			args.values[i] = val
+			continue
+		}
+
+		// bound args missing their dependencies are wiring errors, defaults only substitute unbound args
+		def, ok := defaults[argType]
+		if !ok || !errors.Is(err, ErrObjectNotFound) || impl.findEntity(argType) != nil {
+			return nil, err
+		}
+
+		defValue := reflect.ValueOf(def)
+		if !defValue.IsValid() {
+			defValue = reflect.Zero(argType)
+		}
+
+		if !defValue.Type().AssignableTo(argType) {
+			return nil, buildInvalidArgsError(fmt.Sprintf("default value %T is not assignable to %v", def, argType))
+		}
+
+		args.values[i] = defValue
+	}
+
+	returnValues := callbackValue.Call(args.values)
 	results := make([]interface{}, len(returnValues))
 	for index, val := range returnValues {
 		results[index] = val.Interface()
@@ -339,10 +743,52 @@ func (impl *container) Get(key interface{}) (interface{}, error) {
 	return impl.lookupInstance(key, nil)
 }
 
-func (impl *container) lookupEntity(lookupKeys []any, provider func() []*Entity) *Entity {
-	impl.lock.RLock()
-	defer impl.lock.RUnlock()
+// GetLocal get instance by key from current container only, parent containers are not consulted, so
+// request-scoped code can detect whether something was explicitly bound in current scope
+func (impl *container) GetLocal(key interface{}) (interface{}, error) {
+	if !reflect.ValueOf(key).IsValid() {
+		return nil, buildInvalidArgsError("key is nil")
+	}
+
+	lookupKeys, _ := impl.resolveLookupKeys(key)
+	obj := impl.lookupEntity(lookupKeys, nil)
+	if obj == nil {
+		return nil, buildObjectNotFoundError(fmt.Sprintf("key=%v not bound in current container", key))
+	}
+
+	return obj.resolve(nil)
+}
+
+// ResolveLocal inject args for callback like Resolve, but the args are looked up from current container only
+// (see GetLocal), dependencies of their constructors are resolved as usual
+func (impl *container) ResolveLocal(callback interface{}) error {
+	callbackValue := reflect.ValueOf(callback)
+	if !callbackValue.IsValid() || callbackValue.Kind() != reflect.Func {
+		return buildInvalidArgsError(fmt.Sprintf("callback must be a func, got %T", callback))
+	}
+
+	callbackType := callbackValue.Type()
+	args := make([]reflect.Value, callbackType.NumIn())
+	for i := range args {
+		arg, err := impl.GetLocal(callbackType.In(i))
+		if err != nil {
+			return wrapArgNotInstancedError(err)
+		}
+
+		args[i] = reflect.ValueOf(arg)
+	}
+
+	results := callbackValue.Call(args)
+	if len(results) == 1 {
+		if err, ok := results[0].Interface().(error); ok && err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
+func (impl *container) lookupEntity(lookupKeys []any, provider func() []*Entity) *Entity {
 	if provider != nil {
 		for _, obj := range provider() {
 			for _, lookupKey := range lookupKeys {
@@ -353,31 +799,118 @@ func (impl *container) lookupEntity(lookupKeys []any, provider func() []*Entity)
 		}
 	}
 
+	impl.lock.RLock()
 	for _, lookupKey := range lookupKeys {
 		if obj, ok := impl.entities[lookupKey]; ok {
+			impl.lock.RUnlock()
 			return obj
 		}
 	}
+	impl.lock.RUnlock()
 
-	return nil
+	return impl.matchEntity(lookupKeys)
 }
 
 func (impl *container) lookupInstance(key interface{}, provider func() []*Entity) (interface{}, error) {
+	if !reflect.ValueOf(key).IsValid() {
+		return nil, buildInvalidArgsError("key is nil, expect a type (such as new(UserRepo) or reflect.Type) or a string key")
+	}
+
+	if typ, ok := key.(reflect.Type); ok && provider == nil {
+		if val, ok := impl.fastGet(typ); ok {
+			return val, nil
+		}
+	}
+
+	val, err := impl.lookupInstanceWithDepth(impl, key, provider, 0, impl.maxLookupDepth)
+	if path, ok := impl.isPathKey(key, err); ok {
+		if pathVal, pathErr := impl.lookupPath(path, provider); pathErr == nil {
+			return pathVal, nil
+		}
+	}
+
+	if err != nil {
+		return impl.lookupFallback(key, err)
+	}
+
+	return val, nil
+}
+
+// lookupInstanceWithDepth lookup instance from current container and its parents for origin (the container the
+// lookup starts from), depth is the level of current container relative to the original one
+func (impl *container) lookupInstanceWithDepth(origin *container, key interface{}, provider func() []*Entity, depth int, maxDepth int) (interface{}, error) {
+	if err := impl.closedError(key); err != nil {
+		return nil, err
+	}
+
+	generation := impl.generation.Load()
 	lookupKey, possibleKey := impl.resolveLookupKeys(key)
 	obj := impl.lookupEntity(lookupKey, provider)
 	if obj != nil {
-		return obj.Value(provider)
+		val, err := obj.resolveFor(origin, provider)
+		if err == nil && impl == origin && provider == nil {
+			impl.rememberFast(key, obj, generation)
+		}
+
+		return val, err
+	}
+
+	if len(impl.bindingSources()) > 0 {
+		obj, err := impl.sourceEntity(lookupKey)
+		if err != nil {
+			return nil, err
+		}
+
+		if obj != nil {
+			return obj.resolveFor(origin, provider)
+		}
+	}
+
+	if parent := impl.Parent(); parent != nil {
+		if maxDepth > 0 && depth >= maxDepth {
+			return nil, buildObjectNotFoundError(fmt.Sprintf("key=%v not found within max lookup depth %d", key, maxDepth))
+		}
+
+		if maxDepth <= 0 {
+			if obj, cached := impl.ancestorEntity(key, lookupKey); cached {
+				if obj != nil {
+					if err := obj.c.closedError(key); err != nil {
+						return nil, err
+					}
+
+					return obj.resolveFor(origin, provider)
+				}
+
+				return nil, impl.notFoundError(key, possibleKey)
+			}
+		}
+
+		if p, ok := parent.(*container); ok {
+			return p.lookupInstanceWithDepth(origin, key, provider, depth+1, maxDepth)
+		}
+
+		return parent.Get(key)
 	}
 
-	if impl.parent != nil {
-		return impl.parent.Get(key)
+	return nil, impl.notFoundError(key, possibleKey)
+}
+
+// closedError return an ErrScopeClosed error for resolving key if current container is shut down
+func (impl *container) closedError(key any) error {
+	if !impl.closed.Load() {
+		return nil
 	}
 
+	return buildScopeClosedError(fmt.Sprintf("can not resolve key=%v from %s container %s", key, impl.scope.Kind, impl.scope.ID))
+}
+
+// notFoundError build the error of key not found, possibleKey is suggested if not nil
+func (impl *container) notFoundError(key any, possibleKey any) error {
 	errMsg := fmt.Sprintf("key=%v not found", key)
 	if possibleKey != nil {
 		errMsg = fmt.Sprintf("%s, may be you want %v", errMsg, possibleKey)
 	}
-	return nil, buildObjectNotFoundError(errMsg)
+	return buildObjectNotFoundError(errMsg)
 }
 
 // resolveLookupKeys 解析用于查找的 Keys
@@ -385,6 +918,8 @@ func (impl *container) lookupInstance(key interface{}, provider func() []*Entity
 //  1. matchKey == lookupKey ，则匹配
 //  2. matchKey == type(lookupKey) ，则匹配
 //  3. 如果 lookupKey 是指向接口的指针，则解析成接口本身，与 matchKey 比较，相等则匹配
+//
+// 以上规则均未匹配时，再由 WithKeyMatchers 配置的 KeyMatcher 依次匹配（见 matchEntity）
 func (impl *container) resolveLookupKeys(lookupKey interface{}) (lookupKeys []any, possibleKey any) {
 	keyReflectType, lookupKeyIsReflectType := lookupKey.(reflect.Type)
 	if !lookupKeyIsReflectType {
@@ -407,8 +942,7 @@ func (impl *container) resolveLookupKeys(lookupKey interface{}) (lookupKeys []an
 		}
 	case reflect.Struct:
 		if !lookupKeyIsReflectType {
-			reflectValue := reflect.ValueOf(lookupKey)
-			possibleKey = reflectValue.Addr().Type()
+			possibleKey = reflect.PointerTo(keyReflectType)
 		}
 	}
 
@@ -425,63 +959,248 @@ func (impl *container) MustGet(key interface{}) interface{} {
 	return res
 }
 
-func (impl *container) funcArgs(t reflect.Type, provider func() []*Entity) ([]reflect.Value, error) {
-	argsSize := t.NumIn()
-	argValues := make([]reflect.Value, argsSize)
-	for i := 0; i < argsSize; i++ {
+// funcArgs create args for calling a func of type t, the returned buffer should be released after the call
+func (impl *container) funcArgs(t reflect.Type, provider func() []*Entity) (*argsBuf, error) {
+	args := acquireArgs(t.NumIn())
+	for i := range args.values {
+		val, err := impl.instanceOfType(t.In(i), provider)
+		if err != nil {
+			args.release()
+			return nil, err
+		}
+
+		args.values[i] = val
+	}
+
+	return args, nil
+}
+
+// memoizedFuncArgs create args like funcArgs, but args of the same type share one instance, so a prototype
+// needed by several args is created only once
+func (impl *container) memoizedFuncArgs(t reflect.Type, provider func() []*Entity) (*argsBuf, error) {
+	memo := make(map[reflect.Type]reflect.Value)
+	args := acquireArgs(t.NumIn())
+	for i := range args.values {
 		argType := t.In(i)
+		if val, ok := memo[argType]; ok {
+			args.values[i] = val
+			continue
+		}
+
 		val, err := impl.instanceOfType(argType, provider)
 		if err != nil {
-			return argValues, err
+			args.release()
+			return nil, err
 		}
 
-		argValues[i] = val
+		memo[argType] = val
+		args.values[i] = val
 	}
 
-	return argValues, nil
+	return args, nil
 }
 
 func (impl *container) instanceOfType(t reflect.Type, provider func() []*Entity) (reflect.Value, error) {
+	if isParamStruct(t) {
+		return impl.paramStructOf(t, provider)
+	}
+
+	if isFactoryType(t) {
+		return impl.factoryOf(t, provider), nil
+	}
+
 	arg, err := impl.lookupInstance(t, provider)
 	if err != nil {
-		return reflect.Value{}, buildArgNotInstancedError(err.Error())
+		return reflect.Value{}, wrapArgNotInstancedError(err)
 	}
 
 	return reflect.ValueOf(arg), nil
 }
 
-// Keys return all keys
+// Keys return all keys, ordered by priority (higher first) and registration order
 func (impl *container) Keys() []interface{} {
-	impl.lock.RLock()
-	defer impl.lock.RUnlock()
-
-	results := make([]any, 0, len(impl.entities))
-	for k := range impl.entities {
-		results = append(results, k)
+	entities := impl.sortedEntities()
+	results := make([]any, 0, len(entities))
+	for _, e := range entities {
+		results = append(results, e.key)
 	}
 
 	return results
 }
 
-// CanOverride returns whether the key can be overridden
-func (impl *container) CanOverride(key interface{}) (bool, error) {
+// sortedEntities return all entities of current container, ordered by priority (higher first) and registration order
+func (impl *container) sortedEntities() []*Entity {
 	impl.lock.RLock()
-	defer impl.lock.RUnlock()
+	entities := make([]*Entity, 0, len(impl.entities))
+	for _, e := range impl.entities {
+		entities = append(entities, e)
+	}
+	impl.lock.RUnlock()
+
+	sort.Slice(entities, func(i, j int) bool {
+		if entities[i].priority != entities[j].priority {
+			return entities[i].priority > entities[j].priority
+		}
+
+		return entities[i].index < entities[j].index
+	})
+
+	return entities
+}
+
+// ResolveAll return instances of all bindings whose type is assignable to the type of key, such as all
+// implementations of an interface. Instances are ordered by priority (higher first) and registration order,
+// bindings from current container come before bindings from parents, keys shadowed by a child are skipped
+func (impl *container) ResolveAll(key interface{}) ([]interface{}, error) {
+	if !reflect.ValueOf(key).IsValid() {
+		return nil, buildInvalidArgsError("key is nil")
+	}
 
-	for _, obj := range impl.entities {
-		if obj.key == key {
-			return obj.overridable, nil
+	lookupKeys, _ := impl.resolveLookupKeys(key)
+	typ := lookupKeys[len(lookupKeys)-1].(reflect.Type)
+
+	results := make([]any, 0)
+	seen := make(map[any]bool)
+	for _, cc := range append([]Container{impl}, impl.Ancestors()...) {
+		c, ok := cc.(*container)
+		if !ok {
+			break
+		}
+
+		for _, e := range c.sortedEntities() {
+			if seen[e.key] || e.typ == nil || !e.typ.AssignableTo(typ) {
+				continue
+			}
+
+			seen[e.key] = true
+
+			val, err := e.resolve(nil)
+			if err != nil {
+				return nil, err
+			}
+
+			results = append(results, val)
 		}
 	}
 
+	return results, nil
+}
+
+// CanOverride returns whether the key can be overridden, key is normalized in the same way as Get
+func (impl *container) CanOverride(key interface{}) (bool, error) {
+	if !reflect.ValueOf(key).IsValid() {
+		return true, buildInvalidArgsError("key is nil")
+	}
+
+	lookupKeys, _ := impl.resolveLookupKeys(key)
+	if obj := impl.lookupEntity(lookupKeys, nil); obj != nil {
+		return obj.overridable, nil
+	}
+
 	return true, buildObjectNotFoundError(fmt.Sprintf("key=%#v not found", key))
 }
 
+// Reserve reserve keys to prevent accidental binding, binding to a reserved key (including override and Unbind)
+// fails with ErrReservedKey unless the binding is marked with Privileged option. Keys are normalized in
+// the same way as Get, for example new(Container) reserves the Container interface
+func (impl *container) Reserve(keys ...any) error {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
+	for _, key := range keys {
+		if !reflect.ValueOf(key).IsValid() {
+			return buildInvalidArgsError("key is nil")
+		}
+
+		if k, ok := key.(string); ok {
+			impl.reserved[k] = true
+			continue
+		}
+
+		lookupKeys, _ := impl.resolveLookupKeys(key)
+		for _, k := range lookupKeys {
+			impl.reserved[k] = true
+		}
+	}
+
+	return nil
+}
+
+// Unbind remove the binding of key from current container, key is normalized in the same way as Get
+func (impl *container) Unbind(key interface{}) error {
+	if !reflect.ValueOf(key).IsValid() {
+		return buildInvalidArgsError("key is nil")
+	}
+
+	lookupKeys, _ := impl.resolveLookupKeys(key)
+
+	defer impl.notifyChanges()
+
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
+	if impl.frozen {
+		return buildFrozenError(fmt.Sprintf("key=%v can not be unbound after Freeze", key))
+	}
+
+	for _, lookupKey := range lookupKeys {
+		if e, ok := impl.entities[lookupKey]; ok {
+			if e.builtin && !e.overridable {
+				return buildBuiltinBindingError(fmt.Sprintf("key=%v is bound by container itself and can not be unbound", lookupKey))
+			}
+
+			if impl.reserved[lookupKey] {
+				return buildReservedKeyError(fmt.Sprintf("key=%v is reserved, it can not be unbound", lookupKey))
+			}
+
+			delete(impl.entities, lookupKey)
+			impl.bumpGeneration()
+			if impl.stacks != nil {
+				delete(impl.stacks, lookupKey)
+			}
+			return nil
+		}
+	}
+
+	return buildObjectNotFoundError(fmt.Sprintf("key=%#v not found", key))
+}
+
+// checkKeyCollision 检查 key 是否与已注册的类型名称（或字符串 key）相同，调用方需要持有锁
+func (impl *container) checkKeyCollision(key any) error {
+	if !impl.keyCollisionCheck {
+		return nil
+	}
+
+	switch k := key.(type) {
+	case string:
+		for existKey := range impl.entities {
+			if typ, ok := existKey.(reflect.Type); ok && typ.String() == k {
+				return buildKeyCollisionError(fmt.Sprintf("string key %s is identical to the name of type %v", k, typ))
+			}
+		}
+	case reflect.Type:
+		if _, ok := impl.entities[k.String()]; ok {
+			return buildKeyCollisionError(fmt.Sprintf("the name of type %v is identical to string key %s", k, k.String()))
+		}
+	}
+
+	return nil
+}
+
+// isValidKey 判断 key 是否允许绑定，reflect.Type 类型的 key 以其表示的类型为准
+func (impl *container) isValidKey(key any) error {
+	if typ, ok := key.(reflect.Type); ok {
+		return impl.isValidKeyKind(typ.Kind())
+	}
+
+	return impl.isValidKeyKind(reflect.TypeOf(key).Kind())
+}
+
 // isValidKeyKind 判断类型是否允许作为key
 func (impl *container) isValidKeyKind(kind reflect.Kind) error {
-	if kind == reflect.Struct || kind == reflect.Interface || kind == reflect.Ptr {
+	if kind == reflect.Struct || kind == reflect.Interface || kind == reflect.Ptr || kind == reflect.Func {
 		return nil
 	}
 
-	return buildInvalidArgsError(fmt.Sprintf("the type of key can not be a %v", kind))
+	return buildInvalidArgsError(fmt.Sprintf("the type of key can not be a %v, expect a struct, interface, pointer or func", kind))
 }
@@ -2,6 +2,7 @@ package ioc_test
 
 import (
 	"github.com/mylxsw/go-ioc"
+	"reflect"
 	"strconv"
 	"testing"
 )
@@ -50,3 +51,41 @@ func BenchmarkContainerImpl_Keys(b *testing.B) {
 		cc.Keys()
 	}
 }
+
+// 824534	      2118 ns/op	     272 B/op	      10 allocs/op
+// 850156	      1442 ns/op	     176 B/op	       9 allocs/op
+func BenchmarkContainerImpl_ResolveArgs(b *testing.B) {
+	cc := buildContainer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cc.MustResolve(func(userRepo *UserRepo, userService *UserService, roleService *RoleService, demo InterfaceDemo) {
+			// DO NOTHING
+		})
+	}
+}
+
+// 1694308	       680.2 ns/op	     160 B/op	       8 allocs/op
+func BenchmarkCheckedResolve(b *testing.B) {
+	cc := buildContainer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = ioc.CheckedResolve4(cc, func(userRepo *UserRepo, userService *UserService, roleService *RoleService, demo InterfaceDemo) error {
+			return nil
+		})
+	}
+}
+
+// 2000000	        37.41 ns/op
+func BenchmarkContainerImpl_GetSingleton(b *testing.B) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	c.MustGet(new(UserRepo))
+
+	typ := reflect.TypeOf(&UserRepo{})
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.MustGet(typ)
+		}
+	})
+}
@@ -1,13 +1,27 @@
 package ioc_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/mylxsw/go-ioc"
+	"github.com/mylxsw/go-ioc/ioctest"
 )
 
 type GetUserInterface interface {
@@ -156,20 +170,96 @@ func TestInterfaceInjection(t *testing.T) {
 
 // TestWithContext 测试默认添加 Context 实例
 func TestWithContext(t *testing.T) {
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
-	defer cancel()
+	ctx, cancel := context.WithCancel(context.Background())
 
 	c := ioc.NewWithContext(ctx)
+	cancel()
+
 	c.MustResolve(func(ctx context.Context) {
-		startTime := time.Now().UnixNano()
 		<-ctx.Done()
 
-		if (time.Now().UnixNano()-startTime)/1000/1000 < 100 {
+		if !errors.Is(ctx.Err(), context.Canceled) {
 			t.Error("test failed")
 		}
 	})
+}
+
+type canceledChain struct{ name string }
+
+// TestResolutionCanceled 测试 ctx 结束后中止构造函数链
+func TestResolutionCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var created []string
+
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo {
+		created = append(created, "repo")
+		cancel()
+		return &UserRepo{}
+	})
+	c.MustSingleton(func(repo *UserRepo) *UserService {
+		created = append(created, "service")
+		return &UserService{repo: repo}
+	})
+	c.MustPrototype(func(svc *UserService) canceledChain {
+		created = append(created, "chain")
+		return canceledChain{}
+	})
+
+	_, err := c.CallWithContext(ctx, func(chain canceledChain) {
+		t.Error("test failed: callback should not be called")
+	})
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "(*ioc_test.UserService) context canceled") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if len(created) != 1 || created[0] != "repo" {
+		t.Errorf("test failed: %v", created)
+	}
+
+	// 已经创建的单例不受影响，未中止的 ctx 可以继续完成解析
+	if _, err := c.CallWithContext(context.Background(), func(chain canceledChain) {}); err != nil || len(created) != 3 {
+		t.Errorf("test failed: %v, %v", err, created)
+	}
+
+	// 容器绑定的 ctx 结束后，新的构造函数链同样会被中止，但回调函数依然可以执行
+	bound, cancelBound := context.WithCancel(context.Background())
+	c2 := ioc.NewWithContext(bound)
+	c2.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	cancelBound()
+
+	if _, err := c2.Get(new(UserRepo)); !errors.Is(err, context.Canceled) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	c2.MustResolve(func(c ioc.Container) {})
+
+	// 子容器中调用时，父容器中绑定的构造函数链同样会被中止
+	ctx, cancel = context.WithCancel(context.Background())
+	created = nil
+	parent := ioc.New()
+	parent.MustSingleton(func() *UserRepo {
+		created = append(created, "repo")
+		cancel()
+		return &UserRepo{}
+	})
+	parent.MustSingleton(func(repo *UserRepo) *UserService {
+		created = append(created, "service")
+		return &UserService{repo: repo}
+	})
 
-	time.Sleep(200 * time.Millisecond)
+	if _, err := ioc.Extend(parent).CallWithContext(ctx, func(*UserService) {}); !errors.Is(err, context.Canceled) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if len(created) != 1 || created[0] != "repo" {
+		t.Errorf("test failed: %v", created)
+	}
+
+	c2.Must(c2.ReplaceContext(context.Background()))
+	if _, err := ioc.Extend(c2).Get(new(UserRepo)); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
 }
 
 type TestObject struct {
@@ -247,11 +337,11 @@ func TestSearchAdvanced(t *testing.T) {
 		fmt.Println(userRepo.connStr)
 	})
 	err := c.Resolve(func(userService *UserService) { fmt.Println(userService.GetUser()) })
-	if err == nil || err.Error() != "args not instanced: not found in container: key=*container_test.UserService not found, may be you want container_test.UserService" {
+	if err == nil || err.Error() != "args not instanced: not found in container: key=*ioc_test.UserService not found, may be you want ioc_test.UserService" {
 		t.Errorf("test failed")
 	}
 	err = c.Resolve(func(userRepo UserRepo) { fmt.Println(userRepo.connStr) })
-	if err == nil || err.Error() != "args not instanced: not found in container: key=container_test.UserRepo not found" {
+	if err == nil || err.Error() != "args not instanced: not found in container: key=ioc_test.UserRepo not found" {
 		t.Errorf("test failed")
 	}
 }
@@ -444,3 +534,3998 @@ func TestResolveReflectValue(t *testing.T) {
 
 	cc.MustResolve(reflect.ValueOf(callback))
 }
+
+// TestHierarchy 测试容器层级信息
+func TestHierarchy(t *testing.T) {
+	root := ioc.New()
+	root.MustBindValue("conn_str", "root:root@/my_db?charset=utf8")
+	root.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "root"} })
+
+	child := ioc.Extend(root)
+	grandson := ioc.Extend(child)
+	grandson.MustBindValue("name", "grandson")
+
+	if root.Parent() != nil {
+		t.Error("test failed")
+	}
+
+	if grandson.Parent() != child {
+		t.Error("test failed")
+	}
+
+	ancestors := grandson.Ancestors()
+	if len(ancestors) != 2 || ancestors[0] != child || ancestors[1] != root {
+		t.Error("test failed")
+	}
+
+	info, err := grandson.Lookup(new(UserRepo))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if info.Container != root || info.Type != reflect.TypeOf(&UserRepo{}) {
+		t.Error("test failed")
+	}
+
+	info, err = grandson.Lookup("name")
+	if err != nil || info.Container != grandson {
+		t.Error("test failed")
+	}
+
+	if _, err := child.Lookup("name"); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Error("test failed")
+	}
+}
+
+// TestExtendFromCycle 测试容器继承循环检测
+func TestExtendFromCycle(t *testing.T) {
+	a := ioc.New()
+	b := ioc.Extend(a)
+	c := ioc.Extend(b)
+
+	if err := a.TryExtendFrom(c); !errors.Is(err, ioc.ErrParentCycle) {
+		t.Error("test failed")
+	}
+
+	if err := a.TryExtendFrom(a); !errors.Is(err, ioc.ErrParentCycle) {
+		t.Error("test failed")
+	}
+
+	// ExtendFrom 保持原有签名，形成环时保持原父容器不变
+	a.ExtendFrom(c)
+	if a.Parent() != nil {
+		t.Error("test failed")
+	}
+
+	func() {
+		defer func() {
+			if err, _ := recover().(error); !errors.Is(err, ioc.ErrParentCycle) {
+				t.Errorf("test failed: %v", err)
+			}
+		}()
+
+		a.MustExtendFrom(b)
+	}()
+
+	d := ioc.New()
+	if err := d.TryExtendFrom(c); err != nil {
+		t.Error(err)
+	}
+
+	e := ioc.New()
+	e.ExtendFrom(d)
+	if e.Parent() != d {
+		t.Error("test failed")
+	}
+}
+
+// TestMaxLookupDepth 测试父容器查找深度限制
+func TestMaxLookupDepth(t *testing.T) {
+	root := ioc.New()
+	root.MustBindValue("name", "root")
+
+	child := ioc.Extend(root)
+	if child.MustGet("name") != "root" {
+		t.Error("test failed")
+	}
+
+	limited := ioc.Extend(ioc.Extend(root), ioc.WithMaxLookupDepth(1))
+	if _, err := limited.Get("name"); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Error("test failed")
+	}
+
+	unlimited := ioc.Extend(ioc.Extend(root), ioc.WithMaxLookupDepth(2))
+	if unlimited.MustGet("name") != "root" {
+		t.Error("test failed")
+	}
+}
+
+// TestKeyCollisionCheck 测试字符串 key 与类型名称冲突检查
+func TestKeyCollisionCheck(t *testing.T) {
+	c := ioc.New(ioc.WithKeyCollisionCheck())
+	if err := c.BindValue("ioc.Container", "与接口同名的value"); !errors.Is(err, ioc.ErrKeyCollision) {
+		t.Error("test failed")
+	}
+
+	c.MustBindValue("*ioc_test.UserRepo", "与结构体同名的value")
+	if err := c.Singleton(func() *UserRepo { return &UserRepo{} }); !errors.Is(err, ioc.ErrKeyCollision) {
+		t.Error("test failed")
+	}
+
+	// 未开启检查时，允许同名
+	c2 := ioc.New()
+	if err := c2.BindValue("ioc.Container", "与接口同名的value"); err != nil {
+		t.Error(err)
+	}
+}
+
+type demo3 struct{}
+
+func (d demo3) String() string { return "demo3" }
+
+// TestOrdering 测试 Keys 及 ResolveAll 的顺序
+func TestOrdering(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(demo1{})
+	c.MustSingleton(ioc.WithOptions(demo2{}, ioc.WithPriority(10)))
+	c.MustSingleton(func() demo3 { return demo3{} })
+	c.MustBindValue("key1", "value1")
+
+	keys := c.Keys()
+	if len(keys) != 14 || keys[0] != reflect.TypeOf(demo2{}) || keys[13] != "key1" {
+		t.Errorf("test failed: %v", keys)
+	}
+
+	// 内置绑定先于用户绑定
+	if keys[1] != reflect.TypeOf((*ioc.Container)(nil)).Elem() {
+		t.Errorf("test failed: %v", keys)
+	}
+
+	child := ioc.Extend(c)
+	child.MustSingleton(ioc.WithOptions(func() InterfaceDemo { return demo1{} }, ioc.WithPriority(-1)))
+
+	all, err := child.ResolveAll(new(InterfaceDemo))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0)
+	for _, item := range all {
+		names = append(names, item.(InterfaceDemo).String())
+	}
+
+	if fmt.Sprint(names) != "[demo1 demo2 demo1 demo3]" {
+		t.Errorf("test failed: %v", names)
+	}
+}
+
+// TestKeyNormalization 测试 CanOverride/HasBound/Unbind 的 key 匹配规则与 Get 一致
+func TestKeyNormalization(t *testing.T) {
+	c := ioc.New()
+	c.MustSingletonOverride(func() *UserRepo { return &UserRepo{} })
+	c.MustSingleton(func() InterfaceDemo { return demo1{} })
+
+	for _, key := range []any{new(UserRepo), (*UserRepo)(nil), reflect.TypeOf(&UserRepo{})} {
+		if !c.HasBound(key) {
+			t.Errorf("test failed: %v", key)
+		}
+
+		overridable, err := c.CanOverride(key)
+		if err != nil || !overridable {
+			t.Errorf("test failed: %v", key)
+		}
+	}
+
+	if overridable, err := c.CanOverride(new(InterfaceDemo)); err != nil || overridable {
+		t.Error("test failed")
+	}
+
+	if err := c.Unbind(new(InterfaceDemo)); err != nil {
+		t.Error(err)
+	}
+
+	if c.HasBound(new(InterfaceDemo)) {
+		t.Error("test failed")
+	}
+
+	if err := c.Unbind(new(InterfaceDemo)); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Error("test failed")
+	}
+}
+
+type userModule struct{}
+
+func (userModule) Register(binder ioc.Binder) error {
+	if err := binder.BindValue("conn_str", "root:root@/my_db?charset=utf8"); err != nil {
+		return err
+	}
+
+	return binder.Singleton(func(c ioc.Container) (*UserRepo, error) {
+		connStr, err := c.Get("conn_str")
+		if err != nil {
+			return nil, err
+		}
+
+		return &UserRepo{connStr: connStr.(string)}, nil
+	})
+}
+
+type serviceModule struct{}
+
+func (serviceModule) Register(binder ioc.Binder) error {
+	return binder.Prototype(func(userRepo *UserRepo) *UserService {
+		return &UserService{repo: userRepo}
+	})
+}
+
+// TestRegisterAll 测试模块自注册
+func TestRegisterAll(t *testing.T) {
+	c := ioc.New()
+	if err := c.RegisterAll(userModule{}, "not a module", serviceModule{}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.MustResolve(func(userService *UserService) {
+		if userService.GetUser() != expectedValue {
+			t.Error("test failed")
+		}
+	})
+
+	// 已经加载过的模块不会重复注册
+	if err := c.Load(userModule{}); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type replicaRepoModule struct{}
+
+func (replicaRepoModule) ModuleName() string { return "replica" }
+
+func (replicaRepoModule) Register(binder ioc.Binder) error {
+	return binder.Singleton(func() *UserRepo { return &UserRepo{connStr: "replica"} })
+}
+
+// TestModuleAttribution 测试绑定归属的模块
+func TestModuleAttribution(t *testing.T) {
+	c := ioc.New()
+	c.MustLoad(userModule{}, serviceModule{})
+	c.MustSingleton(func() InterfaceDemo { return demo1{} })
+
+	modules := make(map[any]string)
+	for _, info := range c.Bindings() {
+		modules[info.Key] = info.Module
+	}
+
+	if modules["conn_str"] != "ioc_test.userModule" || modules[reflect.TypeOf(&UserService{})] != "ioc_test.serviceModule" || modules[reflect.TypeOf((*InterfaceDemo)(nil)).Elem()] != "" {
+		t.Errorf("test failed: %v", modules)
+	}
+
+	for _, node := range c.Graph().Nodes {
+		if node.Key == reflect.TypeOf(&UserRepo{}) && node.Module != "ioc_test.userModule" {
+			t.Errorf("test failed: %+v", node)
+		}
+	}
+
+	// 冲突的错误信息中包含两个模块的名称
+	err := c.Load(replicaRepoModule{})
+	if !errors.Is(err, ioc.ErrRepeatedBind) || !strings.Contains(err.Error(), "bound by module ioc_test.userModule, can not be bound again by module replica") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := c.Singleton(func() *UserRepo { return nil }); err == nil || !strings.Contains(err.Error(), "can not be bound again by a binding outside modules") {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type blockingModule struct {
+	started, proceed chan struct{}
+}
+
+func (blockingModule) ModuleName() string { return "blocking" }
+
+func (m blockingModule) Register(binder ioc.Binder) error {
+	close(m.started)
+	<-m.proceed
+
+	return binder.Singleton(func() *RoleService { return &RoleService{} })
+}
+
+// TestModuleAttributionConcurrent 测试模块加载期间其它 goroutine 中的绑定不会归属于该模块
+func TestModuleAttributionConcurrent(t *testing.T) {
+	var conflicts int
+	c := ioc.New(ioc.WithConflictResolver(func(ioc.Conflict) ioc.ConflictResolution {
+		conflicts++
+		return ioc.KeepLast
+	}))
+
+	m := blockingModule{started: make(chan struct{}), proceed: make(chan struct{})}
+	loaded := make(chan error)
+	go func() { loaded <- c.Load(m) }()
+
+	<-m.started
+	c.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	if err := c.Singleton(func() *UserRepo { return &UserRepo{} }); !errors.Is(err, ioc.ErrRepeatedBind) || conflicts != 0 {
+		t.Errorf("test failed: binding outside modules should not be settled by resolver: %v", err)
+	}
+
+	close(m.proceed)
+	if err := <-loaded; err != nil {
+		t.Fatal(err)
+	}
+
+	if info, _ := c.Lookup(new(UserRepo)); info.Module != "" {
+		t.Errorf("test failed: %+v", info)
+	}
+
+	if info, _ := c.Lookup(new(RoleService)); info.Module != "blocking" {
+		t.Errorf("test failed: %+v", info)
+	}
+}
+
+// TestConflictResolver 测试模块加载时的冲突处理
+func TestConflictResolver(t *testing.T) {
+	var conflicts []ioc.Conflict
+	resolution := ioc.KeepFirst
+	newContainer := func() ioc.Container {
+		c := ioc.New(ioc.WithConflictResolver(func(conflict ioc.Conflict) ioc.ConflictResolution {
+			conflicts = append(conflicts, conflict)
+			return resolution
+		}))
+		c.MustLoad(userModule{})
+		return c
+	}
+
+	c := newContainer()
+	c.MustLoad(replicaRepoModule{})
+	if repo := c.MustGet(new(UserRepo)).(*UserRepo); repo.connStr == "replica" {
+		t.Error("test failed: the first binding should be kept")
+	}
+
+	if len(conflicts) != 1 || conflicts[0].Module != "replica" || conflicts[0].Existing.Module != "ioc_test.userModule" {
+		t.Errorf("test failed: %+v", conflicts)
+	}
+
+	resolution = ioc.KeepLast
+	c = newContainer()
+	c.MustLoad(replicaRepoModule{})
+	if repo := c.MustGet(new(UserRepo)).(*UserRepo); repo.connStr != "replica" {
+		t.Error("test failed: the last binding should be kept")
+	}
+
+	resolution = ioc.RenameTo("replica_repo")
+	c = newContainer()
+	c.MustLoad(replicaRepoModule{})
+	if repo := c.MustGet("replica_repo").(*UserRepo); repo.connStr != "replica" {
+		t.Error("test failed: the new binding should be renamed")
+	}
+
+	if repo := c.MustGet(new(UserRepo)).(*UserRepo); repo.connStr == "replica" {
+		t.Error("test failed: the existing binding should be kept")
+	}
+
+	resolution = ioc.RenameTo(new(InterfaceDemo))
+	if err := newContainer().Load(replicaRepoModule{}); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	resolution = ioc.FailOnConflict
+	if err := newContainer().Load(replicaRepoModule{}); !errors.Is(err, ioc.ErrRepeatedBind) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 不在模块中的绑定不会使用冲突处理
+	resolution = ioc.KeepLast
+	c = newContainer()
+	if err := c.Singleton(func() *UserRepo { return nil }); !errors.Is(err, ioc.ErrRepeatedBind) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type userCommand struct {
+	UserRepo *UserRepo `autowire:"@"`
+	version  string    `autowire:"version"`
+
+	initialized bool
+}
+
+func (cmd *userCommand) PostConstruct() error {
+	if cmd.UserRepo == nil {
+		return errors.New("user repo is required")
+	}
+
+	cmd.initialized = true
+	return nil
+}
+
+// TestNewInstance 测试无需绑定直接创建结构体实例
+func TestNewInstance(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(&UserRepo{connStr: "user pointer"})
+	c.MustBindValue("version", "1.0.1")
+
+	cmd, err := ioc.NewInstance[userCommand](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !cmd.initialized || cmd.version != "1.0.1" || cmd.UserRepo.connStr != "user pointer" {
+		t.Error("test failed")
+	}
+
+	if _, err := ioc.NewInstance[userCommand](ioc.New()); err == nil {
+		t.Error("test failed")
+	}
+
+	if _, err := ioc.NewInstance[string](c); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Error("test failed")
+	}
+}
+
+type ListUsersHandler func(limit int) []string
+type DeleteUserHandler func(id int) error
+
+type userHandlers struct {
+	ListUsers  ListUsersHandler  `autowire:"@"`
+	DeleteUser DeleteUserHandler `autowire:"@"`
+}
+
+// TestAutoWireFuncField 测试函数类型字段的自动注入
+func TestAutoWireFuncField(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(&UserRepo{connStr: "user pointer"})
+	c.MustPrototype(func(repo *UserRepo) ListUsersHandler {
+		return func(limit int) []string {
+			return []string{fmt.Sprintf("%s:%d", repo.connStr, limit)}
+		}
+	})
+	c.MustSingleton(func() DeleteUserHandler {
+		return func(id int) error { return fmt.Errorf("user %d can not be deleted", id) }
+	})
+
+	handlers := userHandlers{}
+	c.MustAutoWire(&handlers)
+
+	if res := handlers.ListUsers(10); len(res) != 1 || res[0] != "user pointer:10" {
+		t.Errorf("test failed: %v", res)
+	}
+
+	if err := handlers.DeleteUser(1); err == nil || err.Error() != "user 1 can not be deleted" {
+		t.Error("test failed")
+	}
+
+	if err := ioc.New().AutoWire(&userHandlers{}); err == nil {
+		t.Error("test failed")
+	}
+}
+
+// TestIdleEviction 测试单例空闲回收
+func TestIdleEviction(t *testing.T) {
+	var created int32
+	disposed := make(chan any, 1)
+
+	c := ioc.New()
+	clock := ioctest.UseFakeClock(c)
+	c.MustSingleton(ioc.WithOptions(func() *UserRepo {
+		atomic.AddInt32(&created, 1)
+		return &UserRepo{connStr: "heavyweight"}
+	}, ioc.WithIdleEviction(50*time.Millisecond), ioc.WithDisposer(func(value any) error {
+		disposed <- value
+		return nil
+	})))
+
+	first := c.MustGet(new(UserRepo))
+	if c.MustGet(new(UserRepo)) != first {
+		t.Error("test failed")
+	}
+
+	clock.Advance(49 * time.Millisecond)
+	if c.MustGet(new(UserRepo)) != first {
+		t.Error("test failed: singleton should not be evicted before idle ttl")
+	}
+
+	clock.Advance(50 * time.Millisecond)
+	if <-disposed != first {
+		t.Error("test failed")
+	}
+
+	if c.MustGet(new(UserRepo)) == first || atomic.LoadInt32(&created) != 2 {
+		t.Error("test failed")
+	}
+}
+
+// TestMaxInstances 测试原型实例数量限制
+func TestMaxInstances(t *testing.T) {
+	c := ioc.New()
+	c.MustPrototype(ioc.WithOptions(func() *UserRepo { return &UserRepo{} }, ioc.WithMaxInstances(2)))
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Get(new(UserRepo)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := c.Get(new(UserRepo)); !errors.Is(err, ioc.ErrMaxInstancesExceeded) {
+		t.Error("test failed")
+	}
+
+	info, _ := c.Lookup(new(UserRepo))
+	if info.Instances != 2 || info.CreationRate <= 0 {
+		t.Errorf("test failed: %v", info)
+	}
+
+	exceeded := make([]int64, 0)
+	c2 := ioc.New()
+	c2.MustPrototype(ioc.WithOptions(func() *UserRepo { return &UserRepo{} }, ioc.WithMaxInstances(1), ioc.OnMaxInstancesExceeded(func(key any, count int64) error {
+		exceeded = append(exceeded, count)
+		return nil
+	})))
+
+	for i := 0; i < 3; i++ {
+		if _, err := c2.Get(new(UserRepo)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if fmt.Sprint(exceeded) != "[2 3]" {
+		t.Errorf("test failed: %v", exceeded)
+	}
+}
+
+// TestConstructorConcurrency 测试构造函数并发数量限制
+func TestConstructorConcurrency(t *testing.T) {
+	var running, maxRunning int32
+	entered, release := make(chan struct{}, 10), make(chan struct{})
+
+	c := ioc.New(ioc.WithConstructorConcurrency(2))
+	c.MustPrototype(func() *UserRepo {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+
+		for {
+			old := atomic.LoadInt32(&maxRunning)
+			if current <= old || atomic.CompareAndSwapInt32(&maxRunning, old, current) {
+				break
+			}
+		}
+
+		entered <- struct{}{}
+		<-release
+		return &UserRepo{}
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.MustGet(new(UserRepo))
+		}()
+	}
+
+	// 两个构造函数同时运行时再放行
+	<-entered
+	<-entered
+	close(release)
+	wg.Wait()
+
+	if atomic.LoadInt32(&maxRunning) != 2 {
+		t.Errorf("test failed: %d", maxRunning)
+	}
+}
+
+// TestConstructorConcurrencyNested 测试构造函数的依赖在获取并发槽之前创建，嵌套依赖不会死锁
+func TestConstructorConcurrencyNested(t *testing.T) {
+	c := ioc.New(ioc.WithConstructorConcurrency(1))
+	c.MustPrototype(func() *UserRepo { return &UserRepo{connStr: "nested"} })
+	c.MustPrototype(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+	c.MustPrototype(func(service *UserService) *TestObject { return &TestObject{Name: service.repo.connStr} })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c.MustGet(new(TestObject)).(*TestObject).Name != "nested" {
+				t.Error("test failed")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestGetAsync 测试异步创建实例
+func TestGetAsync(t *testing.T) {
+	release := make(chan struct{})
+
+	c := ioc.New()
+	c.MustSingleton(func() (*UserRepo, error) {
+		<-release
+		return &UserRepo{connStr: "slow"}, nil
+	})
+
+	f := c.GetAsync(new(UserRepo))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := f.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Error("test failed")
+	}
+
+	close(release)
+
+	val, err := f.Wait(context.Background())
+	if err != nil || val.(*UserRepo).connStr != "slow" {
+		t.Error("test failed")
+	}
+
+	if _, err := c.GetAsync("not_exist").Wait(context.Background()); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Error("test failed")
+	}
+}
+
+// TestDefaultLogger 测试默认绑定的日志对象
+func TestDefaultLogger(t *testing.T) {
+	c := ioc.New()
+	c.MustResolve(func(logger *slog.Logger) {
+		if logger != slog.Default() {
+			t.Error("test failed")
+		}
+	})
+
+	buf := bytes.NewBuffer(nil)
+	c.MustSingletonOverride(func() *slog.Logger { return slog.New(slog.NewTextHandler(buf, nil)) })
+
+	ioc.LoggerFor(c, (*UserRepo)(nil)).Info("hello")
+	if !strings.Contains(buf.String(), "logger=UserRepo type=*ioc_test.UserRepo") {
+		t.Errorf("test failed: %s", buf.String())
+	}
+
+	buf.Reset()
+	ioc.LoggerFor(c, "db").Info("hello")
+	if !strings.Contains(buf.String(), "logger=db") || strings.Contains(buf.String(), "type=") {
+		t.Errorf("test failed: %s", buf.String())
+	}
+}
+
+// TestKubernetes 测试 Kubernetes 环境检测及 Pod 信息绑定
+func TestKubernetes(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	c := ioc.New()
+	c.MustSingleton(ioc.WithCondition(func() InterfaceDemo { return demo1{} }, ioc.OnKubernetes()))
+	c.MustSingleton(ioc.WithCondition(func() InterfaceDemo { return demo2{} }, ioc.NotOnKubernetes()))
+	c.Must(ioc.BindKubernetesValues(c))
+
+	if c.MustGet(new(InterfaceDemo)).(InterfaceDemo).String() != "demo2" || c.HasBoundValue(ioc.KubernetesPodNameKey) {
+		t.Error("test failed")
+	}
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("POD_NAMESPACE", "default")
+	t.Setenv("POD_NAME", "app-7d9f8")
+	t.Setenv("POD_SERVICE_ACCOUNT", "app")
+
+	c2 := ioc.New()
+	c2.Must(ioc.BindKubernetesValues(c2))
+	if c2.MustGet(ioc.KubernetesNamespaceKey) != "default" || c2.MustGet(ioc.KubernetesServiceAccountKey) != "app" {
+		t.Error("test failed")
+	}
+
+	c2.MustResolve(func(pod ioc.KubernetesPod) {
+		if pod.Name != "app-7d9f8" {
+			t.Error("test failed")
+		}
+	})
+}
+
+type requestInfo struct {
+	ID string
+}
+
+// TestLint 测试依赖关系分析
+func TestLint(t *testing.T) {
+	root := ioc.New()
+	root.MustBindValue("db_host", "localhost")
+	root.MustBindValue("db-host", "127.0.0.1")
+	root.MustPrototype(func() *UserRepo { return &UserRepo{} })
+	root.MustSingleton(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+	root.MustSingleton(func(demo InterfaceDemo) RoleService { return RoleService{} })
+	root.MustPrototype(func(req *requestInfo) *UserManager { return &UserManager{} })
+
+	request := ioc.Extend(root)
+	request.MustSingleton(&requestInfo{ID: "123"})
+	request.MustResolve(func(req *requestInfo) {})
+
+	kinds := func(issues []ioc.Issue) map[ioc.IssueKind][]string {
+		res := make(map[ioc.IssueKind][]string)
+		for _, issue := range issues {
+			res[issue.Kind] = append(res[issue.Kind], fmt.Sprint(issue.Key))
+		}
+		return res
+	}
+
+	rootIssues := kinds(ioc.Lint(root))
+	if fmt.Sprint(rootIssues[ioc.IssueCaptivePrototype]) != "[*ioc_test.UserService]" {
+		t.Errorf("test failed: %v", rootIssues)
+	}
+
+	if fmt.Sprint(rootIssues[ioc.IssueUnresolvable]) != "[ioc_test.RoleService *ioc_test.UserManager]" {
+		t.Errorf("test failed: %v", rootIssues)
+	}
+
+	if fmt.Sprint(rootIssues[ioc.IssueUnusedBinding]) != "[db_host db-host *ioc_test.UserService ioc_test.RoleService *ioc_test.UserManager]" {
+		t.Errorf("test failed: %v", rootIssues)
+	}
+
+	if fmt.Sprint(rootIssues[ioc.IssueSimilarKeys]) != "[db_host]" {
+		t.Errorf("test failed: %v", rootIssues)
+	}
+
+	requestIssues := kinds(ioc.Lint(request))
+	if fmt.Sprint(requestIssues[ioc.IssueScopedDependency]) != "[*ioc_test.UserManager]" || len(requestIssues[ioc.IssueUnusedBinding]) != 0 {
+		t.Errorf("test failed: %v", requestIssues)
+	}
+}
+
+// TestReserve 测试保留 key
+func TestReserve(t *testing.T) {
+	c := ioc.New()
+	c.MustSingletonOverride(func() InterfaceDemo { return demo1{} })
+	c.Must(c.Reserve(new(InterfaceDemo), "app.name"))
+
+	if err := c.SingletonOverride(func() InterfaceDemo { return demo2{} }); !errors.Is(err, ioc.ErrReservedKey) {
+		t.Error("test failed")
+	}
+
+	if err := c.BindValue("app.name", "demo"); !errors.Is(err, ioc.ErrReservedKey) {
+		t.Error("test failed")
+	}
+
+	if err := c.Unbind(new(InterfaceDemo)); !errors.Is(err, ioc.ErrReservedKey) {
+		t.Error("test failed")
+	}
+
+	c.MustSingletonOverride(ioc.WithOptions(func() InterfaceDemo { return demo2{} }, ioc.Privileged()))
+	c.MustBindValue("app.name", ioc.WithOptions("demo", ioc.Privileged()))
+
+	if c.MustGet(new(InterfaceDemo)).(InterfaceDemo).String() != "demo2" || c.MustGet("app.name") != "demo" {
+		t.Error("test failed")
+	}
+}
+
+type requestIDKey struct{}
+
+// TestBuiltinBinding 测试内置绑定保护
+func TestBuiltinBinding(t *testing.T) {
+	c := ioc.New()
+	if err := c.Singleton(func() context.Context { return context.TODO() }); !errors.Is(err, ioc.ErrBuiltinBinding) {
+		t.Error("test failed")
+	}
+
+	if err := c.Unbind(new(ioc.Container)); !errors.Is(err, ioc.ErrBuiltinBinding) {
+		t.Error("test failed")
+	}
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "123")
+	c.Must(c.ReplaceContext(ctx))
+	c.MustResolve(func(ctx context.Context) {
+		if ctx.Value(requestIDKey{}) != "123" {
+			t.Error("test failed")
+		}
+	})
+
+	// 内置的 logger 允许覆盖
+	c.MustSingletonOverride(func() *slog.Logger { return slog.Default() })
+}
+
+// TestPushOverride 测试临时覆盖绑定
+func TestPushOverride(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() InterfaceDemo { return demo1{} })
+
+	current := func() string { return c.MustGet(new(InterfaceDemo)).(InterfaceDemo).String() }
+
+	restore1 := c.MustPushOverride(func() InterfaceDemo { return demo2{} })
+	if current() != "demo2" {
+		t.Error("test failed")
+	}
+
+	restore2 := c.MustPushOverride(func() InterfaceDemo { return demo3{} })
+	if current() != "demo3" {
+		t.Error("test failed")
+	}
+
+	restore2()
+	if current() != "demo2" {
+		t.Error("test failed")
+	}
+
+	restore1()
+	restore1()
+	if current() != "demo1" {
+		t.Error("test failed")
+	}
+
+	if err := c.Singleton(func() InterfaceDemo { return demo2{} }); !errors.Is(err, ioc.ErrRepeatedBind) {
+		t.Error("test failed")
+	}
+
+	restore := c.MustPushOverride(&UserRepo{connStr: "temporary"})
+	restore()
+	if c.HasBound(new(UserRepo)) {
+		t.Error("test failed")
+	}
+
+	if _, err := c.PushOverride(func() ioc.Resolver { return nil }); !errors.Is(err, ioc.ErrBuiltinBinding) {
+		t.Error("test failed")
+	}
+}
+
+// TestBindings 测试绑定信息
+func TestBindings(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	c.MustPrototypeOverride(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+	c.MustBindValue("version", "1.0.0")
+	c.MustGet(new(UserRepo))
+
+	infos := make(map[any]ioc.BindingInfo)
+	for _, info := range c.Bindings() {
+		infos[info.Key] = info
+	}
+
+	repo := infos[reflect.TypeOf(&UserRepo{})]
+	if repo.Prototype || repo.Overridable || !repo.Instantiated || repo.Container != c {
+		t.Errorf("test failed: %+v", repo)
+	}
+
+	service := infos[reflect.TypeOf(&UserService{})]
+	if !service.Prototype || !service.Overridable || service.Instantiated || service.Index <= repo.Index {
+		t.Errorf("test failed: %+v", service)
+	}
+
+	if version := infos["version"]; !version.Instantiated || version.Type != reflect.TypeOf("") {
+		t.Errorf("test failed: %+v", version)
+	}
+}
+
+// TestEager 测试单例立即初始化
+func TestEager(t *testing.T) {
+	var created int32
+	c := ioc.New()
+	c.MustSingleton(ioc.WithOptions(func() *UserRepo {
+		atomic.AddInt32(&created, 1)
+		return &UserRepo{}
+	}, ioc.WithEager()))
+	c.MustSingleton(func() RoleService {
+		atomic.AddInt32(&created, 1)
+		return RoleService{}
+	})
+
+	if atomic.LoadInt32(&created) != 1 {
+		t.Error("test failed")
+	}
+
+	err := c.Singleton(ioc.WithOptions(func(demo InterfaceDemo) *UserService { return &UserService{} }, ioc.WithEager()))
+	if !errors.Is(err, ioc.ErrArgsNotInstanced) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type serverConfig struct {
+	Port    int           `autowire:"server.port"`
+	Debug   bool          `autowire:"server.debug"`
+	Timeout time.Duration `autowire:"server.timeout"`
+	Ratio   float64       `autowire:"server.ratio"`
+	Workers uint8         `autowire:"server.workers"`
+}
+
+// TestAutoWireConversion 测试自动注入时的值类型转换
+func TestAutoWireConversion(t *testing.T) {
+	c := ioc.New()
+	c.MustBindValue("server.port", "8080")
+	c.MustBindValue("server.debug", "true")
+	c.MustBindValue("server.timeout", "1m30s")
+	c.MustBindValue("server.ratio", "0.75")
+	c.MustBindValue("server.workers", 16)
+
+	conf := serverConfig{}
+	c.MustAutoWire(&conf)
+
+	if conf.Port != 8080 || !conf.Debug || conf.Timeout != 90*time.Second || conf.Ratio != 0.75 || conf.Workers != 16 {
+		t.Errorf("test failed: %+v", conf)
+	}
+
+	c.MustBindValueOverride("port", "eighty")
+	err := c.AutoWire(&struct {
+		Port int `autowire:"port"`
+	}{})
+	if !errors.Is(err, ioc.ErrValueConversion) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type dbConfig struct {
+	Host string
+	Port int
+}
+
+type appConfig struct {
+	DB      *dbConfig
+	Options map[string]any
+	secret  string
+}
+
+// TestPathLookup 测试使用点分隔路径查找值
+func TestPathLookup(t *testing.T) {
+	c := ioc.New()
+	c.MustBindValue("config", appConfig{
+		DB:      &dbConfig{Host: "localhost", Port: 3306},
+		Options: map[string]any{"charset": "utf8", "pool": map[string]int{"size": 10}},
+		secret:  "123456",
+	})
+	c.MustBindValue("config.db.port", "3307")
+
+	if c.MustGet("config.db.host") != "localhost" || c.MustGet("config.options.charset") != "utf8" {
+		t.Error("test failed")
+	}
+
+	if c.MustGet("config.Options.pool.size") != 10 {
+		t.Error("test failed")
+	}
+
+	// 直接绑定的 key 优先
+	if c.MustGet("config.db.port") != "3307" {
+		t.Error("test failed")
+	}
+
+	for _, key := range []string{"config.secret", "config.db.user", "config.", "cfg.db"} {
+		if _, err := c.Get(key); !errors.Is(err, ioc.ErrObjectNotFound) {
+			t.Errorf("test failed: %s", key)
+		}
+	}
+
+	conf := struct {
+		Host string `autowire:"config.db.host"`
+		Port int    `autowire:"config.db.port"`
+	}{}
+	c.MustAutoWire(&conf)
+	if conf.Host != "localhost" || conf.Port != 3307 {
+		t.Errorf("test failed: %+v", conf)
+	}
+
+	child := ioc.Extend(c)
+	if child.MustGet("config.db.host") != "localhost" {
+		t.Error("test failed")
+	}
+}
+
+// TestSecretValue 测试敏感值的隐藏与解密
+func TestSecretValue(t *testing.T) {
+	c := ioc.New()
+	c.MustBindValue("db.password", ioc.WithOptions("ENC(drowssap)", ioc.SecretMarker(), ioc.WithDecrypt(func(value any) (any, error) {
+		raw := strings.TrimSuffix(strings.TrimPrefix(value.(string), "ENC("), ")")
+		runes := []rune(raw)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes), nil
+	})))
+	c.MustBindValue("db.user", "root")
+
+	if c.MustGet("db.password") != "password" {
+		t.Error("test failed")
+	}
+
+	dump := c.Dump()
+	if strings.Contains(dump, "drowssap") || strings.Contains(dump, "password, ") || !strings.Contains(dump, "db.password: type=string, scope=singleton, instantiated=true, value=******") {
+		t.Errorf("test failed: %s", dump)
+	}
+
+	if !strings.Contains(dump, "value=root") {
+		t.Errorf("test failed: %s", dump)
+	}
+
+	info, _ := c.Lookup("db.password")
+	if !info.Secret {
+		t.Error("test failed")
+	}
+
+	err := c.AutoWire(&struct {
+		Password int `autowire:"db.password"`
+	}{})
+	if !errors.Is(err, ioc.ErrValueConversion) || strings.Contains(err.Error(), "password\"") {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestConcurrentSingleton(t *testing.T) {
+	c := ioc.New()
+
+	var created int64
+	entered, release := make(chan struct{}, 1), make(chan struct{})
+	c.MustSingleton(func() *UserRepo {
+		atomic.AddInt64(&created, 1)
+		entered <- struct{}{}
+		<-release
+		return &UserRepo{connStr: "root:root@/my_db?charset=utf8"}
+	})
+	c.MustSingleton(func(cc ioc.Container, repo *UserRepo) *UserService {
+		// constructors can inspect the container while the singleton is initializing
+		_ = cc.Bindings()
+		_, _ = cc.Lookup(new(UserService))
+		return &UserService{repo: repo}
+	})
+
+	var wg sync.WaitGroup
+	services := make([]*UserService, 20)
+	for i := range services {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			services[i] = c.MustGet(new(UserService)).(*UserService)
+		}(i)
+	}
+
+	// 其他调用等待正在运行的构造函数
+	<-entered
+	close(release)
+	wg.Wait()
+
+	if atomic.LoadInt64(&created) != 1 {
+		t.Errorf("test failed: singleton created %d times", created)
+	}
+
+	for _, s := range services {
+		if s != services[0] {
+			t.Error("test failed: singleton should be shared")
+		}
+	}
+}
+
+func TestSingletonInitializePanic(t *testing.T) {
+	c := ioc.New()
+
+	var calls int64
+	c.MustSingleton(func() *UserRepo {
+		if atomic.AddInt64(&calls, 1) == 1 {
+			panic("boom")
+		}
+		return &UserRepo{}
+	})
+
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = c.Get(new(UserRepo))
+	}()
+
+	if _, err := c.Get(new(UserRepo)); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestCallMemoization(t *testing.T) {
+	for _, memoized := range []bool{false, true} {
+		var opts []ioc.Option
+		if memoized {
+			opts = append(opts, ioc.WithCallMemoization())
+		}
+
+		c := ioc.New(opts...)
+		c.MustPrototype(func() *UserRepo { return &UserRepo{} })
+
+		c.MustResolve(func(r1 *UserRepo, r2 *UserRepo) {
+			if (r1 == r2) != memoized {
+				t.Errorf("test failed: memoized=%v, shared=%v", memoized, r1 == r2)
+			}
+		})
+
+		c.MustResolve(func(r1 *UserRepo) {
+			c.MustResolve(func(r2 *UserRepo) {
+				if r1 == r2 {
+					t.Error("test failed: prototype should not be shared across calls")
+				}
+			})
+		})
+	}
+}
+
+func TestResolveInto(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "root:root@/my_db?charset=utf8"} })
+	c.MustPrototype(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+
+	var deps struct {
+		Repo    *UserRepo
+		service *UserService
+		Skipped *demo3 `autowire:"-"`
+	}
+
+	if err := c.ResolveInto(&deps); err != nil {
+		t.Fatal(err)
+	}
+
+	if deps.Repo == nil || deps.service == nil || deps.service.repo != deps.Repo || deps.Skipped != nil {
+		t.Error("test failed")
+	}
+
+	var missing struct{ Demo *demo3 }
+	if err := c.ResolveInto(&missing); !errors.Is(err, ioc.ErrArgsNotInstanced) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := c.ResolveInto(deps); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestGetLocal(t *testing.T) {
+	parent := ioc.New()
+	parent.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "parent"} })
+
+	child := ioc.Extend(parent)
+	child.MustPrototype(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+
+	if _, err := child.GetLocal(new(UserRepo)); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	service, err := child.GetLocal(new(UserService))
+	if err != nil || service.(*UserService).repo.connStr != "parent" {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := child.ResolveLocal(func(repo *UserRepo) {}); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := child.ResolveLocal(func(service *UserService) error { return nil }); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := child.ResolveLocal(func(service *UserService) int { return 1 }); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := child.ResolveLocal(func(service *UserService) error { return errors.New("failed") }); err == nil || err.Error() != "failed" {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestBindingStack(t *testing.T) {
+	c := ioc.New(ioc.WithBindingStack())
+	c.MustBindValueOverride("listen", ioc.WithOptions(":80", ioc.WithPriority(0)))
+	c.MustBindValueOverride("listen", ioc.WithOptions(":8080", ioc.WithPriority(20)))
+	c.MustBindValueOverride("listen", ioc.WithOptions(":9090", ioc.WithPriority(10)))
+
+	if c.MustGet("listen") != ":8080" {
+		t.Errorf("test failed: %v", c.MustGet("listen"))
+	}
+
+	versions, err := c.GetAllVersions("listen")
+	if err != nil || fmt.Sprint(versions) != "[:8080 :9090 :80]" {
+		t.Errorf("test failed: %v, %v", versions, err)
+	}
+
+	child := ioc.Extend(c)
+	if versions, err := child.GetAllVersions("listen"); err != nil || len(versions) != 3 {
+		t.Errorf("test failed: %v, %v", versions, err)
+	}
+
+	plain := ioc.New()
+	plain.MustBindValueOverride("listen", ":80")
+	plain.MustBindValueOverride("listen", ":8080")
+	if versions, err := plain.GetAllVersions("listen"); err != nil || fmt.Sprint(versions) != "[:8080]" {
+		t.Errorf("test failed: %v, %v", versions, err)
+	}
+}
+
+type paymentGateway interface {
+	Name() string
+}
+
+type paymentGatewayV1 struct{}
+
+func (paymentGatewayV1) Name() string { return "v1" }
+
+type paymentGatewayV2 struct{}
+
+func (paymentGatewayV2) Name() string { return "v2" }
+
+func TestVersionedBinding(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() paymentGateway { return paymentGatewayV1{} })
+	c.Must(c.SingletonVersioned(new(paymentGateway), "v1", func() paymentGateway { return paymentGatewayV1{} }))
+	c.Must(c.PrototypeVersioned(new(paymentGateway), "v2", func() paymentGateway { return paymentGatewayV2{} }))
+	c.Must(c.SingletonVersioned("listen", "v2", ":8080"))
+
+	for version, expected := range map[string]string{"v1": "v1", "v2": "v2"} {
+		gateway, err := c.GetVersion(new(paymentGateway), version)
+		if err != nil || gateway.(paymentGateway).Name() != expected {
+			t.Errorf("test failed: %v, %v", gateway, err)
+		}
+	}
+
+	if c.MustGet(new(paymentGateway)).(paymentGateway).Name() != "v1" {
+		t.Error("test failed")
+	}
+
+	if val, err := ioc.Extend(c).GetVersion("listen", "v2"); err != nil || val != ":8080" {
+		t.Errorf("test failed: %v, %v", val, err)
+	}
+
+	if _, err := c.GetVersion(new(paymentGateway), "v3"); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := c.SingletonVersioned(new(paymentGateway), "v1", func() paymentGateway { return paymentGatewayV2{} }); !errors.Is(err, ioc.ErrRepeatedBind) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestBindStrategy(t *testing.T) {
+	c := ioc.New()
+	c.Must(c.SingletonVersioned(new(paymentGateway), "stable", func() paymentGateway { return paymentGatewayV1{} }))
+	c.Must(c.SingletonVersioned(new(paymentGateway), "canary", func() paymentGateway { return paymentGatewayV2{} }))
+	c.MustSingleton(func() *paymentGatewayV2 { return &paymentGatewayV2{} })
+
+	var choice any = "stable"
+	c.Must(c.BindStrategy(new(paymentGateway), func(r ioc.Resolver) any { return choice }))
+
+	for _, tc := range []struct {
+		choice   any
+		expected string
+	}{{"stable", "v1"}, {"canary", "v2"}, {reflect.TypeOf(&paymentGatewayV2{}), "v2"}} {
+		choice = tc.choice
+		c.MustResolve(func(gateway paymentGateway) {
+			if gateway.Name() != tc.expected {
+				t.Errorf("test failed: choice=%v, got %s", tc.choice, gateway.Name())
+			}
+		})
+	}
+
+	choice = "unknown"
+	if _, err := c.Get(new(paymentGateway)); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	choice = new(UserRepo)
+	c.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	if _, err := c.Get(new(paymentGateway)); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+// TestBindStrategyInChild 测试通过子容器获取时，策略使用子容器选择实现
+func TestBindStrategyInChild(t *testing.T) {
+	parent := ioc.New()
+	parent.MustBindValue("gateway.choice", "stable")
+	parent.Must(parent.SingletonVersioned(new(paymentGateway), "stable", func() paymentGateway { return paymentGatewayV1{} }))
+	parent.Must(parent.SingletonVersioned(new(paymentGateway), "canary", func() paymentGateway { return paymentGatewayV2{} }))
+
+	var selectedBy ioc.Resolver
+	parent.Must(parent.BindStrategy(new(paymentGateway), func(r ioc.Resolver) any {
+		selectedBy = r
+		return r.MustGet("gateway.choice")
+	}))
+
+	child := ioc.Extend(parent)
+	child.MustBindValue("gateway.choice", "canary")
+
+	if gateway := child.MustGet(new(paymentGateway)).(paymentGateway); gateway.Name() != "v2" || selectedBy != child {
+		t.Errorf("test failed: %s", gateway.Name())
+	}
+
+	if gateway := parent.MustGet(new(paymentGateway)).(paymentGateway); gateway.Name() != "v1" || selectedBy != parent {
+		t.Errorf("test failed: %s", gateway.Name())
+	}
+}
+
+type userCreated struct {
+	ID int
+}
+
+func TestEventBus(t *testing.T) {
+	c := ioc.New()
+
+	var received []string
+	c.MustResolve(func(bus ioc.EventBus) {
+		ioc.Subscribe(bus, func(evt userCreated) { received = append(received, fmt.Sprintf("created:%d", evt.ID)) })
+		unsubscribe := ioc.Subscribe(bus, func(evt fmt.Stringer) { received = append(received, evt.String()) })
+		ioc.Subscribe(bus, func(evt any) { received = append(received, fmt.Sprintf("any:%T", evt)) })
+
+		bus.Publish(userCreated{ID: 1})
+		bus.Publish(demo1{})
+		unsubscribe()
+		bus.Publish(demo1{})
+	})
+
+	ioc.Extend(c).MustResolve(func(bus ioc.EventBus) {
+		bus.Publish(userCreated{ID: 2})
+	})
+
+	expected := "[created:1 any:ioc_test.userCreated demo1 any:ioc_test.demo1 any:ioc_test.demo1 created:2 any:ioc_test.userCreated]"
+	if fmt.Sprint(received) != expected {
+		t.Errorf("test failed: %v", received)
+	}
+}
+
+func TestReloader(t *testing.T) {
+	c := ioc.New()
+
+	settings := map[string]any{"db.dsn": "root:root@/my_db", "listen": ":80"}
+	source := ioc.ValueSourceFunc(func() (map[string]any, error) { return settings, nil })
+
+	var disposed []string
+	c.MustSingleton(ioc.WithOptions(func(cc ioc.Container) *UserRepo {
+		return &UserRepo{connStr: cc.MustGet("db.dsn").(string)}
+	}, ioc.WithDisposer(func(v any) error {
+		disposed = append(disposed, v.(*UserRepo).connStr)
+		return nil
+	})))
+
+	var events []string
+	c.MustResolve(func(bus ioc.EventBus) {
+		ioc.Subscribe(bus, func(evt ioc.ReloadEvent) { events = append(events, fmt.Sprint(evt.Changed)) })
+	})
+
+	reloader, err := ioc.NewReloader(c, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := reloader.Refreshable(new(UserRepo)).Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.MustGet(new(UserRepo)).(*UserRepo).connStr != "root:root@/my_db" {
+		t.Error("test failed")
+	}
+
+	settings = map[string]any{"db.dsn": "root:root@/another_db", "listen": ":80"}
+	if err := reloader.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.MustGet(new(UserRepo)).(*UserRepo).connStr != "root:root@/another_db" {
+		t.Error("test failed: refreshable singleton should be re-created")
+	}
+
+	if fmt.Sprint(events) != "[[db.dsn listen] [db.dsn]]" || fmt.Sprint(disposed) != "[root:root@/my_db]" {
+		t.Errorf("test failed: events=%v, disposed=%v", events, disposed)
+	}
+
+	failed, _ := ioc.NewReloader(c, ioc.ValueSourceFunc(func() (map[string]any, error) { return nil, errors.New("file not found") }))
+	if err := failed.Reload(); err == nil {
+		t.Error("test failed")
+	}
+
+	// 任意一个值绑定失败时，容器保持不变
+	c.MustBindValue("readonly", "x")
+	settings = map[string]any{"db.dsn": "root:root@/third_db", "listen": ":80", "readonly": "y"}
+	if err := reloader.Reload(); !errors.Is(err, ioc.ErrRepeatedBind) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if c.MustGet("db.dsn") != "root:root@/another_db" || c.MustGet("readonly") != "x" {
+		t.Error("test failed: failed reload should not change the container")
+	}
+
+	if _, err := ioc.NewReloader(nil); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	c := ioc.New()
+
+	var disposed []string
+	c.MustSingleton(ioc.WithOptions(func() *UserRepo { return &UserRepo{} }, ioc.WithDisposer(func(any) error {
+		disposed = append(disposed, "repo")
+		return nil
+	})))
+	c.MustSingleton(ioc.WithOptions(func(repo *UserRepo) *UserService { return &UserService{repo: repo} }, ioc.WithDisposer(func(any) error {
+		disposed = append(disposed, "service")
+		return errors.New("connection reset")
+	})))
+	c.MustSingleton(ioc.WithOptions(func() *demo3 { return &demo3{} }, ioc.WithDisposer(func(any) error {
+		disposed = append(disposed, "demo3")
+		return nil
+	})))
+
+	c.MustResolve(func(*UserService) {})
+
+	if err := c.Close(); err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if fmt.Sprint(disposed) != "[service repo]" {
+		t.Errorf("test failed: %v", disposed)
+	}
+}
+
+// TestShutdownOrder 测试按照实例化的逆序释放，绑定顺序与实例化顺序无关
+func TestShutdownOrder(t *testing.T) {
+	c := ioc.New()
+
+	var disposed []string
+	c.MustSingleton(ioc.WithOptions(func(repo *UserRepo) *UserService { return &UserService{repo: repo} }, ioc.WithDisposer(func(any) error {
+		disposed = append(disposed, "service")
+		return nil
+	})))
+	c.MustSingleton(ioc.WithOptions(func() *UserRepo { return &UserRepo{} }, ioc.WithDisposer(func(any) error {
+		disposed = append(disposed, "repo")
+		return nil
+	})))
+
+	c.MustResolve(func(*UserService) {})
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(disposed) != "[service repo]" {
+		t.Errorf("test failed: dependent should be disposed before its dependency: %v", disposed)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	c := ioc.New()
+
+	release := make(chan struct{})
+	var repoDisposed int32
+
+	c.MustSingleton(ioc.WithOptions(func() *UserRepo { return &UserRepo{} }, ioc.WithDisposer(func(any) error {
+		atomic.AddInt32(&repoDisposed, 1)
+		return nil
+	})))
+	c.MustSingleton(ioc.WithOptions(func() *UserService { return &UserService{} }, ioc.WithDisposer(func(any) error {
+		<-release
+		return nil
+	})))
+	c.MustResolve(func(*UserRepo, *UserService) {})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := c.Shutdown(ctx)
+
+	var timeoutErr *ioc.ShutdownTimeoutError
+	if !errors.Is(err, ioc.ErrShutdownTimeout) || !errors.As(err, &timeoutErr) {
+		t.Fatalf("test failed: %v", err)
+	}
+
+	if fmt.Sprint(timeoutErr.Pending) != fmt.Sprint([]any{reflect.TypeOf(&UserService{}), reflect.TypeOf(&UserRepo{})}) {
+		t.Errorf("test failed: %v", timeoutErr.Pending)
+	}
+
+	if atomic.LoadInt32(&repoDisposed) != 0 {
+		t.Errorf("test failed: repo should not be disposed after timeout")
+	}
+
+	// 未开始释放的单例被保留，再次 Shutdown 时完成释放
+	close(release)
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if atomic.LoadInt32(&repoDisposed) != 1 {
+		t.Errorf("test failed: repo should be disposed by the second shutdown, got %d", repoDisposed)
+	}
+}
+
+func TestConcurrentNewChild(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "root:root@/my_db?charset=utf8"} })
+
+	other := ioc.New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			c.MustBindValue(fmt.Sprintf("key%d", i), i)
+		}(i)
+		go func() {
+			defer wg.Done()
+
+			child := c.NewChild()
+			child.MustPrototype(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+			if child.Parent() != c {
+				t.Error("test failed")
+			}
+
+			child.ExtendFrom(other)
+			child.ExtendFrom(c)
+			if _, err := child.Get(new(UserService)); err != nil {
+				t.Errorf("test failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestParentLookupCache(t *testing.T) {
+	root := ioc.New()
+	parent := ioc.Extend(root)
+	child := ioc.Extend(parent)
+
+	if _, err := child.Get("listen"); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	root.MustBindValue("listen", ":80")
+	if val, err := child.Get("listen"); err != nil || val != ":80" {
+		t.Errorf("test failed: negative cache should be invalidated: %v, %v", val, err)
+	}
+
+	parent.MustBindValue("listen", ":8080")
+	if val, err := child.Get("listen"); err != nil || val != ":8080" {
+		t.Errorf("test failed: %v, %v", val, err)
+	}
+
+	if err := parent.Unbind("listen"); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := child.Get("listen"); err != nil || val != ":80" {
+		t.Errorf("test failed: %v, %v", val, err)
+	}
+
+	other := ioc.New()
+	other.MustBindValue("listen", ":9090")
+	if err := parent.TryExtendFrom(other); err != nil {
+		t.Fatal(err)
+	}
+	if val, err := child.Get("listen"); err != nil || val != ":9090" {
+		t.Errorf("test failed: %v, %v", val, err)
+	}
+}
+
+func TestExpvar(t *testing.T) {
+	c := ioc.New(ioc.WithExpvar("ioc_test_container"))
+
+	var failed bool
+	c.MustSingleton(func() (*UserRepo, error) {
+		if !failed {
+			failed = true
+			return nil, errors.New("connection refused")
+		}
+		return &UserRepo{}, nil
+	})
+
+	_, _ = c.Get(new(UserRepo))
+	_, _ = c.Get(new(UserRepo))
+	_, _ = c.Get(new(UserRepo))
+
+	stats := make(map[string]int64)
+	if err := json.Unmarshal([]byte(expvar.Get("ioc_test_container").String()), &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats["resolutions"] != 3 || stats["cache_hits"] != 1 || stats["constructor_errors"] != 1 {
+		t.Errorf("test failed: %v", stats)
+	}
+}
+
+// TestParentCacheEntries 测试子容器缓存父容器查找结果时，每次传入新的指针不会增加缓存项
+func TestParentCacheEntries(t *testing.T) {
+	root := ioc.New()
+	root.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "root"} })
+
+	child := ioc.Extend(root, ioc.WithExpvar("ioc_test_parent_cache"))
+	for i := 0; i < 1000; i++ {
+		child.MustGet(new(UserRepo))
+	}
+
+	stats := make(map[string]int64)
+	if err := json.Unmarshal([]byte(expvar.Get("ioc_test_parent_cache").String()), &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats["parent_cache_entries"] != 1 {
+		t.Errorf("test failed: %v", stats)
+	}
+
+	// 父容器直接绑定的指针 Key 仍然精确匹配
+	key := &UserService{}
+	root.MustBindWithKey(key, func() *UserService { return &UserService{} }, false, false)
+	if _, err := child.Get(key); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := child.Get(new(UserService)); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestProfiling(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	c.MustPrototype(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+
+	c.EnableProfiling()
+	for i := 0; i < 3; i++ {
+		c.MustResolve(func(*UserService) {})
+	}
+	c.MustResolve(func(*UserRepo) {})
+	workload := c.DisableProfiling()
+
+	counts := make([]string, 0)
+	for _, entry := range workload.Entries {
+		counts = append(counts, fmt.Sprintf("%v=%d", entry.Key, entry.Count))
+	}
+
+	if fmt.Sprint(counts) != "[*ioc_test.UserRepo=4 *ioc_test.UserService=3]" {
+		t.Errorf("test failed: %v", counts)
+	}
+
+	if len(c.Profile().Entries) != 0 {
+		t.Error("test failed: profiling should be disabled")
+	}
+
+	alternative := ioc.New(ioc.WithConstructorConcurrency(1))
+	alternative.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	alternative.MustSingleton(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+
+	report, err := ioc.Replay(alternative, workload)
+	if err != nil || len(report.Entries) != 2 || report.Entries[1].Count != 3 {
+		t.Errorf("test failed: %v, %v", report, err)
+	}
+
+	if _, err := ioc.Replay(ioc.New(), workload); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestKeyedBinding(t *testing.T) {
+	c := ioc.New()
+	c.Must(ioc.BindKeyed[*UserRepo](c, "primary-db", func() *UserRepo { return &UserRepo{connStr: "primary"} }))
+	c.Must(ioc.BindKeyed[*UserRepo](c, "replica-db", func() (*UserRepo, error) { return &UserRepo{connStr: "replica"}, nil }))
+	c.Must(ioc.BindKeyed[InterfaceDemo](c, "primary-db", demo1{}))
+
+	for name, expected := range map[string]string{"primary-db": "primary", "replica-db": "replica"} {
+		repo, err := ioc.GetKeyed[*UserRepo](c, name)
+		if err != nil || repo.connStr != expected {
+			t.Errorf("test failed: %v, %v", repo, err)
+		}
+	}
+
+	if ioc.MustGetKeyed[InterfaceDemo](ioc.Extend(c), "primary-db").String() != "demo1" {
+		t.Error("test failed")
+	}
+
+	if _, err := ioc.GetKeyed[*UserService](c, "primary-db"); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := ioc.BindKeyed[*UserService](c, "service", func() *UserRepo { return nil }); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := ioc.BindKeyed[*UserRepo](c, "primary-db", &UserRepo{}); !errors.Is(err, ioc.ErrRepeatedBind) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestScopeInfo(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func(scope ioc.ScopeInfo) *UserRepo { return &UserRepo{connStr: string(scope.Kind)} })
+	c.MustPrototype(func(scope ioc.ScopeInfo) *UserService {
+		return &UserService{repo: &UserRepo{connStr: fmt.Sprintf("%s:%s:%d", scope.Kind, scope.ID, scope.Depth)}}
+	})
+
+	request := ioc.Extend(c.NewChild(), ioc.WithRequestScope("req-1"))
+	request.MustPrototype(func(scope ioc.ScopeInfo) *UserService {
+		return &UserService{repo: &UserRepo{connStr: fmt.Sprintf("%s:%s:%d", scope.Kind, scope.ID, scope.Depth)}}
+	})
+
+	request.MustResolve(func(repo *UserRepo, service *UserService, scope ioc.ScopeInfo) {
+		if repo.connStr != "root" || service.repo.connStr != "request:req-1:2" || scope != request.Scope() {
+			t.Errorf("test failed: %s, %s", repo.connStr, service.repo.connStr)
+		}
+	})
+
+	if scope := c.NewChild().Scope(); scope.Kind != ioc.ScopeChild || scope.Depth != 1 || scope.ID == "" {
+		t.Errorf("test failed: %v", scope)
+	}
+}
+
+type configKey string
+
+const dbHostKey configKey = "db.host"
+
+func TestStrictMode(t *testing.T) {
+	c := ioc.New(ioc.WithStrictMode())
+
+	for _, err := range []error{
+		c.Singleton(func() (*UserRepo, *json.SyntaxError) { return &UserRepo{}, nil }),
+		c.SingletonWithKey(reflect.TypeOf((*InterfaceDemo)(nil)).Elem(), func() demo1 { return demo1{} }),
+		c.BindValue("db.host", "127.0.0.1"),
+		ioc.BindTypedValue(c, "db.host", "127.0.0.1"),
+	} {
+		if !errors.Is(err, ioc.ErrStrictMode) || !strings.Contains(err.Error(), "hint") {
+			t.Errorf("test failed: %v", err)
+		}
+	}
+
+	c.MustSingleton(func() (*UserRepo, error) { return &UserRepo{}, nil })
+	c.MustSingleton(func() InterfaceDemo { return demo1{} })
+	c.Must(ioc.BindTypedValue(c, dbHostKey, "127.0.0.1"))
+
+	if c.MustGet(string(dbHostKey)) != "127.0.0.1" {
+		t.Error("test failed")
+	}
+
+	c.Freeze()
+	if err := c.Singleton(func() *UserService { return &UserService{} }); !errors.Is(err, ioc.ErrFrozen) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := c.Unbind(new(UserRepo)); !errors.Is(err, ioc.ErrFrozen) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := c.Get(new(UserRepo)); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestRegistrationHook(t *testing.T) {
+	c := ioc.New(ioc.WithRegistrationHook(
+		func(reg ioc.Registration) (ioc.Registration, error) {
+			if reg.Type == reflect.TypeOf(&demo3{}) {
+				return reg, errors.New("demo3 is forbidden")
+			}
+
+			return reg, nil
+		},
+		func(reg ioc.Registration) (ioc.Registration, error) {
+			switch reg.Type {
+			case reflect.TypeOf(&UserRepo{}):
+				return reg.WrapValue(func(val any) (any, error) {
+					repo := val.(*UserRepo)
+					repo.connStr += "?timeout=3s"
+					return repo, nil
+				}), nil
+			case reflect.TypeOf(""):
+				return reg.WrapValue(func(val any) (any, error) { return strings.ToUpper(val.(string)), nil }), nil
+			}
+
+			return reg, nil
+		},
+	))
+
+	c.MustSingleton(func() (*UserRepo, error) { return &UserRepo{connStr: "root:root@/my_db"}, nil })
+	c.MustBindValue("env", "prod")
+
+	if repo := c.MustGet(new(UserRepo)).(*UserRepo); repo.connStr != "root:root@/my_db?timeout=3s" {
+		t.Errorf("test failed: %s", repo.connStr)
+	}
+
+	if c.MustGet("env") != "PROD" {
+		t.Errorf("test failed: %v", c.MustGet("env"))
+	}
+
+	if err := c.Singleton(func() *demo3 { return &demo3{} }); err == nil || !strings.Contains(err.Error(), "forbidden") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// built-in bindings are not affected
+	c.MustResolve(func(ioc.Container, context.Context) {})
+}
+
+type closableRepo struct {
+	name   string
+	closed *[]string
+	err    error
+}
+
+func (r *closableRepo) Close() error {
+	*r.closed = append(*r.closed, r.name)
+	return r.err
+}
+
+func TestAutoClose(t *testing.T) {
+	c := ioc.New()
+
+	closed := make([]string, 0)
+	c.MustSingleton(func() *closableRepo { return &closableRepo{name: "repo", closed: &closed} })
+	c.MustSingleton(func() io.Closer { return &closableRepo{name: "cache", closed: &closed, err: errors.New("broken pipe")} })
+	c.MustSingleton(ioc.WithOptions(func() *UserRepo { return &UserRepo{} }, ioc.WithDisposer(func(any) error {
+		closed = append(closed, "user-repo")
+		return nil
+	})))
+	c.MustResolve(func(*closableRepo, io.Closer, *UserRepo) {})
+
+	err := c.Close()
+	if err == nil || !strings.Contains(err.Error(), "io.Closer") || !strings.Contains(err.Error(), "broken pipe") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if fmt.Sprint(closed) != "[user-repo cache repo]" {
+		t.Errorf("test failed: %v", closed)
+	}
+}
+
+func TestCallWithDefaults(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "root:root@/my_db"} })
+
+	results, err := c.CallWithDefaults(func(repo *UserRepo, demo InterfaceDemo, service *UserService) string {
+		return fmt.Sprintf("%s %s %v", repo.connStr, demo.String(), service == nil)
+	}, map[reflect.Type]any{
+		reflect.TypeOf((*InterfaceDemo)(nil)).Elem(): demo1{},
+		reflect.TypeOf(&UserService{}):               nil,
+	})
+	if err != nil || results[0] != "root:root@/my_db demo1 true" {
+		t.Errorf("test failed: %v, %v", results, err)
+	}
+
+	if _, err := c.CallWithDefaults(func(*UserService) {}, nil); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := c.CallWithDefaults(func(*UserService) {}, map[reflect.Type]any{reflect.TypeOf(&UserService{}): "oops"}); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 已绑定但缺少依赖的参数不使用默认值
+	c.MustSingleton(func(*RoleService) *UserService { return &UserService{} })
+	if _, err := c.CallWithDefaults(func(*UserService) {}, map[reflect.Type]any{reflect.TypeOf(&UserService{}): nil}); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: broken binding should not be substituted: %v", err)
+	}
+}
+
+func TestCaptiveDependencyCheck(t *testing.T) {
+	root := ioc.New(ioc.WithCaptiveDependencyCheck())
+	root.MustBindValueOverride("tenant", "default")
+	root.MustPrototype(func() *UserRepo { return &UserRepo{} })
+	root.MustSingleton(func(repo *UserRepo, cc ioc.Container) *UserService { return &UserService{repo: repo} })
+	root.MustSingleton(func() *RoleService { return &RoleService{} })
+
+	if err := root.Validate(); !errors.Is(err, ioc.ErrCaptiveDependency) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	request := ioc.Extend(root, ioc.WithRequestScope("req-1"), ioc.WithCaptiveDependencyCheck())
+	request.MustSingletonOverride(func() *RoleService { return &RoleService{} })
+	request.MustSingletonOverride(func() *UserRepo { return &UserRepo{} })
+
+	kinds := make([]string, 0)
+	for _, issue := range ioc.Lint(request) {
+		if issue.Kind == ioc.IssueCaptiveScoped {
+			kinds = append(kinds, fmt.Sprint(issue.Key))
+		}
+	}
+
+	if fmt.Sprint(kinds) != "[*ioc_test.UserService]" {
+		t.Errorf("test failed: %v", kinds)
+	}
+
+	if err := ioc.New().Validate(); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type registrySource struct {
+	lookups *int
+}
+
+func (s registrySource) Binding(key any) (ioc.Registration, bool, error) {
+	*s.lookups++
+	if key == "unavailable" {
+		return ioc.Registration{}, false, errors.New("registry unavailable")
+	}
+
+	if key == reflect.TypeOf(&UserService{}) {
+		return ioc.Registration{Initialize: func(repo *UserRepo) *UserService { return &UserService{repo: repo} }, Prototype: true}, true, nil
+	}
+
+	return ioc.Registration{}, false, nil
+}
+
+func TestBindingSources(t *testing.T) {
+	lookups := 0
+	c := ioc.New(ioc.WithBindingSources(
+		ioc.MapSource{
+			reflect.TypeOf(&UserRepo{}): func() *UserRepo { return &UserRepo{connStr: "root:root@/my_db"} },
+			"version":                   "1.0.0",
+		},
+		registrySource{lookups: &lookups},
+	))
+
+	if c.MustGet("version") != "1.0.0" {
+		t.Error("test failed")
+	}
+
+	c.MustResolve(func(s1 *UserService, s2 *UserService, repo *UserRepo) {
+		if s1 == s2 || s1.repo != repo || repo.connStr != "root:root@/my_db" {
+			t.Error("test failed")
+		}
+	})
+
+	if lookups != 1 || !c.HasBound(new(UserService)) {
+		t.Errorf("test failed: %d", lookups)
+	}
+
+	if _, err := c.Get("unavailable"); err == nil || !strings.Contains(err.Error(), "registry unavailable") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := c.Get(new(demo3)); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestBindingSourcesOfAncestors(t *testing.T) {
+	root := ioc.New(ioc.WithBindingSources(ioc.MapSource{
+		reflect.TypeOf(&UserRepo{}): func() *UserRepo { return &UserRepo{connStr: "source"} },
+	}))
+	root.MustSingleton(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+
+	if err := root.Validate(); err != nil {
+		t.Errorf("test failed: source provided dependency should validate: %v", err)
+	}
+
+	child := root.NewChild()
+	child.MustResolve(func(repo *UserRepo) {
+		if repo.connStr != "source" {
+			t.Errorf("test failed: %v", repo)
+		}
+	})
+
+	// 未命中的结果被缓存后，AddSource 使缓存失效
+	other := ioc.New()
+	otherChild := other.NewChild()
+	if _, err := otherChild.Get("version"); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Fatalf("test failed: %v", err)
+	}
+
+	if err := other.AddSource(ioc.MapSource{"version": "2.0.0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if otherChild.MustGet("version") != "2.0.0" {
+		t.Error("test failed: child should see the bindings of the added source")
+	}
+}
+
+type tenantPool struct {
+	tenant string
+	closed *closedTenants
+}
+
+func (p *tenantPool) Close() error {
+	p.closed.lock.Lock()
+	p.closed.ids = append(p.closed.ids, p.tenant)
+	p.closed.lock.Unlock()
+
+	p.closed.notify <- p.tenant
+	return nil
+}
+
+type closedTenants struct {
+	lock   sync.Mutex
+	ids    []string
+	notify chan string
+}
+
+func (c *closedTenants) String() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return fmt.Sprint(c.ids)
+}
+
+type tenantDBModule struct{}
+
+func (tenantDBModule) Register(binder ioc.Binder) error {
+	return binder.Singleton(func(closed *closedTenants, scope ioc.ScopeInfo) *tenantPool {
+		return &tenantPool{tenant: scope.ID, closed: closed}
+	})
+}
+
+func TestTenantContainers(t *testing.T) {
+	closed := &closedTenants{notify: make(chan string, 10)}
+
+	c := ioc.New()
+	clock := ioctest.UseFakeClock(c)
+	c.MustSingleton(func() *closedTenants { return closed })
+
+	tenants := ioc.NewTenantContainers(c, 30*time.Millisecond, tenantDBModule{})
+
+	pools := make(map[string]*tenantPool)
+	for _, id := range []string{"acme", "globex", "acme"} {
+		r, err := tenants.ForTenant(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pool := r.MustGet(new(tenantPool)).(*tenantPool)
+		if prev, ok := pools[id]; ok && prev != pool {
+			t.Error("test failed: tenant singleton should be shared")
+		}
+		pools[id] = pool
+
+		if r.MustGet(ioc.TenantIDKey) != id || pool.tenant != id {
+			t.Errorf("test failed: %v", pool.tenant)
+		}
+	}
+
+	if fmt.Sprint(tenants.Tenants()) != "[acme globex]" {
+		t.Errorf("test failed: %v", tenants.Tenants())
+	}
+
+	if err := tenants.Evict("globex"); err != nil || closed.String() != "[globex]" {
+		t.Errorf("test failed: %v, %v", closed, err)
+	}
+
+	<-closed.notify
+
+	clock.Advance(30 * time.Millisecond)
+	if id := <-closed.notify; id != "acme" || len(tenants.Tenants()) != 0 || closed.String() != "[globex acme]" {
+		t.Errorf("test failed: idle tenant should be evicted: %v, %v", tenants.Tenants(), closed)
+	}
+
+	if _, err := tenants.ForTenant(""); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := tenants.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+type blockingTenantModule struct {
+	entered chan string
+	release chan struct{}
+}
+
+func (m blockingTenantModule) Register(binder ioc.Binder) error {
+	id := binder.(ioc.Resolver).MustGet(ioc.TenantIDKey).(string)
+	m.entered <- id
+	if id == "slow" {
+		<-m.release
+		return errors.New("slow tenant failed")
+	}
+
+	return nil
+}
+
+// TestTenantContainersConcurrentLoad 测试租户模块在锁外加载，慢租户不阻塞其他租户，加载失败的租户不被缓存
+func TestTenantContainersConcurrentLoad(t *testing.T) {
+	module := blockingTenantModule{entered: make(chan string, 10), release: make(chan struct{})}
+	tenants := ioc.NewTenantContainers(ioc.New(), 0, module)
+
+	slow := make(chan error, 2)
+	go func() {
+		_, err := tenants.ForTenant("slow")
+		slow <- err
+	}()
+	<-module.entered
+
+	// 同一租户的并发调用等待第一个调用的结果
+	go func() {
+		_, err := tenants.ForTenant("slow")
+		slow <- err
+	}()
+
+	if _, err := tenants.ForTenant("fast"); err != nil || <-module.entered != "fast" {
+		t.Fatalf("test failed: %v", err)
+	}
+
+	close(module.release)
+	for i := 0; i < 2; i++ {
+		if err := <-slow; err == nil {
+			t.Error("test failed: slow tenant should fail")
+		}
+	}
+
+	if _, err := tenants.ForTenant("slow"); err == nil || <-module.entered != "slow" {
+		t.Errorf("test failed: failed tenant should be loaded again: %v", err)
+	}
+
+	if fmt.Sprint(tenants.Tenants()) != "[fast]" {
+		t.Errorf("test failed: %v", tenants.Tenants())
+	}
+}
+
+func TestDefaultClock(t *testing.T) {
+	c := ioc.New()
+	c.MustResolve(func(clock ioc.Clock) {
+		if time.Since(clock.Now()) > time.Second {
+			t.Error("test failed: default clock should be the real clock")
+		}
+
+		ticker := clock.Ticker(time.Millisecond)
+		defer ticker.Stop()
+		<-ticker.C()
+	})
+}
+
+func TestDefaultIDGenerator(t *testing.T) {
+	c := ioc.New()
+	c.MustResolve(func(ids ioc.IDGenerator, src rand.Source) {
+		id1, id2 := ids.NewID(), ids.NewID()
+		if id1 == id2 || len(id1) != 36 || strings.Count(id1, "-") != 4 || id1[14] != '4' {
+			t.Errorf("test failed: %v, %v", id1, id2)
+		}
+
+		_ = src.Int63()
+	})
+}
+
+type serverParams struct {
+	ioc.In
+
+	Repo    *UserRepo
+	Addr    string        `name:"server.addr" default:":8080"`
+	Workers int           `name:"server.workers" default:"4"`
+	Timeout time.Duration `name:"server.timeout" default:"30s"`
+	Debug   bool          `default:"true"`
+	Logger  InterfaceDemo `optional:"true"`
+	skipped *UserService  `autowire:"-"`
+}
+
+func TestParamStruct(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "repo"} })
+	c.MustBindValue("server.addr", ":9090")
+
+	c.MustResolve(func(p serverParams) {
+		if p.Repo == nil || p.Repo.connStr != "repo" || p.Logger != nil || p.skipped != nil {
+			t.Errorf("test failed: %+v", p)
+		}
+
+		if p.Addr != ":9090" || p.Workers != 4 || p.Timeout != 30*time.Second || !p.Debug {
+			t.Errorf("test failed: %+v", p)
+		}
+	})
+
+	c.MustSingleton(func(p serverParams) *UserService { return &UserService{repo: p.Repo} })
+	if err := c.Validate(); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+
+	type invalidParams struct {
+		ioc.In
+		Repo *UserRepo `default:"repo"`
+	}
+
+	if err := c.Resolve(func(invalidParams) {}); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	type requiredParams struct {
+		ioc.In
+		Closer io.Closer
+	}
+
+	if err := c.Resolve(func(requiredParams) {}); !errors.Is(err, ioc.ErrObjectNotFound) || !strings.Contains(err.Error(), "Closer") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 可选字段的绑定存在但缺少依赖时返回错误，而不是注入零值
+	broken := ioc.New()
+	broken.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	broken.MustSingleton(func(*RoleService) InterfaceDemo { return demo1{} })
+	if err := broken.Resolve(func(serverParams) {}); !errors.Is(err, ioc.ErrObjectNotFound) || !strings.Contains(err.Error(), "Logger") {
+		t.Errorf("test failed: broken binding of optional field should fail: %v", err)
+	}
+
+	type secretParams struct {
+		ioc.In
+		Token int `name:"api.token"`
+	}
+
+	c.MustBindValue("api.token", ioc.WithOptions("s3cr3t", ioc.SecretMarker()))
+	if err := c.Resolve(func(secretParams) {}); !errors.Is(err, ioc.ErrValueConversion) || strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("test failed: secret value should be redacted: %v", err)
+	}
+}
+
+func TestCheckedResolve(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "repo"} })
+	c.MustBindValue("server.addr", ":9090")
+
+	err := ioc.CheckedResolve3(c, func(repo *UserRepo, p serverParams, cc ioc.Container) error {
+		if repo.connStr != "repo" || p.Addr != ":9090" || cc != c {
+			t.Errorf("test failed: %v, %v", repo, p)
+		}
+
+		return errors.New("callback error")
+	})
+	if err == nil || err.Error() != "callback error" {
+		t.Errorf("test failed: %v", err)
+	}
+
+	err = ioc.CheckedResolve1(c, func(io.Closer) error { return nil })
+	if !errors.Is(err, ioc.ErrArgsNotInstanced) || !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type connPool struct {
+	conns ioc.Factory[*UserRepo]
+}
+
+func TestFactory(t *testing.T) {
+	c := ioc.New()
+
+	var created int
+	c.MustSingleton(func() *UserRepo {
+		created++
+		return &UserRepo{connStr: fmt.Sprintf("conn-%d", created)}
+	})
+	c.MustSingleton(func(conns ioc.Factory[*UserRepo]) *connPool { return &connPool{conns: conns} })
+
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	pool := c.MustGet(new(connPool)).(*connPool)
+
+	cached1, err := pool.conns.Cached()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cached2, _ := pool.conns.Cached()
+	fresh, err := pool.conns.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cached1 != cached2 || fresh == cached1 || fresh.connStr != "conn-2" || created != 2 {
+		t.Errorf("test failed: %v, %v, %v", cached1, cached2, fresh)
+	}
+
+	if _, err := (ioc.Factory[*UserRepo]{}).New(); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	c.MustResolve(func(closers ioc.Factory[io.Closer]) {
+		if _, err := closers.New(); !errors.Is(err, ioc.ErrObjectNotFound) {
+			t.Errorf("test failed: %v", err)
+		}
+	})
+}
+
+func TestFactoryOutlivesContext(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "conn"} })
+	c.MustSingleton(func(conns ioc.Factory[*UserRepo]) *connPool { return &connPool{conns: conns} })
+
+	// 单例在请求的 ctx 中首次创建，ctx 结束后其持有的 Factory 仍然可用
+	ctx, cancel := context.WithCancel(context.Background())
+	var pool *connPool
+	if _, err := c.CallWithContext(ctx, func(p *connPool) { pool = p }); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	if _, err := pool.conns.New(); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := pool.conns.Cached(); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type Notifier interface {
+	Notify(msg string) error
+}
+
+type mailNotifier struct{}
+
+func (mailNotifier) Notify(string) error { return nil }
+
+type alertService struct{}
+
+func TestCoverage(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() InterfaceDemo { return demo1{} })
+	c.MustSingleton(func() mailNotifier { return mailNotifier{} })
+	c.MustSingleton(func(Notifier, *slog.Logger) alertService { return alertService{} })
+
+	report := ioc.Coverage(c, "github.com/mylxsw/go-ioc_test")
+	if len(report) != 2 {
+		t.Fatalf("test failed: %v", report)
+	}
+
+	if report[0].Interface != reflect.TypeOf((*InterfaceDemo)(nil)).Elem() || !report[0].Bound || len(report[0].Implementations) != 0 {
+		t.Errorf("test failed: %+v", report[0])
+	}
+
+	if report[1].Interface != reflect.TypeOf((*Notifier)(nil)).Elem() || report[1].Bound ||
+		fmt.Sprint(report[1].Implementations) != "[ioc_test.mailNotifier]" {
+		t.Errorf("test failed: %+v", report[1])
+	}
+
+	if len(ioc.Coverage(c, "github.com/mylxsw/...")) != 2 || len(ioc.Coverage(c, "github.com/acme/...")) != 0 {
+		t.Error("test failed: package patterns")
+	}
+
+	report = ioc.CoverageOf(c, reflect.TypeOf((*io.Closer)(nil)).Elem(), reflect.TypeOf(demo1{}))
+	if len(report) != 1 || report[0].Bound || len(report[0].Implementations) != 0 {
+		t.Errorf("test failed: %+v", report)
+	}
+}
+
+type moduleFunc func(binder ioc.Binder) error
+
+func (f moduleFunc) Register(binder ioc.Binder) error { return f(binder) }
+
+func TestLoadManifest(t *testing.T) {
+	common := moduleFunc(func(binder ioc.Binder) error {
+		return binder.Singleton(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+	})
+	dev := moduleFunc(func(binder ioc.Binder) error {
+		return binder.Singleton(func() *UserRepo { return &UserRepo{connStr: "memory"} })
+	})
+	prod := moduleFunc(func(binder ioc.Binder) error {
+		if err := binder.Singleton(func() *UserRepo { return &UserRepo{connStr: "mysql"} }); err != nil {
+			return err
+		}
+
+		return binder.BindValue("db.dsn", "root@/prod")
+	})
+
+	manifest := ioc.Manifest{
+		Common:       []ioc.Registerable{common},
+		Environments: map[string][]ioc.Registerable{"dev": {dev}, "prod": {prod}},
+	}
+
+	err := ioc.New(ioc.WithManifest(manifest)).LoadManifest("dev")
+	if !errors.Is(err, ioc.ErrManifestMismatch) || !strings.Contains(err.Error(), "dev misses [db.dsn]") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	manifest.Environments["dev"] = []ioc.Registerable{dev, moduleFunc(func(binder ioc.Binder) error {
+		return binder.BindValue("db.dsn", "memory")
+	})}
+
+	c := ioc.New(ioc.WithManifest(manifest))
+	if err := c.LoadManifest("prod"); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.MustGet(new(UserService)).(*UserService).repo.connStr != "mysql" {
+		t.Error("test failed: prod bindings should be loaded")
+	}
+
+	if err := c.LoadManifest("staging"); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := ioc.New().LoadManifest("prod"); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 校验时先加载公共模块，环境模块可以依赖公共模块的绑定
+	requireCommon := moduleFunc(func(binder ioc.Binder) error {
+		if !binder.HasBoundValue("log.level") {
+			return errors.New("common modules are not loaded")
+		}
+
+		return nil
+	})
+	manifest = ioc.Manifest{
+		Common: []ioc.Registerable{moduleFunc(func(binder ioc.Binder) error {
+			return binder.BindValue("log.level", "info")
+		})},
+		Environments: map[string][]ioc.Registerable{"dev": {requireCommon}, "prod": {requireCommon}},
+	}
+	if err := manifest.Validate(); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 键按照值比较，字符串形式相同的类型与字符串键不相等
+	manifest.Environments = map[string][]ioc.Registerable{
+		"dev":  {dev},
+		"prod": {moduleFunc(func(binder ioc.Binder) error { return binder.BindValue("*ioc_test.UserRepo", "mysql") })},
+	}
+	if err := manifest.Validate(); !errors.Is(err, ioc.ErrManifestMismatch) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type legacyRepo struct{}
+
+type legacyService struct {
+	repo *legacyRepo
+}
+
+func TestSetFallback(t *testing.T) {
+	legacy := ioc.New()
+	legacy.MustSingleton(func() *legacyRepo { return &legacyRepo{} })
+	legacy.MustBindValue("legacy.dsn", "root@/legacy")
+	legacy.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "legacy"} })
+
+	root := ioc.New()
+	root.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "root"} })
+	if err := root.SetFallback(legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	child := root.NewChild()
+	child.MustResolve(func(repo *UserRepo) {
+		if repo.connStr != "root" {
+			t.Error("test failed: fallback should not shadow bindings of the hierarchy")
+		}
+	})
+
+	if child.MustGet("legacy.dsn") != "root@/legacy" {
+		t.Error("test failed: missed key should be resolved from fallback of ancestors")
+	}
+
+	if _, err := child.Get("unknown"); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 仅由 fallback 提供的依赖可以通过校验
+	child.MustSingleton(func(repo *legacyRepo) *legacyService { return &legacyService{repo: repo} })
+	if err := child.Validate(); err != nil {
+		t.Errorf("test failed: fallback provided dependency should validate: %v", err)
+	}
+
+	if child.MustGet(new(legacyService)).(*legacyService).repo == nil {
+		t.Error("test failed")
+	}
+
+	// 已绑定的 Key 因为缺少依赖而无法创建时，不会使用 fallback 中的实例
+	legacy.MustSingleton(func() *UserService { return &UserService{repo: &UserRepo{connStr: "legacy"}} })
+	root.MustSingleton(func(*RoleService) *UserService { return &UserService{} })
+	if _, err := child.Get(new(UserService)); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: broken binding should not fall back: %v", err)
+	}
+
+	if err := legacy.SetFallback(child); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := root.SetFallback(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := child.Get("legacy.dsn"); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestContainerString(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	c.MustPrototype(func() InterfaceDemo { return demo1{} })
+	for i := 0; i < 10; i++ {
+		c.MustBindValue(fmt.Sprintf("key%d", i), i)
+	}
+
+	summary := fmt.Sprint(c)
+	lines := strings.Split(summary, "\n")
+	if len(lines) != 12 || !strings.HasPrefix(lines[0], "container(kind=root") ||
+		!strings.Contains(lines[0], "11 singletons, 1 prototypes") || lines[1] != "  *ioc_test.UserRepo" || lines[11] != "  ... and 2 more" {
+		t.Errorf("test failed: %s", summary)
+	}
+
+	if strings.Contains(summary, "{{") {
+		t.Errorf("test failed: %s", summary)
+	}
+}
+
+// TestInvalidArgs 测试非法参数返回 ErrInvalidArgs 而不是在 reflect 中 panic
+func TestInvalidArgs(t *testing.T) {
+	var nilRepo *UserRepo
+	num := 1
+
+	cases := map[string]func(c ioc.Container) error{
+		"AutoWire(nil pointer)":     func(c ioc.Container) error { return c.AutoWire(nilRepo) },
+		"AutoWire(non struct)":      func(c ioc.Container) error { return c.AutoWire(&num) },
+		"ResolveInto(nil pointer)":  func(c ioc.Container) error { return c.ResolveInto(nilRepo) },
+		"Bind(non func non struct)": func(c ioc.Container) error { return c.Bind(1, false, false) },
+		"BindWithKey(nil key)":      func(c ioc.Container) error { return c.BindWithKey(nil, func() int { return 1 }, false, false) },
+		"Get(nil)":                  func(c ioc.Container) error { _, err := c.Get(nil); return err },
+		"Lookup(nil)":               func(c ioc.Container) error { _, err := c.Lookup(nil); return err },
+		"Resolve(non func)":         func(c ioc.Container) error { return c.Resolve(1) },
+		"Call(non func)":            func(c ioc.Container) error { _, err := c.Call("callback"); return err },
+		"Singleton(no return)":      func(c ioc.Container) error { return c.Singleton(func() {}) },
+		"Singleton(3 returns)":      func(c ioc.Container) error { return c.Singleton(func() (*UserRepo, error, int) { return nil, nil, 0 }) },
+		"Singleton(non error)":      func(c ioc.Container) error { return c.Singleton(func() (*UserRepo, int) { return nil, 0 }) },
+		"SingletonWithKey(non error)": func(c ioc.Container) error {
+			return c.SingletonWithKey("repo", func() (*UserRepo, bool) { return nil, true })
+		},
+		"Provider(non func)": func(c ioc.Container) (err error) {
+			defer func() { err, _ = recover().(error) }()
+			c.Provider(42)
+			return nil
+		},
+	}
+
+	for name, tc := range cases {
+		if err := tc(ioc.New()); !errors.Is(err, ioc.ErrInvalidArgs) {
+			t.Errorf("test failed: %s: %v", name, err)
+		}
+	}
+}
+
+func TestBindWithTypeKey(t *testing.T) {
+	c := ioc.New()
+
+	demoType := reflect.TypeOf((*InterfaceDemo)(nil)).Elem()
+	if err := c.BindWithKey(demoType, func() demo1 { return demo1{} }, false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	c.MustResolve(func(demo InterfaceDemo) {
+		if demo.String() != "demo1" {
+			t.Errorf("test failed: %v", demo)
+		}
+	})
+
+	handlerType := reflect.TypeOf((func(string) string)(nil))
+	if err := c.BindWithKey(handlerType, func(name string) string { return "hello " + name }, false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	c.MustResolve(func(handler func(string) string) {
+		if handler("ioc") != "hello ioc" {
+			t.Error("test failed: func should be bound as value")
+		}
+	})
+
+	err := c.BindWithKey(reflect.TypeOf((*UserRepo)(nil)), func() string { return "repo" }, false, false)
+	if !errors.Is(err, ioc.ErrInvalidArgs) || !strings.Contains(err.Error(), "not assignable") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := c.BindWithKey(reflect.TypeOf(0), func() int { return 1 }, false, false); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type requestID string
+
+func TestWithValues(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "repo"} })
+	c.MustBindValue("locale", "en")
+
+	overlay := c.WithValues(map[any]any{
+		reflect.TypeOf(requestID("")): requestID("req-1"),
+		new(UserRepo):                 &UserRepo{connStr: "overlay"},
+		"locale":                      "zh",
+		reflect.TypeOf(0):             "42",
+	})
+
+	overlay.MustResolve(func(id requestID, repo *UserRepo, n int, cc ioc.Container) {
+		if id != "req-1" || repo.connStr != "overlay" || n != 42 || cc != c {
+			t.Errorf("test failed: %v, %v, %v", id, repo, n)
+		}
+	})
+
+	if overlay.MustGet("locale") != "zh" || c.MustGet("locale") != "en" {
+		t.Error("test failed: overlay should not change container")
+	}
+
+	if err := c.Resolve(func(requestID) {}); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := c.WithValues(map[any]any{nil: 1}).Resolve(func() {}); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	err := c.WithValues(map[any]any{reflect.TypeOf(0): "s3cr3t"}).Resolve(func(int) {})
+	if !errors.Is(err, ioc.ErrValueConversion) || strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("test failed: overlay value should be redacted: %v", err)
+	}
+}
+
+func TestTaskGroup(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "repo"} })
+
+	var canceled atomic.Bool
+	err := ioc.NewTaskGroup(c).
+		Add(func(ctx context.Context, repo *UserRepo) error {
+			if repo.connStr != "repo" {
+				t.Errorf("test failed: %v", repo)
+			}
+
+			return errors.New("consumer crashed")
+		}).
+		Add(func(ctx context.Context) error {
+			<-ctx.Done()
+			canceled.Store(true)
+			return nil
+		}).
+		Run()
+
+	if err == nil || !strings.Contains(err.Error(), "consumer crashed") || !canceled.Load() {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := ioc.NewTaskGroup(c).Add(func(ctx context.Context, closer io.Closer) error { return nil }).Run(); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := ioc.NewTaskGroup(c).Add(func(repo *UserRepo) {}).Run(); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := ioc.NewTaskGroup(c).Add(func(ctx context.Context) error { return nil }).Run(); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type topicProducer struct {
+	topic  string
+	closed bool
+}
+
+func (p *topicProducer) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestBindTemplate(t *testing.T) {
+	c := ioc.New()
+
+	var created int32
+	c.Must(c.BindTemplate("producer", func(topic string) any {
+		atomic.AddInt32(&created, 1)
+		if topic == "" {
+			return errors.New("topic is required")
+		}
+
+		return &topicProducer{topic: topic}
+	}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c.MustGetTemplated("producer", "orders").(*topicProducer).topic != "orders" {
+				t.Error("test failed")
+			}
+		}()
+	}
+	wg.Wait()
+
+	orders := c.MustGetTemplated("producer", "orders").(*topicProducer)
+	payments := c.MustGetTemplated("producer", "payments").(*topicProducer)
+	if orders == payments || atomic.LoadInt32(&created) != 2 {
+		t.Errorf("test failed: one instance per parameter expected, created %d", created)
+	}
+
+	if _, err := c.GetTemplated("producer", ""); err == nil || !strings.Contains(err.Error(), "topic is required") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := ioc.Extend(c).GetTemplated("producer", "orders"); err != nil {
+		t.Errorf("test failed: template should be found in parent: %v", err)
+	}
+
+	if _, err := c.GetTemplated("consumer", "orders"); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := c.BindTemplate("", func(string) any { return nil }); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := c.Close(); err != nil || !orders.closed || !payments.closed {
+		t.Errorf("test failed: templated instances should be closed: %v", err)
+	}
+}
+
+type Codec interface {
+	Name() string
+}
+
+type namedCodec string
+
+func (c namedCodec) Name() string {
+	return string(c)
+}
+
+func TestInMap(t *testing.T) {
+	c := ioc.New()
+	c.Must(ioc.InMap[Codec](c, "codecs", "json", func() Codec { return namedCodec("json") }))
+	c.Must(ioc.InMap[Codec](c, "codecs", "yaml", func() (namedCodec, error) { return "yaml", nil }))
+
+	c.MustResolve(func(codecs map[string]Codec) {
+		if len(codecs) != 2 || codecs["json"].Name() != "json" || codecs["yaml"].Name() != "yaml" {
+			t.Errorf("test failed: %v", codecs)
+		}
+	})
+
+	// 子容器的条目覆盖父容器中相同 key 的条目，父容器的条目依然可见
+	child := ioc.Extend(c)
+	child.Must(ioc.InMap[Codec](child, "codecs", "yaml", namedCodec("yaml-v2")))
+	child.Must(ioc.InMap[Codec](child, "codecs", "toml", namedCodec("toml")))
+
+	codecs := child.MustGet(reflect.TypeOf(map[string]Codec(nil))).(map[string]Codec)
+	if len(codecs) != 3 || codecs["json"].Name() != "json" || codecs["yaml"].Name() != "yaml-v2" {
+		t.Errorf("test failed: %v", codecs)
+	}
+
+	if codecs := c.MustGet(reflect.TypeOf(map[string]Codec(nil))).(map[string]Codec); len(codecs) != 2 || codecs["yaml"].Name() != "yaml" {
+		t.Errorf("test failed: parent should not see entries of child: %v", codecs)
+	}
+
+	if err := c.Validate(); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := ioc.InMap[Codec](c, "codecs", "json", namedCodec("json")); !errors.Is(err, ioc.ErrRepeatedBind) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := ioc.InMap[Codec](c, "serializers", "json", namedCodec("json")); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: a value type belongs to one map: %v", err)
+	}
+
+	if err := ioc.InMap[Codec](c, "codecs", "xml", func() *UserRepo { return nil }); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := c.Get(reflect.TypeOf(map[string]InterfaceDemo(nil))); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestSimulateOverride(t *testing.T) {
+	c := ioc.New()
+	c.MustSingletonOverride(func() *UserRepo { return &UserRepo{connStr: "mysql"} })
+	c.MustSingleton(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+	c.MustPrototype(func(svc *UserService) RoleService { return RoleService{} })
+	c.MustSingleton(func() InterfaceDemo { return demo1{} })
+
+	c.MustResolve(func(svc *UserService) {})
+
+	report, err := c.SimulateOverride(new(UserRepo), func(demo InterfaceDemo, conf *TestObject) *UserRepo { return &UserRepo{connStr: "redis"} })
+	if err != nil {
+		t.Fatalf("test failed: %v", err)
+	}
+
+	if !report.Exists || len(report.Dependents) != 2 || report.Dependents[0] != reflect.TypeOf(&UserService{}) || report.Dependents[1] != reflect.TypeOf(RoleService{}) {
+		t.Errorf("test failed: %+v", report)
+	}
+
+	// 原型模式的 RoleService 不会过期，已初始化的单例 UserService 仍然持有旧的 UserRepo
+	if len(report.Stale) != 1 || report.Stale[0] != reflect.TypeOf(&UserService{}) {
+		t.Errorf("test failed: %+v", report)
+	}
+
+	if len(report.Missing) != 1 || report.Missing[0] != reflect.TypeOf(&TestObject{}) {
+		t.Errorf("test failed: %+v", report)
+	}
+
+	// 模拟覆盖不会修改容器
+	if c.MustGet(new(UserRepo)).(*UserRepo).connStr != "mysql" {
+		t.Error("test failed: container should not be changed")
+	}
+
+	if _, err := c.SimulateOverride(new(InterfaceDemo), func() demo1 { return demo1{} }); !errors.Is(err, ioc.ErrRepeatedBind) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := c.SimulateOverride(new(UserRepo), func() *UserService { return nil }); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	child := ioc.Extend(c)
+	if report, err := child.SimulateOverride(new(UserRepo), &UserRepo{connStr: "child"}); err != nil || report.Exists || len(report.Dependents) != 0 {
+		t.Errorf("test failed: %+v, %v", report, err)
+	}
+}
+
+func TestInitOrder(t *testing.T) {
+	c := ioc.New(ioc.WithDeferredEager())
+	c.MustBindValue("conn_str", "root@/my_db")
+	c.MustSingleton(ioc.WithOptions(func(repo *UserRepo) *UserService { return &UserService{repo: repo} }, ioc.WithEager()))
+	c.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "root@/my_db"} })
+	c.MustSingleton(func() InterfaceDemo { return demo1{} })
+	c.MustPrototype(func() RoleService { return RoleService{} })
+
+	if order := c.InitOrder(); len(order) != 0 {
+		t.Errorf("test failed: %v", order)
+	}
+
+	c.Must(c.Warmup())
+	c.MustGet(new(InterfaceDemo))
+	c.MustGet(RoleService{})
+
+	// 被依赖的 UserRepo 先于 UserService 完成初始化，原型及值绑定不会被记录
+	order := c.InitOrder()
+	if len(order) != 3 || order[0] != reflect.TypeOf(&UserRepo{}) || order[1] != reflect.TypeOf(&UserService{}) || order[2] != reflect.TypeOf((*InterfaceDemo)(nil)).Elem() {
+		t.Errorf("test failed: %v", order)
+	}
+
+	c.Must(c.Close())
+	if order := c.InitOrder(); len(order) != 0 {
+		t.Errorf("test failed: released singletons should not be included: %v", order)
+	}
+}
+
+func TestSharedCache(t *testing.T) {
+	root := ioc.New()
+
+	var created int32
+	newTenant := func(opts ...ioc.Option) ioc.Container {
+		tenant := ioc.Extend(root, opts...)
+		tenant.MustSingleton(func() *topicProducer {
+			atomic.AddInt32(&created, 1)
+			return &topicProducer{topic: "compiled"}
+		})
+		return tenant
+	}
+
+	tenants := make([]ioc.Container, 10)
+	for i := range tenants {
+		tenants[i] = newTenant(ioc.WithSharedCache(new(topicProducer)))
+	}
+
+	producers := make([]*topicProducer, len(tenants))
+	var wg sync.WaitGroup
+	for i, tenant := range tenants {
+		wg.Add(1)
+		go func(i int, tenant ioc.Container) {
+			defer wg.Done()
+			producers[i] = tenant.MustGet(new(topicProducer)).(*topicProducer)
+		}(i, tenant)
+	}
+	wg.Wait()
+
+	for _, p := range producers {
+		if p != producers[0] {
+			t.Fatal("test failed: the singleton should be shared by tenants")
+		}
+	}
+
+	if atomic.LoadInt32(&created) != 1 {
+		t.Errorf("test failed: created %d times", created)
+	}
+
+	// 未共享的子容器创建自己的实例
+	if newTenant().MustGet(new(topicProducer)).(*topicProducer) == producers[0] || atomic.LoadInt32(&created) != 2 {
+		t.Error("test failed: the singleton should not be shared")
+	}
+
+	// 子容器关闭时不会释放共享的实例，由父容器释放
+	if err := tenants[0].Close(); err != nil || producers[0].closed {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := root.Close(); err != nil || !producers[0].closed {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 创建失败不会被缓存
+	failing := true
+	newFailing := func() ioc.Container {
+		tenant := ioc.Extend(root, ioc.WithSharedCache(new(UserRepo)))
+		tenant.MustSingleton(func() (*UserRepo, error) {
+			if failing {
+				return nil, errors.New("fetch failed")
+			}
+			return &UserRepo{}, nil
+		})
+		return tenant
+	}
+
+	if _, err := newFailing().Get(new(UserRepo)); err == nil {
+		t.Error("test failed")
+	}
+
+	failing = false
+	if _, err := newFailing().Get(new(UserRepo)); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestValueSnapshot(t *testing.T) {
+	c := ioc.New()
+	clock := ioctest.UseFakeClock(c)
+	c.MustBindValue("db.host", "10.0.0.1")
+	c.MustBindValue("db.port", 3306)
+	c.MustBindValue("db.password", ioc.WithOptions("s3cr3t", ioc.SecretMarker()))
+	c.MustSingleton(func() *UserRepo { return &UserRepo{} })
+
+	var buf bytes.Buffer
+	c.Must(c.SaveSnapshot(&buf, nil))
+	if strings.Contains(buf.String(), "s3cr3t") || strings.Contains(buf.String(), "UserRepo") {
+		t.Errorf("test failed: secret values and constructors should not be persisted: %s", buf.String())
+	}
+
+	data := buf.Bytes()
+
+	restored := ioc.New()
+	ioctest.UseFakeClock(restored).Set(clock.Now().Add(time.Minute))
+	ok, err := restored.RestoreSnapshot(bytes.NewReader(data), ioc.JSONSnapshotCodec, time.Hour)
+	if err != nil || !ok {
+		t.Fatalf("test failed: %v", err)
+	}
+
+	// JSON 编码后数字类型变为 float64
+	if restored.MustGet("db.host") != "10.0.0.1" || restored.MustGet("db.port") != float64(3306) || restored.HasBoundValue("db.password") {
+		t.Error("test failed")
+	}
+
+	// 快照过期时不会恢复
+	stale := ioc.New()
+	ioctest.UseFakeClock(stale).Set(clock.Now().Add(2 * time.Hour))
+	if ok, err := stale.RestoreSnapshot(bytes.NewReader(data), nil, time.Hour); ok || err != nil || stale.HasBoundValue("db.host") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	conflicted := ioc.New()
+	conflicted.MustBindValue("db.port", 3307)
+	if _, err := conflicted.RestoreSnapshot(bytes.NewReader(data), nil, 0); !errors.Is(err, ioc.ErrRepeatedBind) || conflicted.HasBoundValue("db.host") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := ioc.New().RestoreSnapshot(strings.NewReader("not json"), nil, 0); err == nil {
+		t.Error("test failed")
+	}
+}
+
+type cycleNotifier interface {
+	Notify(user string)
+}
+
+type cycleUsers struct {
+	notifier cycleNotifier
+}
+
+type cycleMailer struct {
+	users *cycleUsers
+}
+
+func (m *cycleMailer) Notify(user string) {}
+
+func TestLintDependencyCycle(t *testing.T) {
+	cycles := func(c ioc.Container) []ioc.Issue {
+		issues := make([]ioc.Issue, 0)
+		for _, issue := range ioc.Lint(c) {
+			if issue.Kind == ioc.IssueDependencyCycle {
+				issues = append(issues, issue)
+			}
+		}
+		return issues
+	}
+
+	c := ioc.New()
+	c.MustSingleton(func(notifier cycleNotifier) *cycleUsers { return &cycleUsers{notifier: notifier} })
+	c.MustSingleton(func(users *cycleUsers) cycleNotifier { return &cycleMailer{users: users} })
+	c.MustSingleton(func(users *cycleUsers) *UserService { return &UserService{} })
+
+	issues := cycles(c)
+	if len(issues) != 1 || issues[0].Key != reflect.TypeOf(&cycleUsers{}) {
+		t.Fatalf("test failed: %v", issues)
+	}
+
+	// 建议打断依赖接口的那条边
+	if !strings.Contains(issues[0].Message, "*ioc_test.cycleUsers -> ioc_test.cycleNotifier -> *ioc_test.cycleUsers") ||
+		!strings.Contains(issues[0].Message, "[*ioc_test.cycleUsers -> ioc_test.cycleNotifier]") {
+		t.Errorf("test failed: %s", issues[0].Message)
+	}
+
+	// 没有接口依赖时，建议引入接口
+	c2 := ioc.New()
+	c2.MustSingleton(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+	c2.MustSingleton(func(svc *UserService) *UserRepo { return &UserRepo{} })
+	if issues := cycles(c2); len(issues) != 1 || !strings.Contains(issues[0].Message, "introduce an interface") {
+		t.Errorf("test failed: %v", issues)
+	}
+
+	// 使用 Factory 延迟获取时不存在循环
+	c3 := ioc.New()
+	c3.MustSingleton(func(notifier ioc.Factory[cycleNotifier]) *cycleUsers { return &cycleUsers{} })
+	c3.MustSingleton(func(users *cycleUsers) cycleNotifier { return &cycleMailer{users: users} })
+	if issues := cycles(c3); len(issues) != 0 {
+		t.Errorf("test failed: %v", issues)
+	}
+}
+
+func TestFastSingletonGet(t *testing.T) {
+	c := ioc.New()
+	created := 0
+	c.MustSingletonOverride(func() *UserRepo {
+		created++
+		return &UserRepo{connStr: fmt.Sprintf("conn-%d", created)}
+	})
+
+	// 初始化之后的单例从快速路径中获取，实例不变
+	first := c.MustGet(new(UserRepo))
+	for i := 0; i < 3; i++ {
+		if c.MustGet(new(UserRepo)) != first {
+			t.Fatal("test failed")
+		}
+	}
+
+	if created != 1 {
+		t.Errorf("test failed: %d", created)
+	}
+
+	// 覆盖绑定之后不再返回旧的实例
+	c.MustSingletonOverride(func() *UserRepo { return &UserRepo{connStr: "override"} })
+	for i := 0; i < 2; i++ {
+		if repo := c.MustGet(new(UserRepo)).(*UserRepo); repo.connStr != "override" {
+			t.Errorf("test failed: %s", repo.connStr)
+		}
+	}
+
+	// 新增的拦截器同样生效
+	calls := 0
+	remove, err := c.Intercept(new(UserRepo), func(key any, next func() (any, error)) (any, error) {
+		calls++
+		return next()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.MustGet(new(UserRepo))
+	c.MustGet(new(UserRepo))
+	remove()
+	if calls != 2 {
+		t.Errorf("test failed: %d", calls)
+	}
+
+	// 开启 profiling 后每次解析都会被记录
+	c.EnableProfiling()
+	c.MustGet(new(UserRepo))
+	c.MustGet(new(UserRepo))
+	if workload := c.DisableProfiling(); len(workload.Entries) != 1 || workload.Entries[0].Count != 2 {
+		t.Errorf("test failed: %v", workload)
+	}
+
+	// 子容器中解析的结果与父容器一致
+	if c.NewChild().MustGet(new(UserRepo)) != c.MustGet(new(UserRepo)) {
+		t.Error("test failed")
+	}
+}
+
+func TestErrorTaxonomy(t *testing.T) {
+	// 单例之间的循环依赖返回 ErrCircularDependency，而不是永久等待
+	c := ioc.New()
+	c.MustSingleton(func(notifier cycleNotifier) *cycleUsers { return &cycleUsers{notifier: notifier} })
+	c.MustSingleton(func(users *cycleUsers) cycleNotifier { return &cycleMailer{users: users} })
+	if _, err := c.Get(new(cycleUsers)); !errors.Is(err, ioc.ErrCircularDependency) ||
+		!strings.Contains(err.Error(), "*ioc_test.cycleUsers -> ioc_test.cycleNotifier -> *ioc_test.cycleUsers") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := c.Validate(); !errors.Is(err, ioc.ErrCircularDependency) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 原型之间的循环依赖不会无限递归
+	c = ioc.New()
+	c.MustPrototype(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+	c.MustPrototype(func(svc *UserService) *UserRepo { return &UserRepo{} })
+	if _, err := c.Get(new(UserService)); !errors.Is(err, ioc.ErrCircularDependency) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 构造函数 panic 被转换为 ErrConstructorPanic，原始错误链被保留
+	c = ioc.New()
+	c.MustSingleton(func() (*UserRepo, error) { panic(io.ErrUnexpectedEOF) })
+	c.MustSingleton(func() *RoleService { panic("boom") })
+	if _, err := c.Get(new(UserRepo)); !errors.Is(err, ioc.ErrConstructorPanic) || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := c.Get(new(RoleService)); !errors.Is(err, ioc.ErrConstructorPanic) || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 条件函数执行失败时返回 ErrConditionFailed，原始错误链被保留
+	err := c.Singleton(ioc.WithCondition(func() *UserService { return &UserService{} }, func(svc *UserService) bool { return svc != nil }))
+	if !errors.Is(err, ioc.ErrConditionFailed) || !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 关闭之后的容器无法再解析，子容器解析父容器中的绑定同样失败
+	c = ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	child := c.NewChild()
+	child.MustGet(new(UserRepo))
+	c.Must(c.Close())
+
+	if _, err := c.Get(new(UserRepo)); !errors.Is(err, ioc.ErrScopeClosed) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := child.Get(new(UserRepo)); !errors.Is(err, ioc.ErrScopeClosed) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type lockedDemo struct {
+	inner InterfaceDemo
+	lock  sync.Locker
+}
+
+func (d lockedDemo) String() string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return "locked " + d.inner.String()
+}
+
+func TestNotThreadSafe(t *testing.T) {
+	c := ioc.New()
+
+	// 非线程安全的绑定不能作为全局单例
+	if err := c.Singleton(ioc.WithOptions(func() *UserRepo { return &UserRepo{} }, ioc.NotThreadSafe())); !errors.Is(err, ioc.ErrNotThreadSafe) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 原型以及请求作用域中的单例不受限制
+	c.MustPrototype(ioc.WithOptions(func() *UserRepo { return &UserRepo{} }, ioc.NotThreadSafe()))
+	if c.MustGet(new(UserRepo)) == c.MustGet(new(UserRepo)) {
+		t.Error("test failed: prototype should create new instances")
+	}
+
+	if info, err := c.Lookup(new(UserRepo)); err != nil || info.ThreadSafe {
+		t.Errorf("test failed: %v, %v", info, err)
+	}
+
+	req := c.NewChild(ioc.WithRequestScope("req-1"))
+	if err := req.Singleton(ioc.WithOptions(func() *RoleService { return &RoleService{} }, ioc.NotThreadSafe())); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 接口类型的单例可以通过代理加锁后共享
+	proxy := ioc.NotThreadSafeProxy(func(inner InterfaceDemo, lock sync.Locker) InterfaceDemo {
+		return lockedDemo{inner: inner, lock: lock}
+	})
+	c.MustSingleton(ioc.WithOptions(func() InterfaceDemo { return demo1{} }, proxy))
+	if demo := c.MustGet(new(InterfaceDemo)).(InterfaceDemo); demo.String() != "locked demo1" {
+		t.Errorf("test failed: %s", demo.String())
+	}
+
+	// 代理的接口类型必须与绑定的 key 一致
+	if err := c.Singleton(ioc.WithOptions(func() *UserService { return &UserService{} }, proxy)); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestBindBuildInfo(t *testing.T) {
+	c := ioc.New()
+	c.Must(c.BindBuildInfo())
+
+	build, ok := ioc.CurrentBuildInfo()
+	if !ok {
+		t.Skip("build info is not available")
+	}
+
+	c.MustResolve(func(info ioc.BuildInfo) {
+		if info != build {
+			t.Errorf("test failed: %v", info)
+		}
+	})
+
+	if c.MustGet(ioc.BuildGoVersionKey) != runtime.Version() {
+		t.Errorf("test failed: %v", c.MustGet(ioc.BuildGoVersionKey))
+	}
+
+	// 没有 VCS 信息时（如 go test 构建的测试程序）不绑定对应的值
+	if build.Revision == "" && (c.HasBoundValue(ioc.BuildRevisionKey) || c.HasBoundValue(ioc.BuildModifiedKey)) {
+		t.Error("test failed")
+	}
+
+	// 重复绑定返回 ErrRepeatedBind
+	if err := c.BindBuildInfo(); !errors.Is(err, ioc.ErrRepeatedBind) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestLoadWiring(t *testing.T) {
+	c := ioc.New()
+	c.MustRegisterFactory("postgres", func() InterfaceDemo { return demo1{} })
+	c.MustRegisterFactory("memory", func() InterfaceDemo { return demo2{} })
+	c.MustRegisterFactory("repo", func() (*UserRepo, error) { return &UserRepo{connStr: "wired"}, nil })
+
+	if err := c.RegisterFactory("memory", func() InterfaceDemo { return demo2{} }); !errors.Is(err, ioc.ErrRepeatedBind) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := c.RegisterFactory("invalid", func() {}); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 子容器可以使用父容器中注册的构造函数，根据配置选择实现
+	child := c.NewChild()
+	child.Must(child.LoadWiring(strings.NewReader(`{"bindings": [{"factory": "memory"}, {"factory": "repo", "version": "primary", "prototype": true}]}`), nil))
+	if child.MustGet(new(InterfaceDemo)).(InterfaceDemo).String() != "demo2" {
+		t.Error("test failed")
+	}
+
+	repo, err := child.GetVersion(new(UserRepo), "primary")
+	if err != nil || repo.(*UserRepo).connStr != "wired" {
+		t.Errorf("test failed: %v, %v", repo, err)
+	}
+
+	if again, _ := child.GetVersion(new(UserRepo), "primary"); again == repo {
+		t.Error("test failed: prototype should create new instances")
+	}
+
+	if c.HasBound(new(InterfaceDemo)) {
+		t.Error("test failed: wiring of child should not be bound in parent")
+	}
+
+	// 未注册的构造函数不会导致部分绑定
+	err = c.LoadWiring(strings.NewReader(`{"bindings": [{"factory": "postgres"}, {"factory": "mysql"}]}`), nil)
+	if !errors.Is(err, ioc.ErrObjectNotFound) || !strings.Contains(err.Error(), "registered: memory, postgres, repo") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if c.HasBound(new(InterfaceDemo)) {
+		t.Error("test failed: nothing should be bound")
+	}
+
+	// 未知的字段视为配置错误
+	if err := c.LoadWiring(strings.NewReader(`{"bindings": [{"factroy": "postgres"}]}`), nil); err == nil || !strings.Contains(err.Error(), "decode wiring failed") {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestHierarchicalShutdown(t *testing.T) {
+	root := ioc.New()
+
+	closed := make([]string, 0)
+	disposer := func(name string) ioc.BindOption {
+		return ioc.WithDisposer(func(any) error {
+			closed = append(closed, name)
+			return nil
+		})
+	}
+
+	root.MustSingleton(ioc.WithOptions(func() *UserRepo { return &UserRepo{} }, disposer("root")))
+
+	newRequest := func(name string, opts ...ioc.Option) ioc.Container {
+		req := root.NewChild(opts...)
+		req.MustSingleton(ioc.WithOptions(func(repo *UserRepo) *UserService { return &UserService{repo: repo} }, disposer(name)))
+		req.MustGet(new(UserService))
+		return req
+	}
+
+	first := newRequest("first")
+	second := newRequest("second")
+	detached := newRequest("detached", ioc.WithDetached())
+	finished := newRequest("finished")
+	finished.Must(finished.Close())
+
+	// 没有需要释放的单例的子容器不会被跟踪
+	idle := root.NewChild()
+	idle.MustGet(new(UserRepo))
+
+	// 子容器先于父容器关闭（后创建的先关闭），已关闭及 detached 的子容器不受影响
+	root.Must(root.Close())
+	if fmt.Sprint(closed) != "[finished second first root]" {
+		t.Errorf("test failed: %v", closed)
+	}
+
+	for _, c := range []ioc.Container{first, second, idle} {
+		if _, err := c.Get(new(UserRepo)); !errors.Is(err, ioc.ErrScopeClosed) {
+			t.Errorf("test failed: %v", err)
+		}
+	}
+
+	detached.Must(detached.Close())
+	if closed[len(closed)-1] != "detached" {
+		t.Errorf("test failed: %v", closed)
+	}
+}
+
+func TestBindingLabels(t *testing.T) {
+	resolutions := make([]ioc.Resolution, 0)
+	c := ioc.New(ioc.WithResolutionObserver(func(r ioc.Resolution) {
+		resolutions = append(resolutions, r)
+	}))
+
+	c.MustSingleton(ioc.WithOptions(
+		func() *UserRepo { return &UserRepo{} },
+		ioc.WithLabels(map[string]string{"team": "users", "subsystem": "storage"}),
+		ioc.WithLabels(map[string]string{"subsystem": "db"}),
+	))
+	c.MustSingleton(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+
+	// 初始化后的单例同样会上报给 observer
+	c.MustGet(new(UserService))
+	c.MustGet(new(UserService))
+
+	labels := make([]string, 0)
+	for _, r := range resolutions {
+		labels = append(labels, fmt.Sprintf("%v%v", r.Key, r.Labels))
+	}
+
+	if fmt.Sprint(labels) != "[*ioc_test.UserRepomap[subsystem:db team:users] *ioc_test.UserServicemap[] *ioc_test.UserServicemap[]]" {
+		t.Errorf("test failed: %v", labels)
+	}
+
+	info, _ := c.Lookup(new(UserRepo))
+	if info.Labels["team"] != "users" {
+		t.Errorf("test failed: %v", info.Labels)
+	}
+
+	// BindingInfo 中的 labels 是副本
+	info.Labels["team"] = "orders"
+	if info, _ := c.Lookup(new(UserRepo)); info.Labels["team"] != "users" {
+		t.Errorf("test failed: %v", info.Labels)
+	}
+
+	c.EnableProfiling()
+	c.MustGet(new(UserRepo))
+	if workload := c.DisableProfiling(); len(workload.Entries) != 1 || workload.Entries[0].Labels["subsystem"] != "db" {
+		t.Errorf("test failed: %v", workload)
+	}
+}
+
+type schemaMigrations struct{ applied bool }
+
+func TestWithDependsOn(t *testing.T) {
+	c := ioc.New(ioc.WithDeferredEager())
+
+	order := make([]string, 0)
+	c.MustSingleton(ioc.WithOptions(func() *UserRepo {
+		order = append(order, "repo")
+		return &UserRepo{}
+	}, ioc.WithEager(), ioc.WithDependsOn(new(schemaMigrations))))
+	c.MustSingleton(func() *schemaMigrations {
+		order = append(order, "migrations")
+		return &schemaMigrations{applied: true}
+	})
+
+	// 虽然 UserRepo 先注册且没有注入 schemaMigrations，迁移仍然先执行
+	c.Must(c.Warmup())
+	if fmt.Sprint(order) != "[migrations repo]" {
+		t.Errorf("test failed: %v", order)
+	}
+
+	for _, node := range c.Graph().Nodes {
+		if node.Key == reflect.TypeOf(&UserRepo{}) && fmt.Sprint(node.Dependencies) != "[*ioc_test.schemaMigrations]" {
+			t.Errorf("test failed: %v", node.Dependencies)
+		}
+	}
+
+	// 声明的依赖不存在时，创建失败并且 Validate 会报告
+	c2 := ioc.New()
+	c2.MustSingleton(ioc.WithOptions(func() *UserRepo { return &UserRepo{} }, ioc.WithDependsOn(new(schemaMigrations))))
+	if _, err := c2.Get(new(UserRepo)); !errors.Is(err, ioc.ErrArgsNotInstanced) || !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := c2.Validate(); !errors.Is(err, ioc.ErrArgsNotInstanced) || !strings.Contains(err.Error(), "WithDependsOn") {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type countingDemo struct {
+	inner InterfaceDemo
+	label string
+}
+
+func (d countingDemo) String() string { return d.label + "(" + d.inner.String() + ")" }
+
+func TestDecorate(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() InterfaceDemo { return demo1{} })
+	c.MustBindValue("label", "traced")
+
+	// 后添加的装饰器包装先添加的装饰器
+	ioc.MustDecorate(c, func(inner InterfaceDemo) InterfaceDemo { return countingDemo{inner: inner, label: "logged"} })
+	type deps struct {
+		ioc.In
+		Label string `name:"label"`
+	}
+	if err := ioc.DecorateWith(c, func(inner InterfaceDemo, d deps) InterfaceDemo { return countingDemo{inner: inner, label: d.Label} }); err != nil {
+		t.Fatal(err)
+	}
+
+	demo := c.MustGet(new(InterfaceDemo)).(InterfaceDemo)
+	if demo.String() != "traced(logged(demo1))" || c.MustGet(new(InterfaceDemo)) != demo {
+		t.Errorf("test failed: %s", demo.String())
+	}
+
+	// 已经实例化的单例不能再被装饰
+	if err := ioc.Decorate(c, func(inner InterfaceDemo) InterfaceDemo { return inner }); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 只能装饰当前容器中的绑定
+	if err := ioc.Decorate(c.NewChild(), func(inner InterfaceDemo) InterfaceDemo { return inner }); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 装饰器的依赖无法解析时，创建失败
+	c.MustPrototype(func() *UserRepo { return &UserRepo{} })
+	if err := ioc.DecorateWith(c, func(inner *UserRepo, svc *UserService) *UserRepo { return inner }); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Get(new(UserRepo)); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestAsProvider(t *testing.T) {
+	core := ioc.New()
+	core.MustBindValue("conn_str", "root:root@/my_db?charset=utf8")
+	core.MustSingleton(func(c ioc.Container) *UserRepo {
+		return &UserRepo{connStr: c.MustGet("conn_str").(string)}
+	})
+	core.MustSingleton(func() InterfaceDemo { return demo1{} })
+
+	shared := core.AsProvider(new(UserRepo), "conn_str", new(InterfaceDemo))
+
+	// 另一个独立的容器通过 provider 使用 core 中的绑定，单例在容器之间共享
+	plugin := ioc.New()
+	plugin.MustPrototype(func(repo *UserRepo) *UserService { return &UserService{repo: repo} })
+	type params struct {
+		ioc.In
+		ConnStr string `name:"conn_str"`
+	}
+	if _, err := plugin.CallWithProvider(func(repo *UserRepo, p params, demo InterfaceDemo) {
+		if repo != core.MustGet(new(UserRepo)) || p.ConnStr != repo.connStr || demo.String() != "demo1" {
+			t.Error("test failed: exported bindings should be resolved from core")
+		}
+	}, shared); err != nil {
+		t.Fatal(err)
+	}
+
+	// plugin 中绑定的构造函数也可以依赖导出的绑定
+	if _, err := plugin.CallWithProvider(func(svc *UserService) {
+		if svc.GetUser() != expectedValue {
+			t.Error("test failed")
+		}
+	}, shared); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未导出的 key 不可见
+	core.MustSingleton(func() *RoleService { return &RoleService{} })
+	if _, err := plugin.CallWithProvider(func(svc *RoleService) {}, shared); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 未绑定的 key 无法导出
+	func() {
+		defer func() {
+			if err, ok := recover().(error); !ok || !errors.Is(err, ioc.ErrObjectNotFound) {
+				t.Errorf("test failed: %v", err)
+			}
+		}()
+		core.AsProvider(new(TestObject))
+	}()
+}
+
+// sharedModule is a library module depended on by several modules
+type sharedModule struct {
+	loads *int
+}
+
+func (sharedModule) ModuleName() string { return "shared" }
+
+func (m sharedModule) Register(binder ioc.Binder) error {
+	*m.loads++
+	return binder.Singleton(func() *UserRepo { return &UserRepo{connStr: "shared"} })
+}
+
+// dependentModule load sharedModule before registering its own bindings
+type dependentModule struct {
+	shared sharedModule
+	init   any
+}
+
+func (m *dependentModule) Register(binder ioc.Binder) error {
+	if err := binder.Load(m.shared); err != nil {
+		return err
+	}
+
+	return binder.Prototype(m.init)
+}
+
+type failingModule struct {
+	fail bool
+}
+
+func (m *failingModule) Register(binder ioc.Binder) error {
+	if m.fail {
+		return errors.New("not ready")
+	}
+
+	return binder.Singleton(func() *RoleService { return &RoleService{} })
+}
+
+func TestLoadGuard(t *testing.T) {
+	var loads int
+	shared := sharedModule{loads: &loads}
+
+	users := &dependentModule{shared: shared, init: func(repo *UserRepo) *UserService { return &UserService{repo: repo} }}
+	demos := &dependentModule{shared: shared, init: func() InterfaceDemo { return demo1{} }}
+
+	c := ioc.New()
+	if err := c.Load(users, demos, users); err != nil {
+		t.Fatal(err)
+	}
+
+	if loads != 1 || c.MustGet(new(UserService)).(*UserService).repo.connStr != "shared" {
+		t.Errorf("test failed: shared module loaded %d times", loads)
+	}
+
+	// 不可比较的模块没有加载保护
+	type unguarded struct {
+		*dependentModule
+		tags []string
+	}
+	if err := c.Load(unguarded{dependentModule: users}); !errors.Is(err, ioc.ErrRepeatedBind) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 加载失败的模块可以重新加载
+	failing := &failingModule{fail: true}
+	if err := c.Load(failing); err == nil {
+		t.Fatal("test failed")
+	}
+
+	failing.fail = false
+	if err := c.Load(failing); err != nil {
+		t.Fatal(err)
+	}
+
+	// 子容器中可以独立加载同一个模块
+	child := c.NewChild()
+	child.MustLoad(shared)
+	if loads != 2 {
+		t.Errorf("test failed: shared module loaded %d times", loads)
+	}
+}
+
+// TestBindWithInterfaceKey 测试使用接口指针作为 key 绑定时的类型检查
+func TestBindWithInterfaceKey(t *testing.T) {
+	c := ioc.New()
+	if err := c.SingletonWithKey(new(InterfaceDemo), func() demo2 { return demo2{} }); err != nil {
+		t.Fatal(err)
+	}
+
+	// 绑定到接口类型上，与 Get 的查找规则一致
+	if demo, err := c.Get(new(InterfaceDemo)); err != nil || demo.(InterfaceDemo).String() != "demo2" {
+		t.Errorf("test failed: %v, %v", demo, err)
+	}
+
+	// 构造函数的返回值没有实现接口时，绑定失败，错误信息包含两个类型
+	err := c.PrototypeWithKey(new(InterfaceDemo), func() *UserRepo { return &UserRepo{} })
+	if !errors.Is(err, ioc.ErrInvalidArgs) || !strings.Contains(err.Error(), "*ioc_test.UserRepo does not implement key type ioc_test.InterfaceDemo") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := c.BindWithKey(new(InterfaceDemo), UserRepo{}, false, true); !errors.Is(err, ioc.ErrInvalidArgs) || !strings.Contains(err.Error(), "ioc_test.UserRepo does not implement") {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+// TestAutoWirePrimary 测试接口字段注入 Primary 实现以及命名的绑定
+func TestAutoWirePrimary(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() demo1 { return demo1{} })
+	c.MustSingleton(ioc.WithOptions(func() demo2 { return demo2{} }, ioc.Primary()))
+	if err := ioc.BindKeyed[InterfaceDemo](c, "first", func() InterfaceDemo { return demo1{} }); err != nil {
+		t.Fatal(err)
+	}
+	c.Must(c.SingletonVersioned(new(InterfaceDemo), "v2", func() InterfaceDemo { return demo2{} }))
+
+	var target struct {
+		Primary InterfaceDemo `autowire:"@"`
+		First   InterfaceDemo `autowire:"@first"`
+		V2      InterfaceDemo `autowire:"@v2"`
+	}
+	if err := c.AutoWire(&target); err != nil {
+		t.Fatal(err)
+	}
+
+	if target.Primary.String() != "demo2" || target.First.String() != "demo1" || target.V2.String() != "demo2" {
+		t.Errorf("test failed: %v", target)
+	}
+
+	// 没有对应名称的绑定
+	var missingNamed struct {
+		Demo InterfaceDemo `autowire:"@missing"`
+	}
+	if err := c.AutoWire(&missingNamed); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 子容器中的 Primary 实现优先，同一容器中存在多个 Primary 实现时失败
+	child := c.NewChild()
+	child.MustSingleton(ioc.WithOptions(func() countingDemo { return countingDemo{inner: demo1{}, label: "child"} }, ioc.Primary()))
+	var childTarget struct {
+		Demo InterfaceDemo `autowire:"@"`
+	}
+	if err := child.AutoWire(&childTarget); err != nil || childTarget.Demo.String() != "child(demo1)" {
+		t.Errorf("test failed: %v, %v", childTarget, err)
+	}
+
+	c.MustSingleton(ioc.WithOptions(func() *demo1 { return &demo1{} }, ioc.Primary()))
+	if err := c.AutoWire(&childTarget); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 接口本身有绑定时优先使用
+	c.MustSingleton(func() InterfaceDemo { return demo1{} })
+	if err := c.AutoWire(&childTarget); err != nil || childTarget.Demo.String() != "demo1" {
+		t.Errorf("test failed: %v, %v", childTarget, err)
+	}
+
+	// 接口本身的绑定缺少依赖时返回错误，不使用 Primary 实现
+	broken := ioc.New()
+	broken.MustSingleton(ioc.WithOptions(func() demo2 { return demo2{} }, ioc.Primary()))
+	broken.MustSingleton(func(*RoleService) InterfaceDemo { return demo1{} })
+	if err := broken.AutoWire(&childTarget); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: broken binding should not fall back to primary: %v", err)
+	}
+}
+
+type listenConfig struct {
+	Listen  string        `mapstructure:"listen"`
+	Timeout time.Duration `mapstructure:"timeout"`
+	Debug   bool
+}
+
+type populatedConfig struct {
+	listenConfig `mapstructure:",squash"`
+	Region       string `mapstructure:"region"`
+	DB           struct {
+		DSN      string `mapstructure:"dsn"`
+		MaxConns int    `mapstructure:"max_conns"`
+	} `mapstructure:"db"`
+	Tags    []string `mapstructure:"tags"`
+	Ignored string   `mapstructure:"-"`
+}
+
+// TestPopulateConfig 测试从多个配置源按优先级填充配置
+func TestPopulateConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"listen": ":80", "timeout": "5s", "db": {"dsn": "file-dsn", "max_conns": 8}, "tags": ["a", "b"], "ignored": "x"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("APP_DB_DSN", "env-dsn")
+
+	c := ioc.New()
+	c.MustBindValue("app.db.max_conns", "16")
+	c.MustBindValue("app.debug", "true")
+	c.MustBindValue("app.db.dsn", "values-dsn")
+
+	cfg := populatedConfig{Region: "cn", Ignored: "default"}
+	cfg.Listen = ":8080"
+	if err := c.PopulateConfig(&cfg, ioc.FromEnv("APP_"), ioc.FromValues("app."), ioc.FromFile(file)); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.DB.DSN != "env-dsn" || cfg.DB.MaxConns != 16 || !cfg.Debug || cfg.Listen != ":80" || cfg.Timeout != 5*time.Second {
+		t.Errorf("test failed: %+v", cfg)
+	}
+
+	// 没有配置源提供的字段保留原来的值
+	if !reflect.DeepEqual(cfg.Tags, []string{"a", "b"}) || cfg.Ignored != "default" || cfg.Region != "cn" {
+		t.Errorf("test failed: %+v", cfg)
+	}
+
+	// 填充的配置被绑定为单例
+	c.MustResolve(func(bound *populatedConfig) {
+		if bound != &cfg {
+			t.Error("test failed: config should be bound")
+		}
+	})
+
+	// 带小数部分的浮点数不会被截断为整数
+	fractional := filepath.Join(t.TempDir(), "fractional.json")
+	if err := os.WriteFile(fractional, []byte(`{"db": {"max_conns": 1.9}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioc.New().PopulateConfig(&populatedConfig{}, ioc.FromFile(fractional)); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	integral := filepath.Join(t.TempDir(), "integral.json")
+	if err := os.WriteFile(integral, []byte(`{"db": {"max_conns": 2}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	integralCfg := populatedConfig{}
+	if err := ioc.New().PopulateConfig(&integralCfg, ioc.FromFile(integral)); err != nil || integralCfg.DB.MaxConns != 2 {
+		t.Errorf("test failed: %v, %+v", err, integralCfg)
+	}
+
+	// 转换失败时错误中包含配置路径
+	t.Setenv("APP_TIMEOUT", "soon")
+	if err := ioc.New().PopulateConfig(&populatedConfig{}, ioc.FromEnv("APP_")); !errors.Is(err, ioc.ErrValueConversion) || !strings.Contains(err.Error(), "config timeout") {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if err := ioc.New().PopulateConfig(&populatedConfig{}, ioc.FromFile(file+".missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type orderService struct {
+	users ioc.Reference[*userDirectory]
+}
+
+type userDirectory struct {
+	orders ioc.Reference[*orderService]
+}
+
+type orderModule struct{}
+
+func (orderModule) Register(binder ioc.Binder) error {
+	users := ioc.Ref[*userDirectory](binder)
+	return binder.Singleton(func() *orderService { return &orderService{users: users} })
+}
+
+// TestReference 测试延迟解析的前向引用
+func TestReference(t *testing.T) {
+	c := ioc.New()
+
+	// orderModule 引用的 userDirectory 在之后才注册
+	c.MustLoad(orderModule{})
+	c.MustSingleton(func(orders ioc.Reference[*orderService]) *userDirectory { return &userDirectory{orders: orders} })
+
+	orders := c.MustGet(new(orderService)).(*orderService)
+	users := orders.users.MustGet()
+	if !orders.users.Bound() || users.orders.MustGet() != orders || c.MustGet(new(userDirectory)) != users {
+		t.Error("test failed: references should resolve the singletons")
+	}
+
+	// 引用的绑定不存在时，在使用时返回错误
+	r := ioc.Ref[*RoleService](c)
+	if _, err := r.Get(); r.Bound() || !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	var unbound ioc.Reference[*RoleService]
+	if _, err := unbound.Get(); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	// 相互引用不是循环依赖
+	if err := c.Validate(); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+func TestReferenceOutlivesContext(t *testing.T) {
+	c := ioc.New()
+	c.MustLoad(orderModule{})
+	c.MustSingleton(func(orders ioc.Reference[*orderService]) *userDirectory { return &userDirectory{orders: orders} })
+
+	// 单例在请求的 ctx 中首次创建，ctx 结束后其持有的 Reference 仍然可用
+	ctx, cancel := context.WithCancel(context.Background())
+	var users *userDirectory
+	if _, err := c.CallWithContext(ctx, func(u *userDirectory) { users = u }); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	if _, err := users.orders.Get(); err != nil {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+// TestGeneration 测试绑定的版本号以及变更通知
+func TestGeneration(t *testing.T) {
+	c := ioc.New()
+
+	var changes int
+	remove := c.OnChange(func() {
+		changes++
+		// 监听函数可以访问容器
+		_ = c.HasBound(new(UserRepo))
+	})
+
+	generation := c.Generation()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	if c.Generation() <= generation || changes != 1 {
+		t.Errorf("test failed: generation=%d, changes=%d", c.Generation(), changes)
+	}
+
+	// 解析不会改变版本号
+	generation = c.Generation()
+	c.MustGet(new(UserRepo))
+	if c.Generation() != generation || changes != 1 {
+		t.Error("test failed: resolutions should not change the generation")
+	}
+
+	// 绑定失败不会通知
+	if err := c.Singleton(func() *UserRepo { return &UserRepo{} }); !errors.Is(err, ioc.ErrRepeatedBind) || changes != 1 {
+		t.Errorf("test failed: %v, changes=%d", err, changes)
+	}
+
+	removeInterceptor, err := c.Intercept(new(UserRepo), func(key any, next func() (any, error)) (any, error) { return next() })
+	if err != nil {
+		t.Fatal(err)
+	}
+	removeInterceptor()
+
+	if err := c.Unbind(new(UserRepo)); err != nil {
+		t.Fatal(err)
+	}
+
+	if changes != 4 {
+		t.Errorf("test failed: changes=%d", changes)
+	}
+
+	remove()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{} })
+	if changes != 4 {
+		t.Errorf("test failed: removed listener should not be called, changes=%d", changes)
+	}
+}
+
+// TestGetNamedAll 测试获取某个类型所有命名的绑定
+func TestGetNamedAll(t *testing.T) {
+	c := ioc.New()
+	c.Must(ioc.BindKeyed[InterfaceDemo](c, "first", func() InterfaceDemo { return demo1{} }))
+	c.Must(ioc.BindKeyed[InterfaceDemo](c, "second", demo2{}))
+	c.Must(ioc.BindKeyed[*UserRepo](c, "users", &UserRepo{}))
+
+	child := c.NewChild()
+	child.Must(ioc.BindKeyed[InterfaceDemo](child, "second", countingDemo{inner: demo2{}, label: "child"}))
+
+	demos, err := child.GetNamedAll(new(InterfaceDemo))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	typed := demos.(map[string]InterfaceDemo)
+	if len(typed) != 2 || typed["first"].String() != "demo1" || typed["second"].String() != "child(demo2)" {
+		t.Errorf("test failed: %v", typed)
+	}
+
+	repos, err := ioc.GetAllKeyed[*UserRepo](child)
+	if err != nil || len(repos) != 1 || repos["users"] == nil {
+		t.Errorf("test failed: %v, %v", repos, err)
+	}
+
+	// 没有命名绑定时返回空 map
+	if roles, err := ioc.GetAllKeyed[*RoleService](c); err != nil || roles == nil || len(roles) != 0 {
+		t.Errorf("test failed: %v, %v", roles, err)
+	}
+
+	if _, err := c.GetNamedAll("demo"); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+// TestConditionError 测试条件函数执行失败时的错误信息
+func TestConditionError(t *testing.T) {
+	c := ioc.New()
+
+	// 条件函数的参数无法解析
+	err := c.Singleton(ioc.WithCondition(func() *UserService { return &UserService{} }, func(repo *UserRepo) bool { return repo != nil }))
+	var condErr *ioc.ConditionError
+	if !errors.As(err, &condErr) || !errors.Is(err, ioc.ErrConditionFailed) || !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Fatalf("test failed: %v", err)
+	}
+
+	if condErr.Key != reflect.TypeOf(&UserService{}) || condErr.Condition != "func(*ioc_test.UserRepo) bool" {
+		t.Errorf("test failed: %+v", condErr)
+	}
+
+	// 条件函数返回的错误
+	errNotReady := errors.New("not ready")
+	err = c.BindValue("dsn", ioc.WithCondition("root@/db", func() (bool, error) { return false, errNotReady }))
+	if !errors.As(err, &condErr) || !errors.Is(err, errNotReady) || condErr.Key != "dsn" || condErr.Condition != "func() (bool, error)" {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "condition func() (bool, error) of key=dsn: not ready") {
+		t.Errorf("test failed: %v", err)
+	}
+}
+
+type featureFlag bool
+
+// TestConditionSignatures 测试条件函数支持的签名
+func TestConditionSignatures(t *testing.T) {
+	c := ioc.New()
+
+	// (bool, error) 返回 nil error
+	c.MustSingleton(ioc.WithCondition(func() *UserRepo { return &UserRepo{} }, func() (bool, error) { return true, nil }))
+	c.MustSingleton(ioc.WithCondition(func() *RoleService { return &RoleService{} }, func() (bool, error) { return false, nil }))
+
+	// 只返回 error 的条件函数，nil 表示条件满足
+	c.MustSingleton(ioc.WithCondition(func() InterfaceDemo { return demo1{} }, func() error { return nil }))
+	c.MustBindValue("dsn", ioc.WithCondition("root@/db", func() error { return errors.New("no database") }))
+
+	// 基于 bool 定义的类型
+	c.MustSingleton(ioc.WithCondition(func() *TestObject { return &TestObject{} }, func() featureFlag { return true }))
+
+	if !c.HasBound(new(UserRepo)) || c.HasBound(new(RoleService)) || !c.HasBound(new(InterfaceDemo)) || c.HasBoundValue("dsn") || !c.HasBound(new(TestObject)) {
+		t.Error("test failed: conditions are not evaluated as expected")
+	}
+
+	// 签名不合法时 panic，错误信息包含实际的签名
+	for _, cond := range []any{nil, "true", func() {}, func() int { return 0 }, func() (bool, int) { return true, 0 }, func() (error, bool) { return nil, true }} {
+		func() {
+			defer func() {
+				err, ok := recover().(error)
+				if !ok || !errors.Is(err, ioc.ErrInvalidArgs) || !strings.Contains(err.Error(), "expect func(...) bool") {
+					t.Errorf("test failed: %T %v", cond, err)
+				}
+			}()
+
+			ioc.WithCondition(func() *UserService { return &UserService{} }, cond)
+		}()
+	}
+
+	func() {
+		defer func() {
+			if err, _ := recover().(error); err == nil || !strings.Contains(err.Error(), "func() (bool, int) returns unsupported values") {
+				t.Errorf("test failed: %v", err)
+			}
+		}()
+
+		ioc.WithCondition(func() *UserService { return &UserService{} }, func() (bool, int) { return true, 0 })
+	}()
+}
+
+func TestKeyMatcher(t *testing.T) {
+	// 默认规则下，接口及大小写不同的字符串 Key 不能匹配
+	plain := ioc.New()
+	plain.MustSingleton(func() demo1 { return demo1{} })
+	plain.MustBindValue("dsn", "mysql://localhost")
+	if plain.HasBound(new(InterfaceDemo)) || plain.HasBound("DSN") {
+		t.Error("test failed: built-in rules should not match")
+	}
+
+	c := ioc.New(ioc.WithKeyMatchers(ioc.CaseInsensitiveKeys(), ioc.AssignableKeys()))
+	c.MustSingleton(func() demo1 { return demo1{} })
+	c.MustBindValue("dsn", "mysql://localhost")
+
+	if !c.HasBound(new(InterfaceDemo)) || !c.HasBoundValue("DSN") {
+		t.Fatal("test failed: keys should be matched by key matchers")
+	}
+
+	if dsn := c.MustGet("DSN"); dsn != "mysql://localhost" {
+		t.Errorf("test failed: got %v", dsn)
+	}
+
+	c.MustResolve(func(demo InterfaceDemo) {
+		if demo.String() != "demo1" {
+			t.Errorf("test failed: got %s", demo.String())
+		}
+	})
+
+	// 子容器可以使用父容器的匹配规则查找父容器中的绑定
+	child := ioc.Extend(c)
+	if dsn := child.MustGet("Dsn"); dsn != "mysql://localhost" {
+		t.Errorf("test failed: got %v", dsn)
+	}
+
+	// Unbind 只使用内置规则
+	if err := c.Unbind("DSN"); err == nil && !c.HasBoundValue("dsn") {
+		t.Error("test failed: matched binding should not be unbound")
+	}
+
+	// 自定义匹配规则
+	prefixed := ioc.New(ioc.WithKeyMatchers(ioc.KeyMatcherFunc(func(key, bound any) bool {
+		k, ok := key.(string)
+		return ok && "app."+k == bound
+	})))
+	prefixed.MustBindValue("app.name", "ioc")
+	if name := prefixed.MustGet("name"); name != "ioc" {
+		t.Errorf("test failed: got %v", name)
+	}
+
+	if _, err := prefixed.Get("version"); !errors.Is(err, ioc.ErrObjectNotFound) {
+		t.Errorf("test failed: unmatched key should not be found, got %v", err)
+	}
+}
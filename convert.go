@@ -0,0 +1,101 @@
+package ioc
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// convertBoundValue convert val bound to key in container like convertValue, secret values are redacted from
+// the error
+func (impl *container) convertBoundValue(key any, val any, typ reflect.Type) (reflect.Value, error) {
+	converted, err := convertValue(val, typ)
+	if err != nil {
+		if e := impl.findEntity(key); e != nil && e.secret {
+			return reflect.Value{}, buildValueConversionError(fmt.Sprintf("can not convert secret value %s to %v", redacted, typ))
+		}
+	}
+
+	return converted, err
+}
+
+// convertValue convert val to typ, values assignable to typ are used directly, string values are parsed
+// for bool, int, uint, float and time.Duration (and the types defined on them), floats with a fractional part
+// are rejected for integer types instead of being truncated
+func convertValue(val any, typ reflect.Type) (reflect.Value, error) {
+	if !reflect.ValueOf(val).IsValid() {
+		return reflect.Zero(typ), nil
+	}
+
+	v := reflect.ValueOf(val)
+	if v.Type().AssignableTo(typ) {
+		return v, nil
+	}
+
+	str, ok := val.(string)
+	if !ok {
+		if isFloatKind(v.Kind()) && isIntegerKind(typ.Kind()) && v.Float() != math.Trunc(v.Float()) {
+			return reflect.Value{}, buildInvalidArgsError(fmt.Sprintf("can not convert %v to %v without truncating", val, typ))
+		}
+
+		if v.Type().ConvertibleTo(typ) && v.Kind() != reflect.String && typ.Kind() != reflect.String {
+			return v.Convert(typ), nil
+		}
+
+		return reflect.Value{}, buildValueConversionError(fmt.Sprintf("can not convert %T to %v", val, typ))
+	}
+
+	res := reflect.New(typ).Elem()
+	var err error
+	switch {
+	case typ == durationType:
+		var d time.Duration
+		if d, err = time.ParseDuration(str); err == nil {
+			res.SetInt(int64(d))
+		}
+	case typ.Kind() == reflect.String:
+		res.SetString(str)
+	case typ.Kind() == reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(str); err == nil {
+			res.SetBool(b)
+		}
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(str, 10, typ.Bits()); err == nil {
+			res.SetInt(i)
+		}
+	case typ.Kind() >= reflect.Uint && typ.Kind() <= reflect.Uint64:
+		var u uint64
+		if u, err = strconv.ParseUint(str, 10, typ.Bits()); err == nil {
+			res.SetUint(u)
+		}
+	case typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(str, typ.Bits()); err == nil {
+			res.SetFloat(f)
+		}
+	default:
+		return reflect.Value{}, buildValueConversionError(fmt.Sprintf("can not convert string to %v", typ))
+	}
+
+	if err != nil {
+		return reflect.Value{}, buildValueConversionError(fmt.Sprintf("can not convert %q to %v: %v", str, typ, err))
+	}
+
+	return res, nil
+}
+
+// isFloatKind report whether kind is a float kind
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
+
+// isIntegerKind report whether kind is a signed or unsigned integer kind
+func isIntegerKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Uintptr
+}
@@ -0,0 +1,129 @@
+package ioc
+
+import (
+	"go/token"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// InterfaceCoverage describe how an interface is covered by the bindings of container
+type InterfaceCoverage struct {
+	Interface reflect.Type
+	// Bound whether the interface itself is bound explicitly, only bound interfaces can be resolved
+	Bound bool
+	// Implementations keys of bindings (of current container and its ancestors) whose values implement the
+	// interface, it helps to find the binding an unbound interface should be pointed to
+	Implementations []any
+}
+
+// Coverage report the coverage of the exported interfaces declared in packages pkgPaths (a path ending with
+// "/..." matches the sub packages too). Reflection can not enumerate the types of a package, so the interfaces
+// are collected from binding keys and constructor dependencies of container and its ancestors, use
+// CoverageOf to check the interfaces which are not referenced by any binding yet
+//
+//	for _, cov := range ioc.Coverage(c, "github.com/acme/shop/...") {
+//		if !cov.Bound {
+//			log.Printf("%v is not bound, implemented by %v", cov.Interface, cov.Implementations)
+//		}
+//	}
+func Coverage(c Container, pkgPaths ...string) []InterfaceCoverage {
+	impl, ok := containerOf(c)
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[reflect.Type]bool)
+	interfaces := make([]reflect.Type, 0)
+	collect := func(t reflect.Type) {
+		if t == nil || t.Kind() != reflect.Interface || seen[t] || !isExportedIn(t, pkgPaths) {
+			return
+		}
+
+		seen[t] = true
+		interfaces = append(interfaces, t)
+	}
+
+	for _, e := range impl.coverageEntities() {
+		if t, ok := e.key.(reflect.Type); ok {
+			collect(t)
+		}
+
+		for _, dep := range e.dependencies() {
+			collect(dep)
+		}
+	}
+
+	sort.Slice(interfaces, func(i, j int) bool { return interfaces[i].String() < interfaces[j].String() })
+
+	return CoverageOf(c, interfaces...)
+}
+
+// CoverageOf report the coverage of interfaces, they can be specified like reflect.TypeOf((*Repo)(nil)).Elem(),
+// types which are not interfaces are ignored
+func CoverageOf(c Container, interfaces ...reflect.Type) []InterfaceCoverage {
+	impl, ok := containerOf(c)
+	if !ok {
+		return nil
+	}
+
+	entities := impl.coverageEntities()
+
+	results := make([]InterfaceCoverage, 0, len(interfaces))
+	for _, iface := range interfaces {
+		if iface == nil || iface.Kind() != reflect.Interface {
+			continue
+		}
+
+		cov := InterfaceCoverage{Interface: iface, Bound: impl.findEntity(iface) != nil, Implementations: make([]any, 0)}
+		for _, e := range entities {
+			if e.typ == nil || e.typ.Kind() == reflect.Interface || !e.typ.Implements(iface) {
+				continue
+			}
+
+			cov.Implementations = append(cov.Implementations, e.key)
+		}
+
+		results = append(results, cov)
+	}
+
+	return results
+}
+
+// coverageEntities return the entities of current container and its ancestors, built-in entities are excluded
+func (impl *container) coverageEntities() []*Entity {
+	entities := make([]*Entity, 0)
+	for _, cc := range append([]Container{impl}, impl.Ancestors()...) {
+		p, ok := cc.(*container)
+		if !ok {
+			continue
+		}
+
+		for _, e := range p.sortedEntities() {
+			if !e.builtin {
+				entities = append(entities, e)
+			}
+		}
+	}
+
+	return entities
+}
+
+// isExportedIn return whether t is an exported named type declared in one of pkgPaths
+func isExportedIn(t reflect.Type, pkgPaths []string) bool {
+	if t.Name() == "" || !token.IsExported(t.Name()) {
+		return false
+	}
+
+	for _, p := range pkgPaths {
+		if prefix, ok := strings.CutSuffix(p, "/..."); ok {
+			if t.PkgPath() == prefix || strings.HasPrefix(t.PkgPath(), prefix+"/") {
+				return true
+			}
+		} else if t.PkgPath() == p {
+			return true
+		}
+	}
+
+	return false
+}
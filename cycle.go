@@ -0,0 +1,83 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// resolutionChainKey is the key of the entity carrying the resolution chain in provider
+type resolutionChainKey struct{}
+
+// resolutionChain is the keys of bindings being created by a resolution, the innermost first, it's used to
+// report circular dependencies instead of waiting for a singleton initialized by the resolution itself forever
+// (or recursing infinitely for prototypes). Only dependencies injected as arguments are tracked, a constructor
+// resolving from the container it receives starts a new chain
+type resolutionChain struct {
+	key    any
+	parent *resolutionChain
+}
+
+var resolutionChainType = reflect.TypeOf(&resolutionChain{})
+
+// providedChain return the resolution chain carried by provider, nil if absent
+func providedChain(provider EntitiesProvider) *resolutionChain {
+	if provider == nil {
+		return nil
+	}
+
+	for _, e := range provider() {
+		if chain, ok := e.value.(*resolutionChain); ok && e.key == (resolutionChainKey{}) {
+			return chain
+		}
+	}
+
+	return nil
+}
+
+// circularDependency return an ErrCircularDependency error if key is being created by the resolution of provider
+func circularDependency(provider EntitiesProvider, key any) error {
+	chain := providedChain(provider)
+	for current := chain; current != nil; current = current.parent {
+		if current.key != key {
+			continue
+		}
+
+		path := []string{fmt.Sprint(key)}
+		for c := chain; c != current; c = c.parent {
+			path = append(path, fmt.Sprint(c.key))
+		}
+		path = append(path, fmt.Sprint(key))
+
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+
+		return buildCircularDependencyError(strings.Join(path, " -> "))
+	}
+
+	return nil
+}
+
+// withChain return a provider which carries the resolution chain of provider extended by e, the entities of
+// provider are kept
+func (e *Entity) withChain(provider EntitiesProvider) EntitiesProvider {
+	chain := e.c.newEntity(resolutionChainKey{}, resolutionChainType, nil, false, false)
+	chain.value = &resolutionChain{key: e.key, parent: providedChain(provider)}
+
+	return func() []*Entity {
+		if provider == nil {
+			return []*Entity{chain}
+		}
+
+		entities := provider()
+		results := make([]*Entity, 0, len(entities)+1)
+		for _, entity := range entities {
+			if entity.key != (resolutionChainKey{}) {
+				results = append(results, entity)
+			}
+		}
+
+		return append(results, chain)
+	}
+}
@@ -0,0 +1,116 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// decorator wrap an instance created by an entity, provider is the provider of the resolution
+type decorator func(inner any, provider EntitiesProvider) (any, error)
+
+// Decorate wrap every instance of the binding of T in current container with decorator (such as adding
+// logging or metrics to an interface implementation), the signature is checked by the compiler, so no type
+// assertions are needed in decorator. Decorators are applied in the order they are added, the later one
+// wraps the earlier one. The binding must be created by a constructor and not instantiated yet (for singletons)
+//
+//	ioc.Decorate(c, func(inner UserRepo) UserRepo { return &loggingUserRepo{inner: inner} })
+func Decorate[T any](b Binder, decorate func(inner T) T) error {
+	if decorate == nil {
+		return buildInvalidArgsError("decorator is nil")
+	}
+
+	return decorateBinding[T](b, func(inner any, _ EntitiesProvider) (any, error) {
+		typed, _ := inner.(T)
+		return decorate(typed), nil
+	})
+}
+
+// DecorateWith wrap every instance of the binding of T like Decorate, deps is resolved from the container of
+// the binding for every instance, it's a type bound in container, or a param struct (a struct embedding ioc.In)
+// to inject several dependencies
+//
+//	ioc.DecorateWith(c, func(inner UserRepo, logger *slog.Logger) UserRepo {
+//		return &loggingUserRepo{inner: inner, logger: logger}
+//	})
+func DecorateWith[T, D any](b Binder, decorate func(inner T, deps D) T) error {
+	if decorate == nil {
+		return buildInvalidArgsError("decorator is nil")
+	}
+
+	return decorateBinding[T](b, func(inner any, provider EntitiesProvider) (any, error) {
+		typed, _ := inner.(T)
+
+		var result T
+		callback := func(deps D) { result = decorate(typed, deps) }
+		impl, _ := containerOf(b)
+		if _, err := impl.invoke(reflect.ValueOf(callback), provider); err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	})
+}
+
+// MustDecorate wrap every instance of the binding of T like Decorate, if failed, panic it
+func MustDecorate[T any](b Binder, decorate func(inner T) T) {
+	if err := Decorate(b, decorate); err != nil {
+		panic(err)
+	}
+}
+
+// decorateBinding add decorator to the binding of T in current container
+func decorateBinding[T any](b Binder, decorate decorator) error {
+	impl, ok := containerOf(b)
+	if !ok {
+		return buildInvalidArgsError(fmt.Sprintf("decorator is not supported by %T", b))
+	}
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	lookupKeys, _ := impl.resolveLookupKeys(typ)
+
+	impl.lock.RLock()
+	frozen := impl.frozen
+	impl.lock.RUnlock()
+
+	if frozen {
+		return buildFrozenError(fmt.Sprintf("key=%v can not be decorated after Freeze", typ))
+	}
+
+	e := impl.lookupEntity(lookupKeys, nil)
+	if e == nil {
+		return buildObjectNotFoundError(fmt.Sprintf("key=%v not found in current container, only bindings of current container can be decorated", typ))
+	}
+
+	if e.initializeFunc == nil || e.builtin || e.strategy != nil || e.shadowOf != nil {
+		return buildInvalidArgsError(fmt.Sprintf("key=%v is not created by a constructor, it can not be decorated", typ))
+	}
+
+	defer impl.notifyChanges()
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if !e.prototype && (e.value != nil || e.initializing != nil) {
+		return buildInvalidArgsError(fmt.Sprintf("singleton %v is already instantiated, decorate it before the first resolution", typ))
+	}
+
+	e.decorators = append(e.decorators, decorate)
+	impl.bumpGeneration()
+	return nil
+}
+
+// decorated apply the decorators of entity to value in order
+func (e *Entity) decorated(value any, provider EntitiesProvider) (any, error) {
+	e.lock.RLock()
+	decorators := e.decorators
+	e.lock.RUnlock()
+
+	for _, decorate := range decorators {
+		var err error
+		if value, err = decorate(value, provider); err != nil {
+			return nil, fmt.Errorf("(%v) decorate failed: %w", e.key, err)
+		}
+	}
+
+	return value, nil
+}
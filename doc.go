@@ -24,9 +24,30 @@ Package ioc 实现了依赖注入容器，用于管理Golang对象的创建。
 		t.Errorf("test failed: %s", err)
 		return
 	}
+
+并发模型
+
+容器的所有方法都可以在多个 goroutine 中并发调用：
+
+  - 容器使用读写锁保护绑定表，锁只在读写绑定表时持有，调用构造函数、拦截器、Provider 时不会持有容器锁
+  - 每个单例的构造函数同一时间最多只会执行一次，首个调用方在不持有单例锁的情况下执行构造函数，
+    因此构造函数中可以再次访问容器（如 Get、Bindings、Intercept 等），并发的调用方会等待首个调用方的结果，
+    通过 CallWithContext 等方法传入的 context 被取消时，等待的调用方会立即返回 context 的错误
+  - 单例初始化失败时不会缓存错误，下一次获取时会重新执行构造函数
+  - 原型（Prototype）每次获取时都会创建新的实例，不存在共享状态
+  - 通过构造函数参数注入形成的循环依赖会沿解析链被检测到，返回 ErrCircularDependency 错误，而不是等待正在
+    由自身初始化的单例；构造函数中通过接收的容器重新发起的解析会开始新的解析链，在其中获取正在初始化的自身
+    仍然会一直等待，直到解析的 context 被取消
 */
 package ioc
 
+import (
+	"context"
+	"io"
+	"reflect"
+	"time"
+)
+
 type Container interface {
 	// P alias of Prototype
 	P(initialize any) error
@@ -82,28 +103,152 @@ type Container interface {
 	BindWithKey(key any, initialize any, prototype bool, override bool) error
 	MustBindWithKey(key any, initialize any, prototype bool, override bool)
 
+	// SingletonVersioned 以版本 version 绑定 key 对应的单例，同一个 key 的多个版本可以同时存在，使用 GetVersion 获取指定版本
+	SingletonVersioned(key any, version string, initialize any) error
+	// PrototypeVersioned 以版本 version 绑定 key 对应的原型
+	PrototypeVersioned(key any, version string, initialize any) error
+	// BindStrategy 为 key 绑定一个选择策略，每次获取实例时由 selector 选择具体的实现，selector 返回被选中实现的 key 或者版本号
+	BindStrategy(key any, selector func(r Resolver) any) error
+	// BindBuildInfo 绑定当前程序的构建信息，BuildInfo 以单例绑定，版本、VCS 版本号、提交时间等以 Build*Key 绑定为值
+	BindBuildInfo() error
+	// RegisterFactory 以名称 name 注册一个构造函数（不绑定），LoadWiring 根据配置文件中的名称选择构造函数进行绑定
+	RegisterFactory(name string, factory any) error
+	MustRegisterFactory(name string, factory any)
+	// LoadWiring 从 r 中读取绑定配置（codec 为 nil 时使用 JSONWiringCodec），绑定其中声明的构造函数
+	LoadWiring(r io.Reader, codec WiringCodec) error
+	// PopulateConfig 按照 sources 的顺序（靠前的优先）从多个配置源填充 cfgPtr 指向的结构体（使用 mapstructure tag 命名字段），然后将 cfgPtr 绑定为单例
+	PopulateConfig(cfgPtr any, sources ...ConfigSource) error
+	// BindTemplate 绑定名为 name 的模板，GetTemplated 按照参数使用 factory 创建实例并缓存（每个参数一个实例）
+	BindTemplate(name string, factory func(param string) any) error
+
+	// RegisterAll 对 values 中实现了 Registerable 接口的对象调用 Register 方法，其它对象会被忽略
+	RegisterAll(values ...any) error
+	// Load 按顺序加载所有模块，已经加载过的模块（ModuleName 相同，或者是相等的可比较值）会被跳过
+	Load(modules ...Registerable) error
+	MustLoad(modules ...Registerable)
+	// LoadManifest 校验通过 WithManifest 注册的 Manifest（所有环境必须提供相同的 key 集合），然后加载公共模块以及环境 env 的模块
+	LoadManifest(env string) error
+
 	Resolve(callback any) error
 	MustResolve(callback any)
 	CallWithProvider(callback any, provider EntitiesProvider) ([]any, error)
+	// CallWithContext 与 Call 相同，ctx 结束后参数的解析会在构造函数之间中止，返回包含当前 key 的 ctx.Err()
+	CallWithContext(ctx context.Context, callback any) ([]any, error)
 	Call(callback any) ([]any, error)
+	// CallWithDefaults 与 Call 类似，但容器中未绑定的参数会使用 defaults 中对应类型的默认值代替
+	CallWithDefaults(callback any, defaults map[reflect.Type]any) ([]any, error)
 	// AutoWire 自动对结构体对象进行依赖注入，insPtr 必须是结构体对象的指针
-	// 自动注入字段（公开和私有均支持）需要添加 `autowire` tag，支持以下两种
-	//  - autowire:"@" 根据字段的类型来注入
+	// 自动注入字段（公开和私有均支持）需要添加 `autowire` tag，支持以下三种
+	//  - autowire:"@" 根据字段的类型来注入，接口类型本身没有绑定时，注入通过 Primary 标记的实现
+	//  - autowire:"@name" 注入名为 name 的绑定（BindKeyed 绑定的字段类型，或者字段类型的 name 版本）
 	//  - autowire:"自定义key" 根据自定义的key来注入（查找名为 key 的绑定）
 	AutoWire(insPtr any) error
 	MustAutoWire(insPtr any)
+	// ResolveInto 根据字段类型填充结构体的所有字段（无需 tag），valPtr 必须是结构体对象的指针，带有 `autowire:"-"` tag 的字段会被忽略
+	ResolveInto(valPtr any) error
+	MustResolveInto(valPtr any)
 
 	Get(key any) (any, error)
 	MustGet(key any) any
+	// GetLocal 只从当前容器中获取 key 对应的实例，不会查找父容器
+	GetLocal(key any) (any, error)
+	// GetVersion 获取以版本 version 绑定的 key 对应的实例
+	GetVersion(key any, version string) (any, error)
+	// GetTemplated 获取模板 name 对应参数 param 的实例，首次获取时创建
+	GetTemplated(name string, param string) (any, error)
+	MustGetTemplated(name string, param string) any
+	// ResolveLocal 与 Resolve 类似，但 callback 的参数只从当前容器中查找
+	ResolveLocal(callback any) error
+	// GetAsync 在后台创建 key 对应的实例，返回 Future，使用 Future.Wait 等待实例创建完成
+	GetAsync(key any) *Future
+	// ResolveAll 返回所有类型可以赋值给 key 类型的绑定实例（比如某个接口的所有实现），按照优先级（高优先）及注册顺序排列
+	ResolveAll(key any) ([]any, error)
+	// GetNamedAll 返回 key 类型所有通过 BindKeyed 绑定的实例（包括祖先容器中的，子容器中同名的绑定优先），结果为以名称为 key 的 map[string]T
+	GetNamedAll(key any) (any, error)
+	// GetAllVersions 返回 key 的所有注册（需要使用 WithBindingStack 创建容器）的实例，按照优先级（高优先）及注册顺序（后注册优先）排列，第一个即为 Get 返回的实例
+	GetAllVersions(key any) ([]any, error)
 
 	Provider(initializes ...any) EntitiesProvider
+	// AsProvider 将当前容器（及其父容器）中 keys 对应的绑定导出为 EntitiesProvider，可以传给其它容器的 CallWithProvider，每次解析都委托给当前容器，单例在容器之间共享
+	AsProvider(keys ...any) EntitiesProvider
+	// ExtendFrom 指定当前容器的父容器，如果 parent 为当前容器或者其子孙容器，记录错误日志并保持原父容器不变
 	ExtendFrom(parent Container)
+	// TryExtendFrom 指定当前容器的父容器，如果 parent 为当前容器或者其子孙容器，返回 ErrParentCycle
+	TryExtendFrom(parent Container) error
+	// MustExtendFrom 与 TryExtendFrom 相同，失败时 panic
+	MustExtendFrom(parent Container)
+	// Parent 返回父容器，根容器返回 nil
+	Parent() Container
+	// WithValues 创建一个临时的 Overlay，通过它调用的回调函数的参数优先从 values 中查找，适用于传递请求 ID、语言等单次操作的值
+	WithValues(values map[any]any) *Overlay
+	// AddSource 追加一个 BindingSource，它会在已有的 BindingSource 之后被查询，子孙容器的缓存会随之失效
+	AddSource(source BindingSource) error
+	// SetFallback 设置后备的 Resolver，仅当当前容器及其祖先容器都找不到 key 时才会从 fallback 中查找，可用于迁移期间桥接旧的服务定位器，fallback 为 nil 时取消
+	SetFallback(fallback Resolver) error
+	// NewChild 创建当前容器的子容器，等同于 Extend(c, opts...)，可以与当前容器的其它操作并发执行
+	NewChild(opts ...Option) Container
+	// Scope 返回当前容器的作用域信息，构造函数也可以直接依赖 ScopeInfo 获取构建它的容器的作用域信息
+	Scope() ScopeInfo
+	// Ancestors 返回所有祖先容器，按照从近到远（父容器到根容器）的顺序排列
+	Ancestors() []Container
+	// Lookup 从当前容器及其祖先容器中查找 key 对应的绑定信息，BindingInfo.Container 为该绑定所在的容器
+	Lookup(key any) (BindingInfo, error)
+	// Bindings 返回当前容器（不包含父容器）中所有绑定的信息，顺序规则与 Keys 一致
+	Bindings() []BindingInfo
+	// Generation 返回当前容器绑定的版本号，任何绑定的变更（注册、解绑、覆盖、拦截器、装饰器、BindingSource、父容器）都会使其增加，不包含祖先容器的变更
+	Generation() uint64
+	// OnChange 注册监听函数，当前容器的绑定变更后（在容器的锁之外）调用，并发的多次变更可能只通知一次，返回的函数用于取消监听
+	OnChange(listener func()) (remove func())
+	// Dump 返回当前容器中所有绑定的描述信息，标记为 SecretMarker 的值会被隐藏
+	Dump() string
+	// Validate 在不创建实例的前提下，检查所有的构造函数的依赖是否都可以被解析
+	Validate() error
+	// Warmup 初始化所有标记为 WithEager 且尚未初始化的单例
+	Warmup() error
+	// InitOrder 返回当前容器中已初始化的单例的 key，按照实际初始化的顺序排列（被依赖的优先），可用于排查启动顺序问题
+	InitOrder() []any
+	// Close 释放当前容器中所有已初始化的单例（未指定 disposer 的 io.Closer 会被自动关闭），等同于不限制超时时间的 Shutdown
+	Close() error
+	// Shutdown 按照注册顺序的逆序释放当前容器中所有已初始化的单例，ctx 结束时仍未完成的 disposer 会通过 ShutdownTimeoutError 报告
+	Shutdown(ctx context.Context) error
+	// Graph 返回当前容器的依赖关系图，其中 StartOrder 为 Runner 的启动顺序
+	Graph() Graph
+	// SaveSnapshot 将当前容器中的值绑定（不包含构造函数及 SecretMarker 标记的值）使用 codec 序列化写入 w
+	SaveSnapshot(w io.Writer, codec SnapshotCodec) error
+	// RestoreSnapshot 从 r 中读取 SaveSnapshot 保存的值并绑定到当前容器，快照早于 maxAge 时不会恢复并返回 false
+	RestoreSnapshot(r io.Reader, codec SnapshotCodec, maxAge time.Duration) (bool, error)
+	// SimulateOverride 在不修改容器的前提下，报告使用 initialize 覆盖 key 时会受影响的依赖方及会过期的已初始化单例
+	SimulateOverride(key any, initialize any) (ImpactReport, error)
+	// StartRunners 按照依赖顺序（被依赖的优先）启动当前容器中所有实现了 Runner 接口的单例
+	StartRunners(ctx context.Context) error
+	// StopRunners 按照启动顺序的逆序停止所有通过 StartRunners 启动的 Runner
+	StopRunners(ctx context.Context) error
+	// EnableProfiling 开始记录当前容器的实例获取情况（key、次数及耗时），记录的 Workload 可以使用 Replay 在其它配置的容器上重放
+	EnableProfiling()
+	// DisableProfiling 停止记录并返回记录的 Workload
+	DisableProfiling() Workload
+	// Profile 返回目前为止记录的 Workload
+	Profile() Workload
 
 	Must(err error)
+	// Keys 返回所有的 key，按照优先级（高优先）及注册顺序排列
 	Keys() []any
 	CanOverride(key any) (bool, error)
 	HasBoundValue(key string) bool
 	HasBound(key any) bool
+	// Unbind 从当前容器中移除 key 对应的绑定，key 的匹配规则与 Get 一致
+	Unbind(key any) error
+	// Reserve 保留 key，之后只有使用 Privileged 选项的绑定才能绑定到这些 key 上
+	Reserve(keys ...any) error
+	// Freeze 冻结当前容器，之后所有的绑定变更（绑定、覆盖、解绑）都会返回 ErrFrozen
+	Freeze()
+	// ReplaceContext 替换当前容器内置的 context.Context 绑定，直接重新绑定 context.Context 会返回 ErrBuiltinBinding
+	ReplaceContext(ctx context.Context) error
+	// PushOverride 临时替换 initialize 对应的绑定（即使该绑定不允许覆盖），返回的 restore 函数用于恢复之前的绑定
+	PushOverride(initialize any) (restore func(), err error)
+	MustPushOverride(initialize any) (restore func())
+	// Intercept 为 key 对应的绑定安装拦截器，每次获取实例时都会经过拦截器，返回的 remove 函数用于移除拦截器
+	Intercept(key any, interceptor Interceptor) (remove func(), err error)
 }
 
 type Binder interface {
@@ -151,11 +296,49 @@ type Binder interface {
 	BindWithKey(key any, initialize any, prototype bool, override bool) error
 	MustBindWithKey(key any, initialize any, prototype bool, override bool)
 
+	// SingletonVersioned 以版本 version 绑定 key 对应的单例，同一个 key 的多个版本可以同时存在，使用 GetVersion 获取指定版本
+	SingletonVersioned(key any, version string, initialize any) error
+	// PrototypeVersioned 以版本 version 绑定 key 对应的原型
+	PrototypeVersioned(key any, version string, initialize any) error
+	// BindStrategy 为 key 绑定一个选择策略，每次获取实例时由 selector 选择具体的实现，selector 返回被选中实现的 key 或者版本号
+	BindStrategy(key any, selector func(r Resolver) any) error
+	// BindBuildInfo 绑定当前程序的构建信息，BuildInfo 以单例绑定，版本、VCS 版本号、提交时间等以 Build*Key 绑定为值
+	BindBuildInfo() error
+	// RegisterFactory 以名称 name 注册一个构造函数（不绑定），LoadWiring 根据配置文件中的名称选择构造函数进行绑定
+	RegisterFactory(name string, factory any) error
+	MustRegisterFactory(name string, factory any)
+	// LoadWiring 从 r 中读取绑定配置（codec 为 nil 时使用 JSONWiringCodec），绑定其中声明的构造函数
+	LoadWiring(r io.Reader, codec WiringCodec) error
+	// PopulateConfig 按照 sources 的顺序（靠前的优先）从多个配置源填充 cfgPtr 指向的结构体（使用 mapstructure tag 命名字段），然后将 cfgPtr 绑定为单例
+	PopulateConfig(cfgPtr any, sources ...ConfigSource) error
+	// BindTemplate 绑定名为 name 的模板，GetTemplated 按照参数使用 factory 创建实例并缓存（每个参数一个实例）
+	BindTemplate(name string, factory func(param string) any) error
+
+	// RegisterAll 对 values 中实现了 Registerable 接口的对象调用 Register 方法，其它对象会被忽略
+	RegisterAll(values ...any) error
+	// Load 按顺序加载所有模块，已经加载过的模块（ModuleName 相同，或者是相等的可比较值）会被跳过
+	Load(modules ...Registerable) error
+	MustLoad(modules ...Registerable)
+
 	Must(err error)
+	// Keys 返回所有的 key，按照优先级（高优先）及注册顺序排列
 	Keys() []any
 	CanOverride(key any) (bool, error)
 	HasBoundValue(key string) bool
 	HasBound(key any) bool
+	// Unbind 从当前容器中移除 key 对应的绑定，key 的匹配规则与 Get 一致
+	Unbind(key any) error
+	// Reserve 保留 key，之后只有使用 Privileged 选项的绑定才能绑定到这些 key 上
+	Reserve(keys ...any) error
+	// Freeze 冻结当前容器，之后所有的绑定变更（绑定、覆盖、解绑）都会返回 ErrFrozen
+	Freeze()
+	// ReplaceContext 替换当前容器内置的 context.Context 绑定，直接重新绑定 context.Context 会返回 ErrBuiltinBinding
+	ReplaceContext(ctx context.Context) error
+	// PushOverride 临时替换 initialize 对应的绑定（即使该绑定不允许覆盖），返回的 restore 函数用于恢复之前的绑定
+	PushOverride(initialize any) (restore func(), err error)
+	MustPushOverride(initialize any) (restore func())
+	// Intercept 为 key 对应的绑定安装拦截器，每次获取实例时都会经过拦截器，返回的 remove 函数用于移除拦截器
+	Intercept(key any, interceptor Interceptor) (remove func(), err error)
 }
 
 type EntitiesProvider func() []*Entity
@@ -175,19 +358,56 @@ type Resolver interface {
 	Resolve(callback any) error
 	MustResolve(callback any)
 	CallWithProvider(callback any, provider EntitiesProvider) ([]any, error)
+	// CallWithContext 与 Call 相同，ctx 结束后参数的解析会在构造函数之间中止，返回包含当前 key 的 ctx.Err()
+	CallWithContext(ctx context.Context, callback any) ([]any, error)
 	Provider(initializes ...any) EntitiesProvider
+	// AsProvider 将当前容器（及其父容器）中 keys 对应的绑定导出为 EntitiesProvider，可以传给其它容器的 CallWithProvider，每次解析都委托给当前容器，单例在容器之间共享
+	AsProvider(keys ...any) EntitiesProvider
 	Call(callback any) ([]any, error)
+	// CallWithDefaults 与 Call 类似，但容器中未绑定的参数会使用 defaults 中对应类型的默认值代替
+	CallWithDefaults(callback any, defaults map[reflect.Type]any) ([]any, error)
 	// AutoWire 自动对结构体对象进行依赖注入，object 必须是结构体对象的指针
-	// 自动注入字段（公开和私有均支持）需要添加 `autowire` tag，支持以下两种
-	//  - autowire:"@" 根据字段的类型来注入
+	// 自动注入字段（公开和私有均支持）需要添加 `autowire` tag，支持以下三种
+	//  - autowire:"@" 根据字段的类型来注入，接口类型本身没有绑定时，注入通过 Primary 标记的实现
+	//  - autowire:"@name" 注入名为 name 的绑定（BindKeyed 绑定的字段类型，或者字段类型的 name 版本）
 	//  - autowire:"自定义key" 根据自定义的key来注入（查找名为 key 的绑定）
 	AutoWire(object any) error
 	MustAutoWire(object any)
+	// ResolveInto 根据字段类型填充结构体的所有字段（无需 tag），valPtr 必须是结构体对象的指针，带有 `autowire:"-"` tag 的字段会被忽略
+	ResolveInto(valPtr any) error
+	MustResolveInto(valPtr any)
 
 	Get(key any) (any, error)
 	MustGet(key any) any
+	// GetLocal 只从当前容器中获取 key 对应的实例，不会查找父容器
+	GetLocal(key any) (any, error)
+	// GetVersion 获取以版本 version 绑定的 key 对应的实例
+	GetVersion(key any, version string) (any, error)
+	// GetTemplated 获取模板 name 对应参数 param 的实例，首次获取时创建
+	GetTemplated(name string, param string) (any, error)
+	MustGetTemplated(name string, param string) any
+	// ResolveLocal 与 Resolve 类似，但 callback 的参数只从当前容器中查找
+	ResolveLocal(callback any) error
+	// GetAsync 在后台创建 key 对应的实例，返回 Future，使用 Future.Wait 等待实例创建完成
+	GetAsync(key any) *Future
+	// ResolveAll 返回所有类型可以赋值给 key 类型的绑定实例（比如某个接口的所有实现），按照优先级（高优先）及注册顺序排列
+	ResolveAll(key any) ([]any, error)
+	// GetNamedAll 返回 key 类型所有通过 BindKeyed 绑定的实例（包括祖先容器中的，子容器中同名的绑定优先），结果为以名称为 key 的 map[string]T
+	GetNamedAll(key any) (any, error)
+	// GetAllVersions 返回 key 的所有注册（需要使用 WithBindingStack 创建容器）的实例，按照优先级（高优先）及注册顺序（后注册优先）排列，第一个即为 Get 返回的实例
+	GetAllVersions(key any) ([]any, error)
+	Lookup(key any) (BindingInfo, error)
+	// Bindings 返回当前容器（不包含父容器）中所有绑定的信息，顺序规则与 Keys 一致
+	Bindings() []BindingInfo
+	// Generation 返回当前容器绑定的版本号，任何绑定的变更（注册、解绑、覆盖、拦截器、装饰器、BindingSource、父容器）都会使其增加，不包含祖先容器的变更
+	Generation() uint64
+	// OnChange 注册监听函数，当前容器的绑定变更后（在容器的锁之外）调用，并发的多次变更可能只通知一次，返回的函数用于取消监听
+	OnChange(listener func()) (remove func())
+	// Dump 返回当前容器中所有绑定的描述信息，标记为 SecretMarker 的值会被隐藏
+	Dump() string
 
 	Must(err error)
+	// Keys 返回所有的 key，按照优先级（高优先）及注册顺序排列
 	Keys() []any
 	HasBoundValue(key string) bool
 	HasBound(key any) bool
@@ -0,0 +1,82 @@
+package ioc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// redacted is the placeholder of secret values in dumps and error messages
+const redacted = "******"
+
+// Dump return a human readable description of all bindings in current container, the values of value bindings
+// are included, secret values (marked by SecretMarker) are redacted
+func (impl *container) Dump() string {
+	var sb strings.Builder
+	for _, e := range impl.sortedEntities() {
+		sb.WriteString(e.String())
+		if e.initializeFunc == nil {
+			if e.secret {
+				fmt.Fprintf(&sb, ", value=%s", redacted)
+			} else {
+				fmt.Fprintf(&sb, ", value=%v", e.cachedValue())
+			}
+		}
+
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// summaryKeys is the max count of keys listed by the String of container
+const summaryKeys = 10
+
+// String return a concise summary of the entity, values are never included
+func (e *Entity) String() string {
+	info := e.info()
+
+	scope := "singleton"
+	if info.Prototype {
+		scope = "prototype"
+	}
+
+	return fmt.Sprintf("%v: type=%v, scope=%s, instantiated=%v", info.Key, info.Type, scope, info.Instantiated)
+}
+
+// String return a concise multi-line summary of the container: its scope, the count of bindings by scope and
+// the first keys (built-in bindings excluded), use Dump for the details of all bindings
+func (impl *container) String() string {
+	var singletons, prototypes, builtins int
+	keys := make([]any, 0, summaryKeys)
+	for _, e := range impl.sortedEntities() {
+		if e.builtin {
+			builtins++
+			continue
+		}
+
+		if e.prototype {
+			prototypes++
+		} else {
+			singletons++
+		}
+
+		if len(keys) < summaryKeys {
+			keys = append(keys, e.key)
+		}
+	}
+
+	var sb strings.Builder
+	scope := impl.Scope()
+	fmt.Fprintf(&sb, "container(kind=%s, id=%s, depth=%d): %d singletons, %d prototypes, %d built-in",
+		scope.Kind, scope.ID, scope.Depth, singletons, prototypes, builtins)
+
+	for _, key := range keys {
+		fmt.Fprintf(&sb, "\n  %v", key)
+	}
+
+	if more := singletons + prototypes - len(keys); more > 0 {
+		fmt.Fprintf(&sb, "\n  ... and %d more", more)
+	}
+
+	return sb.String()
+}
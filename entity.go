@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// Entity represent an entity in container
+// Entity represent an entity in container, it's an implementation detail used by providers,
+// use Bindings/Lookup and BindingInfo to inspect the bindings of a container instead
 type Entity struct {
 	lock sync.RWMutex
 
@@ -15,52 +18,444 @@ type Entity struct {
 	value          any          // the value of initializeFunc
 	typ            reflect.Type // the type of value
 	overridable    bool         // identify whether the entity can be overridden
+	index          int          // registration order of the entity
+	priority       int          // priority of the entity, higher priority comes first
+
+	disposer   func(value any) error // disposer is called when the value of entity is released
+	idleTTL    time.Duration         // the cached value of singleton will be released after idle for idleTTL
+	idleStop   chan struct{}         // closed to stop the idle eviction, nil if it's not scheduled
+	idleClock  Clock                 // the clock measures idleness, resolved once by resolveIdleClock
+	idleOnce   sync.Once
+	lastAccess time.Time
+
+	created            int64                            // count of instances created, accessed atomically
+	firstCreatedAt     int64                            // unix nano of the first creation, accessed atomically
+	maxInstances       int64                            // max count of instances can be created, 0 means no limit
+	onInstanceExceeded func(key any, count int64) error // handler invoked when maxInstances exceeded
+
+	resolved    int64  // count of resolutions, accessed atomically
+	instanceSeq uint64 // instantiation sequence of the cached singleton value in container, accessed atomically
+	builtin     bool   // identify whether the entity is bound by container itself
+
+	privileged bool // identify whether the entity can be bound to a reserved key
+	eager      bool // identify whether the singleton is instantiated at bind time
+
+	secret  bool                         // identify whether the value is secret, secret values are redacted
+	decrypt func(value any) (any, error) // decrypt is applied to the value on every resolution
+
+	interceptors   []interceptorEntry // interceptors wrapping every resolution, guarded by lock
+	interceptorSeq int
+	shadowOf       *Entity // the ancestor's entity this child-local entity intercepts, see Intercept
+
+	strategy func(origin *container) (any, error) // chooses the implementation in the resolving container, see BindStrategy
+
+	initializing *initCall // in-flight initialization of singleton, guarded by lock
+
+	fast atomic.Pointer[any] // the cached value served by the lock-free path of Get, see publishFast
+
+	module string            // name of the module which registered the entity, see Load
+	labels map[string]string // metric labels of the entity, see WithLabels
+
+	dependsOn []any // keys resolved before the entity is created without being injected, see WithDependsOn
+	primary   bool  // the primary implementation of interfaces its type implements, see Primary
+
+	decorators []decorator // wrap every instance created by the entity, guarded by lock, see Decorate
+
+	notThreadSafe bool                                  // instances must not be shared between goroutines, see NotThreadSafe
+	syncProxy     func(inner any, lock sync.Locker) any // serializes the access to a shared singleton, see NotThreadSafeProxy
+	syncProxyType reflect.Type                          // the interface syncProxy implements
+	syncLock      sync.Mutex                            // the lock passed to syncProxy
 
 	prototype bool
 	c         *container
 }
 
+// initCall represent an in-flight initialization of singleton
+type initCall struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
 // Value instance value if not initialized
+//
+// Deprecated: Value bypasses interceptors of the binding, use Resolver.Get instead
 func (e *Entity) Value(provider EntitiesProvider) (interface{}, error) {
+	if e.shadowOf != nil {
+		return e.shadowOf.Value(provider)
+	}
+
+	val, err := e.rawValue(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.decrypted(val)
+}
+
+// decrypted apply the decrypt func of entity to val if it has one
+func (e *Entity) decrypted(val any) (any, error) {
+	if e.decrypt == nil {
+		return val, nil
+	}
+
+	decrypted, err := e.decrypt(val)
+	if err != nil {
+		return nil, fmt.Errorf("(%v) decrypt failed: %w", e.key, err)
+	}
+
+	return decrypted, nil
+}
+
+// rawValue return the raw value of entity, instance it if not initialized
+//
+// The singleton is initialized at most once at a time: the first caller runs the constructor without holding
+// the entity lock, so the constructor may re-enter the container freely, concurrent callers wait for its result.
+// A failed initialization is not cached, the next caller tries again
+func (e *Entity) rawValue(provider EntitiesProvider) (interface{}, error) {
 	if e.prototype {
 		return e.createValue(provider)
 	}
 
+	if e.idleTTL > 0 {
+		e.resolveIdleClock()
+	}
+
 	e.lock.Lock()
-	defer e.lock.Unlock()
+	if e.value != nil {
+		e.c.stats.cacheHits.Add(1)
+		if e.idleTTL > 0 {
+			e.touch()
+		}
+
+		e.publishFast()
 
-	if e.value == nil {
-		val, err := e.createValue(provider)
-		if err != nil {
+		val := e.value
+		e.lock.Unlock()
+		return val, nil
+	}
+
+	if call := e.initializing; call != nil {
+		e.lock.Unlock()
+
+		// the singleton is initialized by the resolution itself, waiting for it never ends
+		if err := circularDependency(provider, e.key); err != nil {
 			return nil, err
 		}
 
-		e.value = val
+		ctx := e.c.resolutionContext(provider)
+		select {
+		case <-call.done:
+			return call.value, call.err
+		case <-ctx.Done():
+			return nil, fmt.Errorf("(%v) %w", e.key, ctx.Err())
+		}
+	}
+
+	call := &initCall{done: make(chan struct{})}
+	e.initializing = call
+	e.lock.Unlock()
+
+	e.initialize(call, provider)
+	return call.value, call.err
+}
+
+// initialize run the constructor of singleton for call and publish the result to waiting callers,
+// the waiting callers are released even if the constructor panics
+func (e *Entity) initialize(call *initCall, provider EntitiesProvider) {
+	defer func() {
+		if e.idleTTL > 0 {
+			e.resolveIdleClock()
+		}
+
+		e.lock.Lock()
+		e.initializing = nil
+		if call.err == nil && call.value != nil {
+			e.value = call.value
+			e.publishFast()
+			atomic.StoreUint64(&e.instanceSeq, e.c.instantiations.Add(1))
+			if e.idleTTL > 0 {
+				e.touch()
+			}
+		}
+		e.lock.Unlock()
+
+		if call.err == nil && e.disposerOf(call.value) != nil {
+			e.c.track()
+		}
+
+		close(call.done)
+	}()
+
+	call.err = buildConstructorPanicError(e.key, "initialize panicked")
+	if cache := e.sharedCacheOf(); cache != nil {
+		call.value, call.err = cache.getOrCreate(e.key, e, func() (any, error) { return e.createValue(provider) })
+		return
+	}
+
+	call.value, call.err = e.createValue(provider)
+}
+
+// refresh release the cached value of singleton, so it's re-created on next resolution
+func (e *Entity) refresh() {
+	value := e.release()
+	if value != nil && e.disposer != nil {
+		if err := e.disposer(value); err != nil {
+			e.c.logger().Warn("dispose refreshed singleton failed", "key", e.key, "error", err)
+		}
 	}
+}
+
+// cachedValue return the cached value of singleton without releasing it
+func (e *Entity) cachedValue() any {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	return e.value
+}
+
+// release clear the cached value of singleton and stop its idle eviction, the previous value is returned
+func (e *Entity) release() any {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.idleStop != nil {
+		close(e.idleStop)
+		e.idleStop = nil
+	}
+
+	value := e.value
+	e.value = nil
+	e.fast.Store(nil)
+
+	return value
+}
+
+// publishFast publish the cached value of singleton to the lock-free path of Get if nothing else is involved in
+// its resolution (interceptors, strategies, decryption or idle eviction), caller must hold the lock
+func (e *Entity) publishFast() {
+	if e.value == nil || e.prototype || e.shadowOf != nil || e.strategy != nil || e.decrypt != nil || e.idleTTL > 0 || len(e.interceptors) > 0 {
+		return
+	}
+
+	if e.fast.Load() == nil {
+		value := e.value
+		e.fast.Store(&value)
+	}
+}
+
+// resolveIdleClock resolve the clock measuring idleness from container once, it looks up the container, so
+// caller must not hold the lock of entity
+func (e *Entity) resolveIdleClock() {
+	e.idleOnce.Do(func() { e.idleClock = e.c.clock() })
+}
+
+// touch record the access time of entity and schedule the idle eviction, caller must hold the lock and have
+// resolved the clock by resolveIdleClock
+func (e *Entity) touch() {
+	if e.idleStop == nil {
+		e.idleStop = make(chan struct{})
+		go e.evictIdle(e.idleStop, e.idleClock.After(e.idleTTL))
+	}
+
+	e.lastAccess = e.idleClock.Now()
+}
+
+// evictIdle release the cached value of entity once it has not been accessed for idleTTL, until stop is closed
+func (e *Entity) evictIdle(stop chan struct{}, timeout <-chan time.Time) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timeout:
+		}
+
+		e.lock.Lock()
+		if e.idleStop != stop {
+			// released before the timer fired
+			e.lock.Unlock()
+			return
+		}
+
+		if idle := e.idleClock.Now().Sub(e.lastAccess); idle < e.idleTTL {
+			timeout = e.idleClock.After(e.idleTTL - idle)
+			e.lock.Unlock()
+			continue
+		}
+
+		value := e.value
+		e.value = nil
+		e.idleStop = nil
+		e.lock.Unlock()
+
+		e.c.logger().Debug("idle singleton evicted", "key", e.key)
+
+		if value != nil && e.disposer != nil {
+			if err := e.disposer(value); err != nil {
+				e.c.logger().Warn("dispose idle singleton failed", "key", e.key, "error", err)
+			}
+		}
 
-	return e.value, nil
+		return
+	}
 }
 
 func (e *Entity) createValue(provider EntitiesProvider) (interface{}, error) {
+	if err := e.canceled(provider); err != nil {
+		return nil, err
+	}
+
+	if err := circularDependency(provider, e.key); err != nil {
+		return nil, err
+	}
+
 	initializeValue := reflect.ValueOf(e.initializeFunc)
-	argValues, err := e.c.funcArgs(initializeValue.Type(), provider)
+	if (initializeValue.Type().NumIn() > 0 || len(e.dependsOn) > 0) && !e.builtin {
+		provider = e.withChain(provider)
+	}
+
+	for _, dep := range e.dependsOn {
+		if _, err := e.c.lookupInstance(dep, provider); err != nil {
+			return nil, fmt.Errorf("(%v) resolve dependency %v failed: %w", e.key, dep, wrapArgNotInstancedError(err))
+		}
+	}
+
+	args, err := e.c.funcArgs(initializeValue.Type(), provider)
 	if err != nil {
 		return nil, err
 	}
+	defer args.release()
+
+	// the dependencies may take a while, check again before the constructor of entity itself
+	if err := e.canceled(provider); err != nil {
+		return nil, err
+	}
+
+	if err := e.acquireInstance(provider); err != nil {
+		return nil, err
+	}
+
+	returnValues, err := e.call(args.values)
+	if err != nil {
+		atomic.AddInt64(&e.created, -1)
+		e.c.stats.constructorErrors.Add(1)
+
+		return nil, err
+	}
 
-	returnValues := reflect.ValueOf(e.initializeFunc).Call(argValues)
 	if len(returnValues) <= 0 {
 		return nil, buildInvalidReturnValueCountError("expect greater than 0, got 0")
 	}
 
-	if len(returnValues) > 1 && !returnValues[1].IsNil() && returnValues[1].Interface() != nil {
-		if err, ok := returnValues[1].Interface().(error); ok {
+	if len(returnValues) > 1 {
+		if err := returnedError(returnValues[1]); err != nil {
+			atomic.AddInt64(&e.created, -1)
+			e.c.stats.constructorErrors.Add(1)
+
 			return nil, fmt.Errorf("(%s) %w", e.key, err)
 		}
+	}
+
+	value, err := e.decorated(returnValues[0].Interface(), provider)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.syncProxy != nil && !e.prototype {
+		return e.syncProxy(value, &e.syncLock), nil
+	}
+
+	return value, nil
+}
+
+// canceled return the error of the resolution context (see resolutionContext) if it's done, wrapped with the
+// key of entity, built-in bindings are never canceled so the container itself stays usable
+func (e *Entity) canceled(provider EntitiesProvider) error {
+	if e.builtin {
+		return nil
+	}
+
+	if err := e.c.resolutionContext(provider).Err(); err != nil {
+		return fmt.Errorf("(%v) %w", e.key, err)
+	}
+
+	return nil
+}
+
+// returnedError return the error value returned by func, nil if the value is nil or not an error
+func returnedError(value reflect.Value) error {
+	switch value.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Pointer, reflect.Slice:
+		if value.IsNil() {
+			return nil
+		}
+	}
+
+	err, _ := value.Interface().(error)
+	return err
+}
+
+// call invoke the initializeFunc, if the container limits constructor concurrency, wait for a free slot first
+func (e *Entity) call(argValues []reflect.Value) (results []reflect.Value, err error) {
+	if sem := e.c.constructorSem; sem != nil {
+		sem <- struct{}{}
+		defer func() { <-sem }()
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = buildConstructorPanicError(e.key, recovered)
+		}
+	}()
+
+	return reflect.ValueOf(e.initializeFunc).Call(argValues), nil
+}
+
+// acquireInstance count a new instance of entity, if maxInstances exceeded, the handler decides whether
+// the creation can continue, the default behavior is returning ErrMaxInstancesExceeded
+func (e *Entity) acquireInstance(provider EntitiesProvider) error {
+	count := atomic.AddInt64(&e.created, 1)
+	if atomic.LoadInt64(&e.firstCreatedAt) == 0 {
+		atomic.CompareAndSwapInt64(&e.firstCreatedAt, 0, e.now(provider).UnixNano())
+	}
+
+	if e.maxInstances <= 0 || count <= e.maxInstances {
+		return nil
+	}
+
+	err := buildMaxInstancesExceededError(fmt.Sprintf("key=%v, max=%d, count=%d", e.key, e.maxInstances, count))
+	if e.onInstanceExceeded != nil {
+		err = e.onInstanceExceeded(e.key, count)
+	}
+
+	if err != nil {
+		atomic.AddInt64(&e.created, -1)
+	}
+
+	return err
+}
+
+// now return the time of the Clock of container, the real time is used while the Clock itself is being
+// created by the resolution of provider, since resolving it would wait for itself
+func (e *Entity) now(provider EntitiesProvider) time.Time {
+	if e.key == clockType || circularDependency(provider, clockType) != nil {
+		return time.Now()
+	}
+
+	return e.c.now()
+}
+
+// creationRate return the average count of instances created per second since the first creation
+func (e *Entity) creationRate() float64 {
+	first := atomic.LoadInt64(&e.firstCreatedAt)
+	if first == 0 {
+		return 0
+	}
 
-		// 如果第二个返回值不是 error，则强制转换为 error
-		return nil, fmt.Errorf("(%s) %v", e.key, returnValues[1].Interface())
+	elapsed := e.c.now().Sub(time.Unix(0, first)).Seconds()
+	if elapsed <= 0 {
+		return 0
 	}
 
-	return returnValues[0].Interface(), nil
+	return float64(atomic.LoadInt64(&e.created)) / elapsed
 }
@@ -11,6 +11,22 @@ var (
 	ErrInvalidReturnValueCount = errors.New("invalid return value count")
 	ErrRepeatedBind            = errors.New("repeated bind")
 	ErrInvalidArgs             = errors.New("invalid args")
+	ErrParentCycle             = errors.New("parent cycle")
+	ErrKeyCollision            = errors.New("key collision")
+	ErrMaxInstancesExceeded    = errors.New("max instances exceeded")
+	ErrReservedKey             = errors.New("reserved key")
+	ErrBuiltinBinding          = errors.New("builtin binding")
+	ErrValueConversion         = errors.New("value conversion failed")
+	ErrShutdownTimeout         = errors.New("shutdown timeout")
+	ErrStrictMode              = errors.New("strict mode violation")
+	ErrFrozen                  = errors.New("container frozen")
+	ErrCaptiveDependency       = errors.New("captive dependency")
+	ErrManifestMismatch        = errors.New("manifest mismatch")
+	ErrCircularDependency      = errors.New("circular dependency")
+	ErrScopeClosed             = errors.New("scope closed")
+	ErrConditionFailed         = errors.New("condition failed")
+	ErrConstructorPanic        = errors.New("constructor panicked")
+	ErrNotThreadSafe           = errors.New("not thread safe")
 )
 
 //func isErrorType(t reflect.Type) bool {
@@ -27,6 +43,11 @@ func buildArgNotInstancedError(msg string) error {
 	return fmt.Errorf("%w: %s", ErrArgsNotInstanced, msg)
 }
 
+// wrapArgNotInstancedError wrap err as an arg not instanced error, the original error chain is kept
+func wrapArgNotInstancedError(err error) error {
+	return fmt.Errorf("%w: %w", ErrArgsNotInstanced, err)
+}
+
 // buildInvalidReturnValueCountError is an error object represent return values count not match
 func buildInvalidReturnValueCountError(msg string) error {
 	return fmt.Errorf("%w: %s", ErrInvalidReturnValueCount, msg)
@@ -41,3 +62,79 @@ func buildRepeatedBindError(msg string) error {
 func buildInvalidArgsError(msg string) error {
 	return fmt.Errorf("%w: %s", ErrInvalidArgs, msg)
 }
+
+// buildParentCycleError is an error object represent container hierarchy contains a cycle
+func buildParentCycleError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrParentCycle, msg)
+}
+
+// buildKeyCollisionError is an error object represent a string key collides with a type name
+func buildKeyCollisionError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrKeyCollision, msg)
+}
+
+// buildMaxInstancesExceededError is an error object represent the count of instances exceeds the limit
+func buildMaxInstancesExceededError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrMaxInstancesExceeded, msg)
+}
+
+// buildReservedKeyError is an error object represent bind a reserved key without privilege
+func buildReservedKeyError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrReservedKey, msg)
+}
+
+// buildBuiltinBindingError is an error object represent rebind or unbind a built-in binding, such as
+// Container, Binder, Resolver and context.Context (use ReplaceContext to change the context)
+func buildBuiltinBindingError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrBuiltinBinding, msg)
+}
+
+// buildValueConversionError is an error object represent a value can not be converted to the expected type
+func buildValueConversionError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrValueConversion, msg)
+}
+
+// buildStrictModeError is an error object represent a binding violates the rules of strict mode
+func buildStrictModeError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrStrictMode, msg)
+}
+
+// buildFrozenError is an error object represent changing bindings of a frozen container
+func buildFrozenError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrFrozen, msg)
+}
+
+// buildCaptiveDependencyError is an error object represent a singleton captures a prototype or scoped binding
+func buildCaptiveDependencyError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrCaptiveDependency, msg)
+}
+
+// buildManifestMismatchError is an error object represent environments of a manifest provide different key sets
+func buildManifestMismatchError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrManifestMismatch, msg)
+}
+
+// buildCircularDependencyError is an error object represent a binding depends on itself directly or transitively
+func buildCircularDependencyError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrCircularDependency, msg)
+}
+
+// buildScopeClosedError is an error object represent resolving from a container which is shut down
+func buildScopeClosedError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrScopeClosed, msg)
+}
+
+// buildConstructorPanicError is an error object represent a constructor panicked with recovered, if recovered
+// is an error, its chain is kept
+func buildConstructorPanicError(key any, recovered any) error {
+	if err, ok := recovered.(error); ok {
+		return fmt.Errorf("%w: (%v) %w", ErrConstructorPanic, key, err)
+	}
+
+	return fmt.Errorf("%w: (%v) %v", ErrConstructorPanic, key, recovered)
+}
+
+// buildNotThreadSafeError is an error object represent a binding marked NotThreadSafe is shared between goroutines
+func buildNotThreadSafeError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrNotThreadSafe, msg)
+}
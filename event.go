@@ -0,0 +1,83 @@
+package ioc
+
+import (
+	"reflect"
+	"sync"
+)
+
+// EventBus is a tiny publish/subscribe bus bound in container by default, components resolved from the
+// container can communicate through it without a hard dependency between them. Use Subscribe to listen
+// on typed events
+type EventBus interface {
+	// Publish deliver event to all subscribers whose event type event is assignable to, synchronously and
+	// in subscription order
+	Publish(event any)
+	subscribe(eventType reflect.Type, handler func(event any)) (unsubscribe func())
+}
+
+// Subscribe listen on events of type T (events whose type is assignable to T if T is an interface),
+// the returned function cancels the subscription
+//
+//	ioc.Subscribe(bus, func(evt UserCreated) { ... })
+//	bus.Publish(UserCreated{ID: 1})
+func Subscribe[T any](bus EventBus, handler func(event T)) (unsubscribe func()) {
+	return bus.subscribe(reflect.TypeOf((*T)(nil)).Elem(), func(event any) { handler(event.(T)) })
+}
+
+type subscription struct {
+	id        int
+	eventType reflect.Type
+	handler   func(event any)
+}
+
+type eventBus struct {
+	lock          sync.RWMutex
+	seq           int
+	subscriptions []subscription
+}
+
+// NewEventBus create a new EventBus
+func NewEventBus() EventBus {
+	return &eventBus{}
+}
+
+func (bus *eventBus) Publish(event any) {
+	if event == nil {
+		return
+	}
+
+	bus.lock.RLock()
+	subscriptions := bus.subscriptions
+	bus.lock.RUnlock()
+
+	eventType := reflect.TypeOf(event)
+	for _, sub := range subscriptions {
+		if eventType.AssignableTo(sub.eventType) {
+			sub.handler(event)
+		}
+	}
+}
+
+func (bus *eventBus) subscribe(eventType reflect.Type, handler func(event any)) (unsubscribe func()) {
+	bus.lock.Lock()
+	defer bus.lock.Unlock()
+
+	bus.seq++
+	id := bus.seq
+	bus.subscriptions = append(bus.subscriptions, subscription{id: id, eventType: eventType, handler: handler})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			bus.lock.Lock()
+			defer bus.lock.Unlock()
+
+			for i, sub := range bus.subscriptions {
+				if sub.id == id {
+					bus.subscriptions = append(bus.subscriptions[:i:i], bus.subscriptions[i+1:]...)
+					return
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,6 @@
 module github.com/mylxsw/container/example
 
-go 1.19
+go 1.21
 
 require (
 	github.com/mylxsw/go-ioc v0.0.0
@@ -0,0 +1,62 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// AsProvider create a provider exposing the bindings of keys (visible to current container) to other containers,
+// it can be passed to CallWithProvider of an otherwise separate container. Every resolution through the provider
+// is delegated to current container, so singletons are shared instead of copied, and their dependencies are
+// still resolved from current container only. A key which is not bound panics, like Provider does
+//
+//	shared := core.AsProvider(new(UserRepo), "dsn")
+//	plugin.CallWithProvider(func(repo *UserRepo, svc *PluginService) { ... }, shared)
+func (impl *container) AsProvider(keys ...any) EntitiesProvider {
+	entities := make([]*Entity, len(keys))
+	for i, key := range keys {
+		if !reflect.ValueOf(key).IsValid() {
+			panic(buildInvalidArgsError("key is nil"))
+		}
+
+		found := impl.findEntity(key)
+		if found == nil {
+			panic(buildObjectNotFoundError(fmt.Sprintf("key=%v can not be exported, it's not bound", key)))
+		}
+
+		entity, err := impl.exportedEntity(key, found)
+		if err != nil {
+			panic(err)
+		}
+
+		entities[i] = entity
+	}
+
+	return func() []*Entity {
+		return entities
+	}
+}
+
+// exportedEntity create a prototype entity for key of found, which resolves key from current container on
+// every resolution
+func (impl *container) exportedEntity(key any, found *Entity) (*Entity, error) {
+	fnType := reflect.FuncOf(nil, []reflect.Type{found.typ, errorType}, false)
+	initialize, err := makeFunc(fnType, func([]reflect.Value) []reflect.Value {
+		val, err := impl.Get(key)
+		if err != nil {
+			return []reflect.Value{reflect.Zero(found.typ), reflect.ValueOf(&err).Elem()}
+		}
+
+		value := reflect.ValueOf(val)
+		if !value.IsValid() {
+			value = reflect.Zero(found.typ)
+		}
+
+		return []reflect.Value{value, reflect.Zero(errorType)}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return impl.newEntity(found.key, found.typ, initialize.Interface(), true, false), nil
+}
@@ -0,0 +1,29 @@
+//go:build !tinygo
+
+package ioc
+
+import (
+	"expvar"
+	"log/slog"
+)
+
+// WithExpvar publish the counters of the container (resolutions, cache hits, constructor errors and lookups
+// cached from parents) through expvar under name, so /debug/vars dashboards pick them up. If name is already
+// published, the counters are not published and a warning is logged
+func WithExpvar(name string) Option {
+	return func(impl *container) {
+		if expvar.Get(name) != nil {
+			slog.Default().Warn("expvar name already published, container counters are not published", "component", "ioc", "name", name)
+			return
+		}
+
+		expvar.Publish(name, expvar.Func(func() any {
+			return map[string]int64{
+				"resolutions":          impl.stats.resolutions.Load(),
+				"cache_hits":           impl.stats.cacheHits.Load(),
+				"constructor_errors":   impl.stats.constructorErrors.Load(),
+				"parent_cache_entries": int64(impl.parentCacheSize()),
+			}
+		}))
+	}
+}
@@ -0,0 +1,13 @@
+//go:build tinygo
+
+package ioc
+
+import "log/slog"
+
+// WithExpvar is not supported by TinyGo (expvar depends on net/http), the counters are not published and a
+// warning is logged
+func WithExpvar(name string) Option {
+	return func(impl *container) {
+		slog.Default().Warn("expvar is not supported by TinyGo, container counters are not published", "component", "ioc", "name", name)
+	}
+}
@@ -0,0 +1,118 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Factory gives constructors controlled access to the creation semantics of the binding of T without
+// receiving the whole Container, constructors (and callbacks) request it as an arg
+//
+//	c.MustSingleton(func(conns ioc.Factory[*Conn]) *Pool {
+//		return &Pool{newConn: conns.New}
+//	})
+type Factory[T any] struct {
+	c        *container
+	provider func() []*Entity
+}
+
+// factoryArg is implemented by Factory and Reference of all types, it's used to create their args by reflection
+type factoryArg interface {
+	setContainer(c *container, provider func() []*Entity)
+	target() reflect.Type
+}
+
+var factoryArgType = reflect.TypeOf((*factoryArg)(nil)).Elem()
+
+// New create a fresh instance of T by the constructor of its binding, even if it's a singleton, the
+// instance is not cached by container and interceptors of the binding are not applied
+func (f Factory[T]) New() (T, error) {
+	var res T
+	if f.c == nil {
+		return res, buildInvalidArgsError("factory is not created by container")
+	}
+
+	typ := f.target()
+	e := f.c.findEntity(typ)
+	if e == nil {
+		return res, buildObjectNotFoundError(fmt.Sprintf("key=%v not found", typ))
+	}
+
+	if e.initializeFunc == nil {
+		return res, buildInvalidArgsError(fmt.Sprintf("key=%v is bound to a value, can not create new instance", typ))
+	}
+
+	val, err := e.createValue(f.provider)
+	if err == nil {
+		val, err = e.decrypted(val)
+	}
+
+	if err != nil || val == nil {
+		return res, err
+	}
+
+	return val.(T), nil
+}
+
+// Cached return the instance of T like Get, singletons are created once and cached by container
+func (f Factory[T]) Cached() (T, error) {
+	var res T
+	if f.c == nil {
+		return res, buildInvalidArgsError("factory is not created by container")
+	}
+
+	val, err := f.c.lookupInstance(f.target(), f.provider)
+	if err != nil || val == nil {
+		return res, err
+	}
+
+	return val.(T), nil
+}
+
+func (f *Factory[T]) setContainer(c *container, provider func() []*Entity) {
+	f.c, f.provider = c, detachedProvider(provider)
+}
+
+func (f Factory[T]) target() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// detachedProvider return a provider of the entities of provider without the context of CallWithContext and
+// the resolution chain, so a Factory (or Reference) outliving the resolution creating it neither fails once the
+// context is done nor pins them in memory. nil if no entities are left
+func detachedProvider(provider func() []*Entity) func() []*Entity {
+	if provider == nil {
+		return nil
+	}
+
+	var entities []*Entity
+	for _, e := range provider() {
+		if e.key != (resolutionContextKey{}) && e.key != (resolutionChainKey{}) {
+			entities = append(entities, e)
+		}
+	}
+
+	if len(entities) == 0 {
+		return nil
+	}
+
+	return func() []*Entity { return entities }
+}
+
+// isFactoryType return whether t is a Factory (or Reference) type
+func isFactoryType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PointerTo(t).Implements(factoryArgType)
+}
+
+// factoryOf create a Factory of type t which is bound to current container
+func (impl *container) factoryOf(t reflect.Type, provider func() []*Entity) reflect.Value {
+	f := reflect.New(t)
+	f.Interface().(factoryArg).setContainer(impl, provider)
+
+	return f.Elem()
+}
+
+// factoryTarget return the type Factory type t creates
+func factoryTarget(t reflect.Type) reflect.Type {
+	return reflect.New(t).Interface().(factoryArg).target()
+}
@@ -0,0 +1,94 @@
+package ioc
+
+import "errors"
+
+// SetFallback set the Resolver consulted only when the key is missed by current container and its ancestors,
+// it's useful when bridging to a legacy service locator during migration, a nil fallback removes it. The
+// fallbacks of ancestors are consulted too, the nearest first
+func (impl *container) SetFallback(fallback Resolver) error {
+	if fallback != nil && impl.reachable(fallback) {
+		return buildInvalidArgsError("the fallback is current container or falls back to current container")
+	}
+
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
+	impl.fallback = fallback
+
+	return nil
+}
+
+// reachable return whether current container can be reached from r through hierarchies and fallbacks
+func (impl *container) reachable(r Resolver) bool {
+	visited := make(map[Resolver]bool)
+
+	var visit func(r Resolver) bool
+	visit = func(r Resolver) bool {
+		if r == Resolver(impl) {
+			return true
+		}
+
+		cc, ok := r.(*container)
+		if !ok || visited[r] {
+			return false
+		}
+		visited[r] = true
+
+		if parent := cc.Parent(); parent != nil && visit(parent) {
+			return true
+		}
+
+		fallback := cc.getFallback()
+		return fallback != nil && visit(fallback)
+	}
+
+	return visit(r)
+}
+
+func (impl *container) getFallback() Resolver {
+	impl.lock.RLock()
+	defer impl.lock.RUnlock()
+
+	return impl.fallback
+}
+
+// lookupFallback lookup key from the fallbacks of current container and its ancestors after err (returned by
+// the normal lookup) indicates it's not found, err is returned if no fallback has the key. A key bound in the
+// hierarchy never falls back, even if it is not found because of its dependencies
+func (impl *container) lookupFallback(key any, err error) (any, error) {
+	if !errors.Is(err, ErrObjectNotFound) || impl.findEntity(key) != nil {
+		return nil, err
+	}
+
+	for _, cc := range append([]Container{impl}, impl.Ancestors()...) {
+		p, ok := cc.(*container)
+		if !ok {
+			continue
+		}
+
+		if fallback := p.getFallback(); fallback != nil {
+			if val, fallbackErr := fallback.Get(key); fallbackErr == nil {
+				return val, nil
+			}
+		}
+	}
+
+	return nil, err
+}
+
+// fallbackHas return whether key is bound in the fallbacks of current container or its ancestors, the same
+// fallbacks lookupFallback consults
+func (impl *container) fallbackHas(key any) bool {
+	for _, cc := range append([]Container{impl}, impl.Ancestors()...) {
+		p, ok := cc.(*container)
+		if !ok {
+			continue
+		}
+
+		if fallback := p.getFallback(); fallback != nil && fallback.HasBound(key) {
+			return true
+		}
+	}
+
+	return false
+}
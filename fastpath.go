@@ -0,0 +1,51 @@
+package ioc
+
+import (
+	"reflect"
+	"sync/atomic"
+)
+
+// fastEntry is a singleton of current container served by the lock-free path of Get, it's valid as long as
+// the bindings of container are not changed since generation
+type fastEntry struct {
+	entity     *Entity
+	generation uint64
+}
+
+// fastGet return the cached value of the initialized singleton bound to typ in current container without any
+// lock, false if the value is not available on the fast path (not initialized, bindings changed, resolutions
+// profiled or observed, or something else is involved in the resolution, see publishFast), the caller should resolve it
+// in the usual way
+func (impl *container) fastGet(typ reflect.Type) (any, bool) {
+	entry, ok := impl.fastSingletons.Load(typ)
+	if !ok {
+		return nil, false
+	}
+
+	fe := entry.(fastEntry)
+	if fe.generation != impl.generation.Load() || impl.profiler.Load() != nil || impl.observer != nil || impl.closed.Load() {
+		return nil, false
+	}
+
+	val := fe.entity.fast.Load()
+	if val == nil {
+		return nil, false
+	}
+
+	atomic.AddInt64(&fe.entity.resolved, 1)
+	impl.stats.resolutions.Add(1)
+	impl.stats.cacheHits.Add(1)
+
+	return *val, true
+}
+
+// rememberFast register entity resolved for key in current container to the fast path of Get, generation is
+// the generation of container before the entity was looked up
+func (impl *container) rememberFast(key any, entity *Entity, generation uint64) {
+	typ, ok := key.(reflect.Type)
+	if !ok || entity.fast.Load() == nil {
+		return
+	}
+
+	impl.fastSingletons.Store(typ, fastEntry{entity: entity, generation: generation})
+}
@@ -0,0 +1,18 @@
+//go:build js || wasip1 || appengine || purego || tinygo
+
+package ioc
+
+import (
+	"reflect"
+)
+
+// setField set val to the field of struct without unsafe (on js/wasm, appengine or with the purego build tag),
+// unexported fields can not be injected on these platforms
+func setField(fieldVal reflect.Value, val reflect.Value) error {
+	if !fieldVal.CanSet() {
+		return buildInvalidArgsError("unexported field can not be injected without unsafe (js, wasip1, appengine, purego or tinygo build), export it")
+	}
+
+	fieldVal.Set(val)
+	return nil
+}
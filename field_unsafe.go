@@ -0,0 +1,14 @@
+//go:build !(js || wasip1 || appengine || purego || tinygo)
+
+package ioc
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// setField set val to the field of struct, unexported fields are set through unsafe
+func setField(fieldVal reflect.Value, val reflect.Value) error {
+	reflect.NewAt(fieldVal.Type(), unsafe.Pointer(fieldVal.UnsafeAddr())).Elem().Set(val)
+	return nil
+}
@@ -0,0 +1,152 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Registration describe a binding being registered, it's passed to registration hooks (see WithRegistrationHook)
+type Registration struct {
+	Key         any
+	Type        reflect.Type // the type of value
+	Prototype   bool
+	Overridable bool
+	// Initialize the constructor of binding, nil for values bound by BindValue
+	Initialize any
+	// Value the value bound by BindValue
+	Value any
+}
+
+// RegistrationHook rewrite or veto a registration, returning an error rejects the binding
+type RegistrationHook func(reg Registration) (Registration, error)
+
+// WithRegistrationHook install hooks which can rewrite or veto every registration of the container (built-in
+// bindings excluded), hooks are applied in order. It enables org-wide policies at bind time
+//
+//	ioc.New(ioc.WithRegistrationHook(func(reg ioc.Registration) (ioc.Registration, error) {
+//		if reg.Type != reflect.TypeOf((*http.Client)(nil)) {
+//			return reg, nil
+//		}
+//
+//		return reg.WrapValue(func(val any) (any, error) {
+//			client := val.(*http.Client)
+//			client.Transport = newTracingTransport(client.Transport)
+//			return client, nil
+//		}), nil
+//	}))
+func WithRegistrationHook(hooks ...RegistrationHook) Option {
+	return func(impl *container) {
+		impl.registrationHooks = append(impl.registrationHooks, hooks...)
+	}
+}
+
+// WrapValue return a registration whose value is transformed by wrap after created
+func (reg Registration) WrapValue(wrap func(val any) (any, error)) Registration {
+	if reg.Initialize == nil {
+		wrapped, err := wrap(reg.Value)
+		if err != nil {
+			reg.Initialize = func() (any, error) { return nil, err }
+			reg.Value = nil
+		} else {
+			reg.Value = wrapped
+		}
+
+		return reg
+	}
+
+	initValue := reflect.ValueOf(reg.Initialize)
+	initType := initValue.Type()
+	if initType.Kind() != reflect.Func {
+		return reg
+	}
+
+	outs := []reflect.Type{initType.Out(0), errorType}
+	ins := make([]reflect.Type, initType.NumIn())
+	for i := range ins {
+		ins[i] = initType.In(i)
+	}
+
+	initialize, err := makeFunc(reflect.FuncOf(ins, outs, initType.IsVariadic()), func(args []reflect.Value) []reflect.Value {
+		fail := func(err error) []reflect.Value {
+			return []reflect.Value{reflect.Zero(outs[0]), reflect.ValueOf(&err).Elem()}
+		}
+
+		var results []reflect.Value
+		if initType.IsVariadic() {
+			results = initValue.CallSlice(args)
+		} else {
+			results = initValue.Call(args)
+		}
+
+		if len(results) > 1 && !results[1].IsNil() {
+			if err, ok := results[1].Interface().(error); ok {
+				return fail(err)
+			}
+
+			return fail(fmt.Errorf("%v", results[1].Interface()))
+		}
+
+		wrapped, err := wrap(results[0].Interface())
+		if err != nil {
+			return fail(err)
+		}
+
+		res := reflect.ValueOf(wrapped)
+		if !res.IsValid() {
+			return []reflect.Value{reflect.Zero(outs[0]), reflect.Zero(errorType)}
+		}
+
+		if !res.Type().AssignableTo(outs[0]) {
+			return fail(buildInvalidArgsError(fmt.Sprintf("wrapped value %T is not assignable to %v", wrapped, outs[0])))
+		}
+
+		return []reflect.Value{res, reflect.Zero(errorType)}
+	})
+	if err != nil {
+		reg.Initialize = func() (any, error) { return nil, err }
+		return reg
+	}
+
+	reg.Initialize = initialize.Interface()
+	return reg
+}
+
+// applyRegistrationHooks apply the registration hooks of container to entity
+func (impl *container) applyRegistrationHooks(entity *Entity) error {
+	if len(impl.registrationHooks) == 0 || !impl.bootstrapped || entity.builtin {
+		return nil
+	}
+
+	reg := Registration{
+		Key:         entity.key,
+		Type:        entity.typ,
+		Prototype:   entity.prototype,
+		Overridable: entity.overridable,
+		Initialize:  entity.initializeFunc,
+		Value:       entity.value,
+	}
+
+	for _, hook := range impl.registrationHooks {
+		var err error
+		if reg, err = hook(reg); err != nil {
+			return fmt.Errorf("registration of %v rejected: %w", entity.key, err)
+		}
+	}
+
+	if reg.Initialize == nil && reg.Value == nil {
+		return buildInvalidArgsError(fmt.Sprintf("registration of %v has neither initialize nor value", entity.key))
+	}
+
+	if reg.Initialize != nil {
+		initType := reflect.TypeOf(reg.Initialize)
+		if initType.Kind() != reflect.Func || initType.NumOut() == 0 {
+			return buildInvalidArgsError(fmt.Sprintf("initialize of %v must be a func returning values", entity.key))
+		}
+	}
+
+	entity.key, entity.typ = reg.Key, reg.Type
+	entity.prototype, entity.overridable = reg.Prototype, reg.Overridable
+	entity.initializeFunc, entity.value = reg.Initialize, reg.Value
+
+	return nil
+}
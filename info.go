@@ -0,0 +1,181 @@
+package ioc
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"sort"
+	"sync/atomic"
+)
+
+// BindingInfo describe a binding registered in container, it's a snapshot and safe to be retained
+type BindingInfo struct {
+	Key          any          // binding key
+	Type         reflect.Type // the type of value
+	Prototype    bool         // whether a new instance is created for every resolution
+	Overridable  bool         // whether the binding can be overridden
+	Instantiated bool         // whether the value of singleton has been created (prototype: created at least once)
+	Index        int          // registration order of the binding in its container
+	Priority     int          // priority of the binding, see WithPriority
+	Secret       bool         // whether the value is secret, see SecretMarker
+	Container    Container    // the container which the binding belongs to
+	Module       string       // name of the module which registered the binding (see Load), empty if not registered by a module
+	ThreadSafe   bool         // whether the instances can be shared between goroutines, see NotThreadSafe
+
+	Instances    int64   // count of instances created
+	CreationRate float64 // average count of instances created per second since the first creation
+
+	Labels map[string]string // metric labels of the binding, see WithLabels
+}
+
+// Lookup find the binding of key from current container and its ancestors,
+// the Container field of result is the container which the binding registered in
+func (impl *container) Lookup(key any) (BindingInfo, error) {
+	if !reflect.ValueOf(key).IsValid() {
+		return BindingInfo{}, buildInvalidArgsError("key is nil")
+	}
+
+	lookupKeys, _ := impl.resolveLookupKeys(key)
+	if obj := impl.lookupEntity(lookupKeys, nil); obj != nil {
+		return obj.info(), nil
+	}
+
+	if parent := impl.Parent(); parent != nil {
+		return parent.Lookup(key)
+	}
+
+	return BindingInfo{}, buildObjectNotFoundError(fmt.Sprintf("key=%v not found", key))
+}
+
+// findEntity find the entity of key from current container and its ancestors (including their binding
+// sources) without creating instance, nil is returned if not found, or the ancestor is not a *container
+func (impl *container) findEntity(key any) *Entity {
+	lookupKeys, _ := impl.resolveLookupKeys(key)
+	for cc := impl; cc != nil; {
+		if obj := cc.lookupEntity(lookupKeys, nil); obj != nil {
+			return obj
+		}
+
+		// bindings of sources are registered on demand, like Get does
+		if obj, err := cc.sourceEntity(lookupKeys); err == nil && obj != nil {
+			return obj
+		}
+
+		parent, ok := cc.Parent().(*container)
+		if !ok {
+			return nil
+		}
+
+		cc = parent
+	}
+
+	return nil
+}
+
+// dependencies return the types of arguments of the entity's initializeFunc, parameter structs (see In) are
+// expanded to their required fields and factories (see Factory) are replaced by the types they create
+func (e *Entity) dependencies() []reflect.Type {
+	return e.collectDependencies(true)
+}
+
+// eagerDependencies return the dependencies like dependencies, but factories are skipped since they resolve
+// the types they create lazily (after the entity is created)
+func (e *Entity) eagerDependencies() []reflect.Type {
+	return e.collectDependencies(false)
+}
+
+func (e *Entity) collectDependencies(factories bool) []reflect.Type {
+	if e.initializeFunc == nil {
+		return nil
+	}
+
+	typ := reflect.TypeOf(e.initializeFunc)
+	if typ.Kind() != reflect.Func {
+		return nil
+	}
+
+	deps := make([]reflect.Type, 0, typ.NumIn())
+	for i := 0; i < typ.NumIn(); i++ {
+		if isParamStruct(typ.In(i)) {
+			deps = append(deps, paramDependencies(typ.In(i))...)
+			continue
+		}
+
+		if isFactoryType(typ.In(i)) {
+			if factories {
+				deps = append(deps, factoryTarget(typ.In(i)))
+			}
+			continue
+		}
+
+		deps = append(deps, typ.In(i))
+	}
+
+	return deps
+}
+
+// Bindings return the info of all bindings registered in current container (not including parents),
+// ordered by priority (higher first) and registration order
+func (impl *container) Bindings() []BindingInfo {
+	entities := impl.sortedEntities()
+	infos := make([]BindingInfo, 0, len(entities))
+	for _, e := range entities {
+		infos = append(infos, e.info())
+	}
+
+	return infos
+}
+
+// InitOrder return the keys of singletons instantiated in current container (not including parents) in the
+// order they were instantiated, a singleton comes after the singletons it depends on. It's useful to check the
+// startup order after Warmup or StartRunners, value bindings and singletons released (such as by Shutdown or
+// idle eviction) are not included
+func (impl *container) InitOrder() []any {
+	entities := make([]*Entity, 0)
+	for _, e := range impl.sortedEntities() {
+		if atomic.LoadUint64(&e.instanceSeq) > 0 && e.cachedValue() != nil {
+			entities = append(entities, e)
+		}
+	}
+
+	sort.SliceStable(entities, func(i, j int) bool {
+		return atomic.LoadUint64(&entities[i].instanceSeq) < atomic.LoadUint64(&entities[j].instanceSeq)
+	})
+
+	keys := make([]any, 0, len(entities))
+	for _, e := range entities {
+		keys = append(keys, e.key)
+	}
+
+	return keys
+}
+
+// info return the binding info of entity
+func (e *Entity) info() BindingInfo {
+	e.lock.RLock()
+	instantiated := e.value != nil
+	e.lock.RUnlock()
+
+	if e.prototype {
+		instantiated = atomic.LoadInt64(&e.created) > 0
+	}
+
+	return BindingInfo{
+		Key:          e.key,
+		Type:         e.typ,
+		Prototype:    e.prototype,
+		Overridable:  e.overridable,
+		Instantiated: instantiated,
+		Index:        e.index,
+		Priority:     e.priority,
+		Secret:       e.secret,
+		Container:    e.c,
+		Module:       e.module,
+		ThreadSafe:   !e.notThreadSafe,
+
+		Instances:    atomic.LoadInt64(&e.created),
+		CreationRate: e.creationRate(),
+
+		Labels: maps.Clone(e.labels),
+	}
+}
@@ -0,0 +1,40 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// PostConstructor is implemented by objects which need to be initialized after all dependencies are injected
+type PostConstructor interface {
+	PostConstruct() error
+}
+
+// NewInstance create a new *T without a prior binding: allocate T, inject its fields by AutoWire,
+// and then invoke PostConstruct if *T implements PostConstructor. T must be a struct type
+func NewInstance[T any](r Resolver) (*T, error) {
+	ins := new(T)
+	if kind := reflect.TypeOf(ins).Elem().Kind(); kind != reflect.Struct {
+		return nil, buildInvalidArgsError(fmt.Sprintf("expect a struct type, but got %v", kind))
+	}
+
+	if err := r.AutoWire(ins); err != nil {
+		return nil, err
+	}
+
+	if pc, ok := any(ins).(PostConstructor); ok {
+		if err := pc.PostConstruct(); err != nil {
+			return nil, fmt.Errorf("(%T) post construct failed: %w", ins, err)
+		}
+	}
+
+	return ins, nil
+}
+
+// MustNewInstance create a new *T like NewInstance, if failed, panic it
+func MustNewInstance[T any](r Resolver) *T {
+	ins, err := NewInstance[T](r)
+	r.Must(err)
+
+	return ins
+}
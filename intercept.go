@@ -0,0 +1,119 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+	"sync/atomic"
+)
+
+// Interceptor wraps every resolution of a binding, next resolves the instance (from cache for singletons),
+// an interceptor can delay, replace the result or fail the resolution
+type Interceptor func(key any, next func() (any, error)) (any, error)
+
+type interceptorEntry struct {
+	id          int
+	interceptor Interceptor
+}
+
+// Intercept install an interceptor for the binding of key, interceptors installed later wrap the earlier ones.
+// If the binding is registered in an ancestor container, the interceptor is installed on a child-local shadow of
+// it, so only resolutions through current container (and its descendants) are intercepted, the ancestor is
+// unaffected. The returned function removes the interceptor
+func (impl *container) Intercept(key any, interceptor Interceptor) (remove func(), err error) {
+	if !reflect.ValueOf(key).IsValid() {
+		return nil, buildInvalidArgsError("key is nil")
+	}
+
+	if interceptor == nil {
+		return nil, buildInvalidArgsError("interceptor is nil")
+	}
+
+	e := impl.findEntity(key)
+	if e == nil {
+		return nil, buildObjectNotFoundError(fmt.Sprintf("key=%v not found", key))
+	}
+
+	if e.c != impl {
+		e = impl.shadowEntity(e)
+	}
+
+	e.lock.Lock()
+	e.interceptorSeq++
+	id := e.interceptorSeq
+	e.interceptors = append(e.interceptors, interceptorEntry{id: id, interceptor: interceptor})
+	e.fast.Store(nil)
+	e.lock.Unlock()
+
+	impl.bumpGeneration()
+	impl.notifyChanges()
+
+	return func() {
+		defer impl.notifyChanges()
+
+		e.lock.Lock()
+		defer e.lock.Unlock()
+
+		for i, entry := range e.interceptors {
+			if entry.id == id {
+				e.interceptors = append(e.interceptors[:i:i], e.interceptors[i+1:]...)
+				impl.bumpGeneration()
+				return
+			}
+		}
+	}, nil
+}
+
+// shadowEntity return the child-local shadow of the ancestor's entity target, it's created on first use.
+// The shadow is built-in and overridable, so binding the key in current container replaces it
+func (impl *container) shadowEntity(target *Entity) *Entity {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
+	if e, ok := impl.entities[target.key]; ok && e.shadowOf == target {
+		return e
+	}
+
+	shadow := impl.newEntity(target.key, target.typ, nil, target.prototype, true)
+	shadow.shadowOf, shadow.builtin, shadow.secret = target, true, target.secret
+	impl.storeEntity(shadow)
+
+	return shadow
+}
+
+// resolve return the value of entity through all interceptors, resolved in the container of entity
+func (e *Entity) resolve(provider EntitiesProvider) (any, error) {
+	return e.resolveFor(e.c, provider)
+}
+
+// resolveFor return the value of entity for origin (the container the lookup starts from) through all
+// interceptors, a shadow entity applies its own interceptors on the resolution of the ancestor's entity,
+// a strategy (see BindStrategy) chooses the implementation in origin
+func (e *Entity) resolveFor(origin *container, provider EntitiesProvider) (any, error) {
+	e.lock.RLock()
+	interceptors := e.interceptors
+	e.lock.RUnlock()
+
+	var next func() (any, error)
+	if target := e.shadowOf; target != nil {
+		next = func() (any, error) { return target.resolveFor(origin, provider) }
+	} else {
+		atomic.AddInt64(&e.resolved, 1)
+		e.c.stats.resolutions.Add(1)
+
+		next = func() (any, error) { return e.Value(provider) }
+		if e.strategy != nil {
+			next = func() (any, error) { return e.strategy(origin) }
+		}
+	}
+
+	for _, entry := range interceptors {
+		interceptor, inner := entry.interceptor, next
+		next = func() (any, error) { return interceptor(e.key, inner) }
+	}
+
+	if e.shadowOf != nil {
+		return next()
+	}
+
+	return e.profiled(next)
+}
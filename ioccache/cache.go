@@ -0,0 +1,120 @@
+// Package ioccache binds a cache client (such as a Redis client) configured from values into ioc containers,
+// the client is health-checked when it's instantiated (at Warmup for containers created with
+// ioc.WithDeferredEager, such as the one of ioc.App) and closed when the container shuts down
+//
+//	c := ioc.New(ioc.WithDeferredEager())
+//	c.MustBindValue(ioccache.AddrKey, "127.0.0.1:6379")
+//	c.MustLoad(ioccache.Module[*redis.Client]{
+//		Dial: func(cfg ioccache.Config) (*redis.Client, error) {
+//			return redis.NewClient(&redis.Options{Addr: cfg.Addr, Password: cfg.Password, DB: cfg.DB}), nil
+//		},
+//		Ping: func(ctx context.Context, client *redis.Client) error { return client.Ping(ctx).Err() },
+//	})
+//
+//	err := c.Warmup() // dial and ping the client
+package ioccache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mylxsw/go-ioc"
+)
+
+// Value keys of the cache config
+const (
+	AddrKey     = "cache.addr"
+	PasswordKey = "cache.password"
+	DBKey       = "cache.db"
+)
+
+// defaultPingTimeout is the timeout of health check if Module.PingTimeout is not set
+const defaultPingTimeout = 5 * time.Second
+
+// Config is the connection config of cache client, it's bound in container and read from values
+type Config struct {
+	Addr     string
+	Password string
+	DB       int
+}
+
+type configParams struct {
+	ioc.In
+
+	Addr     string `name:"cache.addr"`
+	Password string `name:"cache.password" optional:"true"`
+	DB       int    `name:"cache.db" optional:"true"`
+}
+
+// Module bind Config from values and the client C created by Dial as an eager singleton, the client is
+// checked by Ping once it's created and closed by Close when it's released
+type Module[C any] struct {
+	// Dial create the client from config
+	Dial func(cfg Config) (C, error)
+	// Ping check the health of client, the client is not checked if it's nil
+	Ping func(ctx context.Context, client C) error
+	// Close close the client, if it's nil, clients implementing io.Closer are closed
+	Close func(client C) error
+	// PingTimeout the timeout of Ping, 5s if it's not set
+	PingTimeout time.Duration
+}
+
+// Register implements ioc.Registerable
+func (m Module[C]) Register(binder ioc.Binder) error {
+	if m.Dial == nil {
+		return fmt.Errorf("%w: Dial of cache module is nil", ioc.ErrInvalidArgs)
+	}
+
+	if err := binder.Singleton(func(p configParams) Config {
+		return Config{Addr: p.Addr, Password: p.Password, DB: p.DB}
+	}); err != nil {
+		return err
+	}
+
+	return binder.Singleton(ioc.WithOptions(m.dial, ioc.WithEager(), ioc.WithDisposer(func(value any) error {
+		return m.close(value.(C))
+	})))
+}
+
+// dial create the client and check its health, the client is closed if the check failed
+func (m Module[C]) dial(ctx context.Context, cfg Config) (C, error) {
+	client, err := m.Dial(cfg)
+	if err != nil {
+		return client, fmt.Errorf("dial cache %s failed: %w", cfg.Addr, err)
+	}
+
+	if m.Ping == nil {
+		return client, nil
+	}
+
+	timeout := m.PingTimeout
+	if timeout <= 0 {
+		timeout = defaultPingTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := m.Ping(ctx, client); err != nil {
+		var zero C
+		return zero, errors.Join(fmt.Errorf("ping cache %s failed: %w", cfg.Addr, err), m.close(client))
+	}
+
+	return client, nil
+}
+
+// close close the client by Close, or by io.Closer if Close is nil
+func (m Module[C]) close(client C) error {
+	if m.Close != nil {
+		return m.Close(client)
+	}
+
+	if closer, ok := any(client).(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
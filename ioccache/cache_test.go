@@ -0,0 +1,94 @@
+package ioccache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mylxsw/go-ioc"
+	"github.com/mylxsw/go-ioc/ioccache"
+)
+
+// fakeClient is a cache client records its lifecycle
+type fakeClient struct {
+	cfg    ioccache.Config
+	pinged bool
+	closed bool
+}
+
+func (c *fakeClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+var errUnreachable = errors.New("unreachable")
+
+func module(clients *[]*fakeClient, pingErr error) ioccache.Module[*fakeClient] {
+	return ioccache.Module[*fakeClient]{
+		Dial: func(cfg ioccache.Config) (*fakeClient, error) {
+			client := &fakeClient{cfg: cfg}
+			*clients = append(*clients, client)
+			return client, nil
+		},
+		Ping: func(ctx context.Context, client *fakeClient) error {
+			if _, ok := ctx.Deadline(); !ok {
+				return errors.New("ping without timeout")
+			}
+
+			client.pinged = true
+			return pingErr
+		},
+	}
+}
+
+func TestModule(t *testing.T) {
+	var clients []*fakeClient
+
+	c := ioc.New(ioc.WithDeferredEager())
+	c.MustBindValue(ioccache.AddrKey, "127.0.0.1:6379")
+	c.MustBindValue(ioccache.DBKey, "2")
+	c.MustLoad(module(&clients, nil))
+
+	if len(clients) != 0 {
+		t.Fatal("test failed: client should be created at warmup")
+	}
+
+	if err := c.Warmup(); err != nil {
+		t.Fatal(err)
+	}
+
+	client := c.MustGet(new(fakeClient)).(*fakeClient)
+	if len(clients) != 1 || clients[0] != client || !client.pinged {
+		t.Errorf("test failed: %+v", clients)
+	}
+
+	if client.cfg != (ioccache.Config{Addr: "127.0.0.1:6379", DB: 2}) {
+		t.Errorf("test failed: %+v", client.cfg)
+	}
+
+	if err := c.Close(); err != nil || !client.closed {
+		t.Errorf("test failed: client should be closed on shutdown: %v", err)
+	}
+}
+
+func TestModulePingFailed(t *testing.T) {
+	var clients []*fakeClient
+
+	c := ioc.New(ioc.WithDeferredEager())
+	c.MustBindValue(ioccache.AddrKey, "127.0.0.1:6379")
+	c.MustLoad(module(&clients, errUnreachable))
+
+	if err := c.Warmup(); !errors.Is(err, errUnreachable) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if len(clients) != 1 || !clients[0].closed {
+		t.Error("test failed: unhealthy client should be closed")
+	}
+}
+
+func TestModuleInvalid(t *testing.T) {
+	if err := ioc.New().Load(ioccache.Module[*fakeClient]{}); !errors.Is(err, ioc.ErrInvalidArgs) {
+		t.Errorf("test failed: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+// Package iochttp serves net/http requests from request scopes of ioc containers, failures of the dependency
+// injection (bindings not found, constructor errors or panics, timeouts) are mapped to structured HTTP
+// responses instead of surfacing as raw panics
+//
+//	c.MustSingleton(NewUserService)
+//
+//	mux := http.NewServeMux()
+//	mux.Handle("/users", iochttp.Handler(func(w http.ResponseWriter, r *http.Request, users *UserService) error {
+//		return json.NewEncoder(w).Encode(users.List(r.Context()))
+//	}))
+//
+//	http.ListenAndServe(":8080", iochttp.Middleware(c)(mux))
+package iochttp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/mylxsw/go-ioc"
+)
+
+// RequestIDHeader is the header the ID of request scope is taken from, a new ID is generated by the
+// ioc.IDGenerator of container if it's absent
+const RequestIDHeader = "X-Request-ID"
+
+// ErrorBody is the structured body of the response of a failed resolution
+type ErrorBody struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ErrorMapper map the error of a resolution to the status code and body of the response, handled is false if
+// err is not a failure of dependency injection, then the error is answered by http.StatusInternalServerError
+// (for errors returned by handlers) or re-panicked (for panics)
+type ErrorMapper func(err error) (status int, body ErrorBody, handled bool)
+
+// DefaultErrorMapper map the sentinel errors of ioc to responses:
+//   - timeouts (context.DeadlineExceeded, ioc.ErrShutdownTimeout) and closed scopes are 503
+//   - other failures of dependency injection (constructor panics, circular dependencies, not found, ...) are 500,
+//     the most specific cause of a wrapped error decides the code of body
+func DefaultErrorMapper(err error) (int, ErrorBody, bool) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, ioc.ErrShutdownTimeout):
+		return http.StatusServiceUnavailable, ErrorBody{Code: "dependency_timeout", Message: err.Error()}, true
+	case errors.Is(err, ioc.ErrScopeClosed):
+		return http.StatusServiceUnavailable, ErrorBody{Code: "unavailable", Message: err.Error()}, true
+	case errors.Is(err, ioc.ErrConstructorPanic):
+		return http.StatusInternalServerError, ErrorBody{Code: "constructor_panicked", Message: err.Error()}, true
+	case errors.Is(err, ioc.ErrCircularDependency), errors.Is(err, ioc.ErrMaxInstancesExceeded), errors.Is(err, ioc.ErrInvalidArgs):
+		return http.StatusInternalServerError, ErrorBody{Code: "dependency_error", Message: err.Error()}, true
+	case errors.Is(err, ioc.ErrObjectNotFound), errors.Is(err, ioc.ErrArgsNotInstanced):
+		return http.StatusInternalServerError, ErrorBody{Code: "dependency_not_found", Message: err.Error()}, true
+	}
+
+	return 0, ErrorBody{}, false
+}
+
+// Options configure the middleware
+type Options struct {
+	// ErrorMapper map errors to responses, DefaultErrorMapper is used if nil
+	ErrorMapper ErrorMapper
+	// ScopeOptions are passed to the request scopes besides ioc.WithRequestScope
+	ScopeOptions []ioc.Option
+}
+
+type scopeKey struct{}
+
+type requestScope struct {
+	c      ioc.Container
+	id     string
+	mapper ErrorMapper
+}
+
+// Middleware serve every request from a request scope of c (see ioc.WithRequestScope), the context of the
+// scope is the context of request, *http.Request and http.ResponseWriter are bound in the scope, and the scope
+// is shut down when the request finishes. Panics whose value is a failure of dependency injection (such as the
+// ones of Must* methods) are answered by the error mapper
+func Middleware(c ioc.Container, opts ...Options) func(next http.Handler) http.Handler {
+	var options Options
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	if options.ErrorMapper == nil {
+		options.ErrorMapper = DefaultErrorMapper
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if id == "" {
+				if generator, err := c.Get(new(ioc.IDGenerator)); err == nil {
+					id = generator.(ioc.IDGenerator).NewID()
+				}
+			}
+
+			scope := c.NewChild(append([]ioc.Option{ioc.WithRequestScope(id)}, options.ScopeOptions...)...)
+			rs := &requestScope{c: scope, id: id, mapper: options.ErrorMapper}
+			defer func() { _ = scope.Close() }()
+			defer rs.recover(w)
+
+			r = r.WithContext(context.WithValue(r.Context(), scopeKey{}, rs))
+			scope.Must(scope.ReplaceContext(r.Context()))
+			scope.MustSingleton(func() *http.Request { return r })
+			scope.MustSingleton(func() http.ResponseWriter { return w })
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Scope return the request scope serving r, nil if r is not served by Middleware
+func Scope(r *http.Request) ioc.Container {
+	if rs, ok := r.Context().Value(scopeKey{}).(*requestScope); ok {
+		return rs.c
+	}
+
+	return nil
+}
+
+// Handler create a handler calling callback with its args resolved from the request scope of Middleware
+// (*http.Request and http.ResponseWriter included), callback may return an error, which is answered by the
+// error mapper
+//
+//	iochttp.Handler(func(w http.ResponseWriter, r *http.Request, users *UserService) error { ... })
+func Handler(callback any) http.Handler {
+	callbackValue := reflect.ValueOf(callback)
+	if callbackValue.Kind() != reflect.Func {
+		panic("iochttp: callback must be a func")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs, ok := r.Context().Value(scopeKey{}).(*requestScope)
+		if !ok {
+			http.Error(w, "iochttp: request is not served by Middleware", http.StatusInternalServerError)
+			return
+		}
+
+		results, err := rs.c.Call(callback)
+		if err == nil && len(results) > 0 {
+			err, _ = results[len(results)-1].(error)
+		}
+
+		if err != nil {
+			rs.fail(w, err)
+		}
+	})
+}
+
+// recover answer the panic of a failed resolution by the error mapper, other panics are re-panicked
+func (rs *requestScope) recover(w http.ResponseWriter) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	err, ok := recovered.(error)
+	if !ok {
+		panic(recovered)
+	}
+
+	status, body, handled := rs.mapper(err)
+	if !handled {
+		panic(recovered)
+	}
+
+	rs.write(w, status, body)
+}
+
+// fail answer err returned by a handler
+func (rs *requestScope) fail(w http.ResponseWriter, err error) {
+	status, body, handled := rs.mapper(err)
+	if !handled {
+		status, body = http.StatusInternalServerError, ErrorBody{Code: "internal_error", Message: err.Error()}
+	}
+
+	rs.write(w, status, body)
+}
+
+func (rs *requestScope) write(w http.ResponseWriter, status int, body ErrorBody) {
+	body.RequestID = rs.id
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,126 @@
+package iochttp_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mylxsw/go-ioc"
+	"github.com/mylxsw/go-ioc/iochttp"
+)
+
+type greeter struct {
+	name string
+}
+
+type missing struct{}
+
+func serve(t *testing.T, h http.Handler, header string) (*httptest.ResponseRecorder, iochttp.ErrorBody) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		req.Header.Set(iochttp.RequestIDHeader, header)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var body iochttp.ErrorBody
+	if rec.Code != http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("test failed: body is not structured: %v", err)
+		}
+	}
+
+	return rec, body
+}
+
+func TestHandler(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *greeter { return &greeter{name: "ioc"} })
+
+	var scopeID string
+	h := iochttp.Middleware(c)(iochttp.Handler(func(w http.ResponseWriter, r *http.Request, g *greeter, scope ioc.ScopeInfo) {
+		scopeID = scope.ID
+		if iochttp.Scope(r) == nil {
+			t.Error("test failed: scope should be stored in request")
+		}
+
+		_, _ = w.Write([]byte("hello " + g.name))
+	}))
+
+	rec, _ := serve(t, h, "req-1")
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello ioc" {
+		t.Errorf("test failed: %d %s", rec.Code, rec.Body.String())
+	}
+
+	if scopeID != "req-1" {
+		t.Errorf("test failed: scope id should be the request id, got %s", scopeID)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() (*greeter, error) { panic("broken") })
+
+	h := iochttp.Middleware(c)(iochttp.Handler(func(g *greeter) {}))
+	rec, body := serve(t, h, "req-2")
+	if rec.Code != http.StatusInternalServerError || body.Code != "constructor_panicked" || body.RequestID != "req-2" {
+		t.Errorf("test failed: %d %+v", rec.Code, body)
+	}
+
+	// Must* 方法的 panic 被转换为响应
+	h = iochttp.Middleware(c)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		iochttp.Scope(r).MustGet(new(missing))
+	}))
+	rec, body = serve(t, h, "")
+	if rec.Code != http.StatusInternalServerError || body.Code != "dependency_not_found" || body.RequestID == "" {
+		t.Errorf("test failed: %d %+v", rec.Code, body)
+	}
+
+	// handler 返回的普通错误
+	h = iochttp.Middleware(c)(iochttp.Handler(func() error { return errors.New("oops") }))
+	rec, body = serve(t, h, "")
+	if rec.Code != http.StatusInternalServerError || body.Code != "internal_error" {
+		t.Errorf("test failed: %d %+v", rec.Code, body)
+	}
+
+	// 超时映射为 503
+	h = iochttp.Middleware(c)(iochttp.Handler(func() error { return ioc.ErrShutdownTimeout }))
+	rec, body = serve(t, h, "")
+	if rec.Code != http.StatusServiceUnavailable || body.Code != "dependency_timeout" {
+		t.Errorf("test failed: %d %+v", rec.Code, body)
+	}
+
+	// 与依赖注入无关的 panic 不会被吞掉
+	h = iochttp.Middleware(c)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }))
+	func() {
+		defer func() {
+			if recover() != "boom" {
+				t.Error("test failed: unrelated panic should be re-panicked")
+			}
+		}()
+		serve(t, h, "")
+	}()
+}
+
+func TestCustomErrorMapper(t *testing.T) {
+	c := ioc.New()
+	h := iochttp.Middleware(c, iochttp.Options{
+		ErrorMapper: func(err error) (int, iochttp.ErrorBody, bool) {
+			if errors.Is(err, ioc.ErrObjectNotFound) {
+				return http.StatusNotImplemented, iochttp.ErrorBody{Code: "not_wired"}, true
+			}
+
+			return iochttp.DefaultErrorMapper(err)
+		},
+	})(iochttp.Handler(func(m *missing) {}))
+
+	rec, body := serve(t, h, "")
+	if rec.Code != http.StatusNotImplemented || body.Code != "not_wired" {
+		t.Errorf("test failed: %d %+v", rec.Code, body)
+	}
+}
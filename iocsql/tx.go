@@ -0,0 +1,146 @@
+// Package iocsql provides request-scoped sql transactions for applications wired with ioc containers
+//
+// Repositories depend on DBTX instead of *sql.DB and are registered by Module, they work on the *sql.DB by
+// default, and on the *sql.Tx when they are resolved from a transaction scope: the scope rebinds them, so
+// their DBTX is resolved from the scope instead of the container they are registered in
+//
+//	c.MustSingleton(func() (*sql.DB, error) { return sql.Open("mysql", dsn) })
+//	c.MustLoad(iocsql.Module{Repositories: []any{NewOrderRepo, NewStockRepo}})
+//
+//	err := iocsql.WithTx(ctx, c, nil, func(scope ioc.Container) error {
+//		return scope.Resolve(func(orders *OrderRepo, stocks *StockRepo) error { ... })
+//	})
+package iocsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mylxsw/go-ioc"
+)
+
+// DBTX is implemented by both *sql.DB and *sql.Tx
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// Module bind DBTX to the *sql.DB of container, and bind Repositories as prototypes
+type Module struct {
+	// Repositories constructors of repositories depending on DBTX, they are rebound in every transaction scope
+	Repositories []any
+}
+
+// repositories is the registry of repository constructors, Begin rebinds them in the scope
+type repositories struct {
+	ctors []any
+}
+
+// Register implements ioc.Registerable
+func (m Module) Register(binder ioc.Binder) error {
+	if err := binder.Singleton(func(db *sql.DB) DBTX { return db }); err != nil {
+		return err
+	}
+
+	registry := &repositories{ctors: m.Repositories}
+	if err := binder.Singleton(func() *repositories { return registry }); err != nil {
+		return err
+	}
+
+	for _, ctor := range m.Repositories {
+		if err := binder.Prototype(ctor); err != nil {
+			return fmt.Errorf("bind repository %T failed: %w", ctor, err)
+		}
+	}
+
+	return nil
+}
+
+// Scope is a request scope (child container) with a transaction, *sql.Tx and DBTX resolved from the scope are
+// the transaction, End commits or rolls back it and closes the scope
+type Scope struct {
+	c  ioc.Container
+	tx *sql.Tx
+}
+
+// Begin create a request scope of parent and begin a transaction on the *sql.DB resolved from parent, ctx
+// is bound in the scope as its context.Context
+func Begin(ctx context.Context, parent ioc.Container, opts *sql.TxOptions) (*Scope, error) {
+	db, err := parent.Get(new(sql.DB))
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.(*sql.DB).BeginTx(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction failed: %w", err)
+	}
+
+	scope := parent.NewChild(ioc.WithRequestScope(fmt.Sprintf("tx-%p", tx)))
+	binds := []func() error{
+		func() error { return scope.ReplaceContext(ctx) },
+		func() error { return scope.Singleton(func() *sql.Tx { return tx }) },
+		func() error { return scope.Singleton(func() DBTX { return tx }) },
+	}
+
+	if registry, err := parent.Get(new(repositories)); err == nil {
+		for _, ctor := range registry.(*repositories).ctors {
+			ctor := ctor
+			binds = append(binds, func() error { return scope.Prototype(ctor) })
+		}
+	}
+
+	for _, bind := range binds {
+		if err := bind(); err != nil {
+			return nil, errors.Join(err, tx.Rollback())
+		}
+	}
+
+	return &Scope{c: scope, tx: tx}, nil
+}
+
+// Container return the container of scope
+func (s *Scope) Container() ioc.Container {
+	return s.c
+}
+
+// Tx return the transaction of scope
+func (s *Scope) Tx() *sql.Tx {
+	return s.tx
+}
+
+// End commit the transaction if err is nil, otherwise roll it back, then close the scope, err is returned
+// joined with the errors of committing and closing
+func (s *Scope) End(err error) error {
+	if err == nil {
+		if commitErr := s.tx.Commit(); commitErr != nil {
+			err = fmt.Errorf("commit transaction failed: %w", commitErr)
+		}
+	} else if rollbackErr := s.tx.Rollback(); rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+		err = errors.Join(err, fmt.Errorf("rollback transaction failed: %w", rollbackErr))
+	}
+
+	return errors.Join(err, s.c.Close())
+}
+
+// WithTx run fn in a transaction scope of parent, the transaction is committed if fn returns nil, otherwise
+// (including panics, which are re-panicked after rollback) it's rolled back
+func WithTx(ctx context.Context, parent ioc.Container, opts *sql.TxOptions, fn func(scope ioc.Container) error) (err error) {
+	scope, err := Begin(ctx, parent, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			_ = scope.End(fmt.Errorf("panic: %v", r))
+			panic(r)
+		}
+	}()
+
+	return scope.End(fn(scope.Container()))
+}
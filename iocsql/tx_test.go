@@ -0,0 +1,153 @@
+package iocsql_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/mylxsw/go-ioc"
+	"github.com/mylxsw/go-ioc/iocsql"
+)
+
+// recordDriver is a fake driver records the statements and transaction outcomes
+type recordDriver struct {
+	lock sync.Mutex
+	logs []string
+}
+
+func (d *recordDriver) log(msg string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.logs = append(d.logs, msg)
+}
+
+func (d *recordDriver) String() string {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return fmt.Sprint(d.logs)
+}
+
+func (d *recordDriver) Open(string) (driver.Conn, error) { return &recordConn{d: d}, nil }
+
+type recordConn struct{ d *recordDriver }
+
+func (c *recordConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordStmt{d: c.d, query: query}, nil
+}
+func (c *recordConn) Close() error              { return nil }
+func (c *recordConn) Begin() (driver.Tx, error) { c.d.log("begin"); return &recordTx{d: c.d}, nil }
+
+type recordTx struct{ d *recordDriver }
+
+func (tx *recordTx) Commit() error   { tx.d.log("commit"); return nil }
+func (tx *recordTx) Rollback() error { tx.d.log("rollback"); return nil }
+
+type recordStmt struct {
+	d     *recordDriver
+	query string
+}
+
+func (s *recordStmt) Close() error  { return nil }
+func (s *recordStmt) NumInput() int { return -1 }
+func (s *recordStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.d.log(s.query)
+	return driver.RowsAffected(1), nil
+}
+func (s *recordStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+var (
+	registerOnce sync.Once
+	fakeDriver   = &recordDriver{}
+)
+
+type orderRepo struct {
+	db iocsql.DBTX
+}
+
+func (r *orderRepo) Create(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, "insert order")
+	return err
+}
+
+func newContainer(t *testing.T) ioc.Container {
+	registerOnce.Do(func() { sql.Register("iocsql-record", fakeDriver) })
+
+	fakeDriver.lock.Lock()
+	fakeDriver.logs = nil
+	fakeDriver.lock.Unlock()
+
+	db, err := sql.Open("iocsql-record", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	c := ioc.New()
+	c.MustSingleton(func() *sql.DB { return db })
+	c.MustLoad(iocsql.Module{Repositories: []any{func(db iocsql.DBTX) *orderRepo { return &orderRepo{db: db} }}})
+
+	return c
+}
+
+func TestWithTx(t *testing.T) {
+	c := newContainer(t)
+	ctx := context.Background()
+
+	err := iocsql.WithTx(ctx, c, nil, func(scope ioc.Container) error {
+		return scope.Resolve(func(repo *orderRepo, tx *sql.Tx) error {
+			if repo.db != iocsql.DBTX(tx) {
+				t.Error("test failed: repository should resolve the transaction in scope")
+			}
+
+			return repo.Create(ctx)
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = iocsql.WithTx(ctx, c, nil, func(scope ioc.Container) error {
+		return scope.Resolve(func(repo *orderRepo) error {
+			if err := repo.Create(ctx); err != nil {
+				return err
+			}
+
+			return errors.New("out of stock")
+		})
+	})
+	if err == nil || err.Error() != "out of stock" {
+		t.Errorf("test failed: %v", err)
+	}
+
+	c.MustResolve(func(repo *orderRepo, db *sql.DB) {
+		if repo.db != iocsql.DBTX(db) {
+			t.Error("test failed: repository should resolve the db outside of scope")
+		}
+	})
+
+	if logs := fakeDriver.String(); logs != "[begin insert order commit begin insert order rollback]" {
+		t.Errorf("test failed: %v", logs)
+	}
+}
+
+func TestWithTxPanic(t *testing.T) {
+	c := newContainer(t)
+
+	defer func() {
+		if r := recover(); r == nil || fakeDriver.String() != "[begin rollback]" {
+			t.Errorf("test failed: %v, %v", r, fakeDriver)
+		}
+	}()
+
+	_ = iocsql.WithTx(context.Background(), c, nil, func(scope ioc.Container) error {
+		panic("boom")
+	})
+}
@@ -0,0 +1,167 @@
+package ioctest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mylxsw/go-ioc"
+)
+
+// FakeClock is an ioc.Clock whose time only moves forward by Advance or Set, timers and tickers created by
+// it fire synchronously when the time passes their deadline
+type FakeClock struct {
+	lock    sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	period   time.Duration // period of ticker, 0 for one-shot timers
+	ch       chan time.Time
+}
+
+// NewFakeClock create a FakeClock starts at start
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// UseFakeClock replace the ioc.Clock binding of c with a FakeClock starts at 2000-01-01 00:00:00 UTC,
+// bindings must resolve the clock after UseFakeClock to observe the fake time
+//
+//	clock := ioctest.UseFakeClock(c)
+//	clock.Advance(time.Minute)
+func UseFakeClock(c ioc.Container) *FakeClock {
+	clock := NewFakeClock(time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC))
+	c.MustSingletonOverride(func() ioc.Clock { return clock })
+
+	return clock
+}
+
+// Now return the current fake time
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.now
+}
+
+// After return a channel receives the fake time once it's advanced by d
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.schedule(d, 0).ch
+}
+
+// Ticker return a Ticker ticks every time the fake time is advanced by d, like time.Ticker, ticks are
+// dropped if the receiver is not ready
+func (c *FakeClock) Ticker(d time.Duration) ioc.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.Ticker")
+	}
+
+	return &fakeTicker{clock: c, waiter: c.schedule(d, d)}
+}
+
+// Advance move the fake time forward by d and fire the timers and tickers due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.setLocked(c.now.Add(d))
+}
+
+// Set move the fake time to t and fire the timers and tickers due, t before the current time is ignored
+func (c *FakeClock) Set(t time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if t.After(c.now) {
+		c.setLocked(t)
+	}
+}
+
+// Waiters return the count of pending timers and tickers, it helps to synchronize with goroutines which
+// are about to wait on the clock
+func (c *FakeClock) Waiters() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.waiters)
+}
+
+func (c *FakeClock) schedule(d time.Duration, period time.Duration) *fakeWaiter {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	w := &fakeWaiter{deadline: c.now.Add(d), period: period, ch: make(chan time.Time, 1)}
+	if d <= 0 && period == 0 {
+		w.ch <- c.now
+		return w
+	}
+
+	c.waiters = append(c.waiters, w)
+	return w
+}
+
+// setLocked move the fake time to now and fire the waiters due in deadline order, caller must hold the lock
+func (c *FakeClock) setLocked(now time.Time) {
+	for {
+		sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].deadline.Before(c.waiters[j].deadline) })
+		if len(c.waiters) == 0 || c.waiters[0].deadline.After(now) {
+			break
+		}
+
+		w := c.waiters[0]
+		c.now = w.deadline
+		select {
+		case w.ch <- w.deadline:
+		default:
+		}
+
+		if w.period > 0 {
+			w.deadline = w.deadline.Add(w.period)
+		} else {
+			c.waiters = c.waiters[1:]
+		}
+	}
+
+	c.now = now
+}
+
+func (c *FakeClock) remove(w *fakeWaiter) {
+	for i, item := range c.waiters {
+		if item == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock  *FakeClock
+	waiter *fakeWaiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.waiter.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+
+	t.clock.remove(t.waiter)
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.Ticker.Reset")
+	}
+
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+
+	t.clock.remove(t.waiter)
+	t.waiter.period, t.waiter.deadline = d, t.clock.now.Add(d)
+	t.clock.waiters = append(t.clock.waiters, t.waiter)
+}
@@ -0,0 +1,88 @@
+package ioctest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mylxsw/go-ioc"
+	"github.com/mylxsw/go-ioc/ioctest"
+)
+
+type sessionStore struct {
+	clock ioc.Clock
+}
+
+func (s sessionStore) Expired(createdAt time.Time) bool {
+	return s.clock.Now().Sub(createdAt) > time.Hour
+}
+
+func TestUseFakeClock(t *testing.T) {
+	c := ioctest.New(t)
+	clock := ioctest.UseFakeClock(c)
+	c.MustSingleton(func(clock ioc.Clock) sessionStore { return sessionStore{clock: clock} })
+
+	store := c.MustGet(sessionStore{}).(sessionStore)
+	createdAt := clock.Now()
+
+	clock.Advance(30 * time.Minute)
+	if store.Expired(createdAt) {
+		t.Error("test failed: session should not expire")
+	}
+
+	clock.Advance(time.Hour)
+	if !store.Expired(createdAt) {
+		t.Error("test failed: session should expire")
+	}
+}
+
+func TestFakeClockCreationRate(t *testing.T) {
+	c := ioctest.New(t)
+	clock := ioctest.UseFakeClock(c)
+	c.MustPrototype(func() *sessionStore { return &sessionStore{} })
+
+	for i := 0; i < 4; i++ {
+		c.MustGet(new(sessionStore))
+	}
+
+	clock.Advance(2 * time.Second)
+	info, err := c.Lookup(new(sessionStore))
+	if err != nil || info.Instances != 4 || info.CreationRate != 2 {
+		t.Errorf("test failed: %+v, %v", info, err)
+	}
+}
+
+func TestFakeClockTimers(t *testing.T) {
+	clock := ioctest.NewFakeClock(time.Unix(0, 0))
+
+	after := clock.After(time.Second)
+	ticker := clock.Ticker(400 * time.Millisecond)
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-after:
+		t.Error("test failed: timer should not fire")
+	default:
+	}
+
+	if tick := <-ticker.C(); !tick.Equal(time.Unix(0, 0).Add(400 * time.Millisecond)) {
+		t.Errorf("test failed: %v", tick)
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if fired := <-after; !fired.Equal(time.Unix(1, 0)) {
+		t.Errorf("test failed: %v", fired)
+	}
+
+	ticker.Stop()
+	<-ticker.C()
+	clock.Advance(time.Second)
+	select {
+	case <-ticker.C():
+		t.Error("test failed: stopped ticker should not tick")
+	default:
+	}
+
+	if clock.Waiters() != 0 {
+		t.Errorf("test failed: %d", clock.Waiters())
+	}
+}
@@ -0,0 +1,29 @@
+package ioctest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mylxsw/go-ioc"
+)
+
+// New create a container for test t, testing.TB is bound so constructors can log, skip or register cleanups
+// via the test, and the built-in context.Context is canceled when the test finishes
+//
+//	c := ioctest.New(t)
+//	c.MustSingleton(func(t testing.TB) *UserRepo {
+//		repo := newRepo()
+//		t.Cleanup(repo.Close)
+//		return repo
+//	})
+func New(t testing.TB, opts ...ioc.Option) ioc.Container {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	c := ioc.NewWithContext(ctx, opts...)
+	c.MustSingleton(func() testing.TB { return t })
+
+	return c
+}
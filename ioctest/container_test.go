@@ -0,0 +1,30 @@
+package ioctest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mylxsw/go-ioc/ioctest"
+)
+
+func TestNew(t *testing.T) {
+	var ctx context.Context
+	t.Run("sub", func(t *testing.T) {
+		c := ioctest.New(t)
+		c.MustResolve(func(tb testing.TB, c context.Context) {
+			if tb != t {
+				t.Error("test failed")
+			}
+
+			ctx = c
+		})
+
+		if ctx.Err() != nil {
+			t.Error("test failed")
+		}
+	})
+
+	if ctx.Err() == nil {
+		t.Error("test failed: context should be canceled at test end")
+	}
+}
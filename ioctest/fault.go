@@ -0,0 +1,73 @@
+// Package ioctest provides utilities for testing applications wired with ioc containers
+package ioctest
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/mylxsw/go-ioc"
+)
+
+// Fault decides whether the nth (starting from 1) resolution of a binding fails, it may also block to
+// simulate latency. A nil error means the resolution continues
+type Fault func(n int64) error
+
+// FailOnNth fail the nth resolution with err
+func FailOnNth(n int64, err error) Fault {
+	return func(current int64) error {
+		if current == n {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// FailAfter fail every resolution after the first n resolutions with err
+func FailAfter(n int64, err error) Fault {
+	return func(current int64) error {
+		if current > n {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// FailEvery fail every nth resolution (n, 2n, 3n...) with err
+func FailEvery(n int64, err error) Fault {
+	return func(current int64) error {
+		if n > 0 && current%n == 0 {
+			return err
+		}
+
+		return nil
+	}
+}
+
+// Latency delay every resolution for d
+func Latency(d time.Duration) Fault {
+	return func(int64) error {
+		time.Sleep(d)
+		return nil
+	}
+}
+
+// InjectFaults wrap the binding of key with faults without changing the production wiring, faults are evaluated
+// in order for every resolution. The returned function removes the faults
+//
+//	restore, err := ioctest.InjectFaults(c, new(UserRepo), ioctest.Latency(time.Second), ioctest.FailOnNth(3, io.EOF))
+//	defer restore()
+func InjectFaults(c ioc.Container, key any, faults ...Fault) (restore func(), err error) {
+	var resolutions int64
+	return c.Intercept(key, func(key any, next func() (any, error)) (any, error) {
+		n := atomic.AddInt64(&resolutions, 1)
+		for _, fault := range faults {
+			if err := fault(n); err != nil {
+				return nil, err
+			}
+		}
+
+		return next()
+	})
+}
@@ -0,0 +1,89 @@
+package ioctest_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mylxsw/go-ioc"
+	"github.com/mylxsw/go-ioc/ioctest"
+)
+
+type UserRepo struct {
+	connStr string
+}
+
+var errConnectionRefused = errors.New("connection refused")
+
+func TestInjectFaults(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "root:root@/my_db?charset=utf8"} })
+
+	restore, err := ioctest.InjectFaults(c, new(UserRepo), ioctest.Latency(10*time.Millisecond), ioctest.FailOnNth(2, errConnectionRefused))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	startTime := time.Now()
+	if _, err := c.Get(new(UserRepo)); err != nil {
+		t.Error(err)
+	}
+
+	if time.Since(startTime) < 10*time.Millisecond {
+		t.Error("test failed")
+	}
+
+	if err := c.Resolve(func(repo *UserRepo) {}); !errors.Is(err, errConnectionRefused) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := c.Get(new(UserRepo)); err != nil {
+		t.Error(err)
+	}
+
+	restore()
+
+	restore, err = ioctest.InjectFaults(c, new(UserRepo), ioctest.FailAfter(0, errConnectionRefused))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Get(new(UserRepo)); !errors.Is(err, errConnectionRefused) {
+		t.Error("test failed")
+	}
+
+	restore()
+	if _, err := c.Get(new(UserRepo)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInjectFaultsOnChild(t *testing.T) {
+	parent := ioc.New()
+	parent.MustSingleton(func() *UserRepo { return &UserRepo{connStr: "root:root@/my_db?charset=utf8"} })
+
+	child := ioc.Extend(parent)
+	grandchild := ioc.Extend(child)
+
+	restore, err := ioctest.InjectFaults(child, new(UserRepo), ioctest.FailAfter(0, errConnectionRefused))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := child.Get(new(UserRepo)); !errors.Is(err, errConnectionRefused) {
+		t.Errorf("test failed: %v", err)
+	}
+
+	if _, err := grandchild.Get(new(UserRepo)); !errors.Is(err, errConnectionRefused) {
+		t.Errorf("test failed: descendants of child should be intercepted: %v", err)
+	}
+
+	if _, err := parent.Get(new(UserRepo)); err != nil {
+		t.Errorf("test failed: parent should be unaffected: %v", err)
+	}
+
+	restore()
+	if child.MustGet(new(UserRepo)) != parent.MustGet(new(UserRepo)) {
+		t.Error("test failed: child should share the singleton of parent")
+	}
+}
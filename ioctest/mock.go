@@ -0,0 +1,54 @@
+package ioctest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mylxsw/go-ioc"
+)
+
+// BindMock override the binding of interface T with the mock created by mockCtor for the duration of test t,
+// the original binding is restored at test cleanup. The test fails immediately if T is not an interface. Expectations of the mock are asserted at cleanup too,
+// mocks with `AssertExpectations(t)` (mockery/testify) and `Finish()` (gomock controllers) are supported
+//
+//	repo := ioctest.BindMock(t, c, func(t testing.TB) UserRepo { return mocks.NewUserRepo(t) })
+//	repo.On("Find", 1).Return(user, nil)
+func BindMock[T any](t testing.TB, c ioc.Container, mockCtor func(t testing.TB) T) T {
+	t.Helper()
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() != reflect.Interface {
+		t.Fatalf("bind mock for %s failed: mocks can only be bound for interfaces", typ)
+	}
+
+	m := mockCtor(t)
+
+	restore, err := c.PushOverride(func() T { return m })
+	if err != nil {
+		t.Fatalf("bind mock for %s failed: %v", typ, err)
+	}
+
+	t.Cleanup(func() {
+		restore()
+		assertExpectations(t, m)
+	})
+
+	return m
+}
+
+// assertExpectations verify the expectations of mock m if it supports
+func assertExpectations(t testing.TB, m any) {
+	if finisher, ok := m.(interface{ Finish() }); ok {
+		finisher.Finish()
+		return
+	}
+
+	method := reflect.ValueOf(m).MethodByName("AssertExpectations")
+	if !method.IsValid() || method.Type().NumIn() != 1 {
+		return
+	}
+
+	if arg := reflect.ValueOf(t); arg.Type().AssignableTo(method.Type().In(0)) {
+		method.Call([]reflect.Value{arg})
+	}
+}
@@ -0,0 +1,100 @@
+package ioctest_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mylxsw/go-ioc"
+	"github.com/mylxsw/go-ioc/ioctest"
+)
+
+type userStore interface {
+	Name(id int) string
+}
+
+type realUserStore struct{}
+
+func (realUserStore) Name(int) string { return "real" }
+
+type expectation interface {
+	Errorf(format string, args ...any)
+}
+
+type mockUserStore struct {
+	calls, expected int
+	asserted        bool
+}
+
+func (m *mockUserStore) Name(int) string {
+	m.calls++
+	return "mock"
+}
+
+func (m *mockUserStore) AssertExpectations(t expectation) bool {
+	m.asserted = true
+	if m.calls != m.expected {
+		t.Errorf("expect %d calls, got %d", m.expected, m.calls)
+		return false
+	}
+
+	return true
+}
+
+func TestBindMock(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() userStore { return realUserStore{} })
+
+	var m *mockUserStore
+	t.Run("sub", func(t *testing.T) {
+		got := ioctest.BindMock(t, c, func(testing.TB) userStore { return &mockUserStore{expected: 1} })
+		m = got.(*mockUserStore)
+
+		c.MustResolve(func(store userStore) {
+			if store.Name(1) != "mock" {
+				t.Error("test failed")
+			}
+		})
+	})
+
+	if !m.asserted {
+		t.Error("test failed: expectations should be asserted at cleanup")
+	}
+
+	c.MustResolve(func(store userStore) {
+		if store.Name(1) != "real" {
+			t.Error("test failed: original binding should be restored")
+		}
+	})
+}
+
+// fatalRecorder records the message of Fatalf and stops the goroutine like testing.T does
+type fatalRecorder struct {
+	testing.TB
+	fatal string
+}
+
+func (r *fatalRecorder) Helper() {}
+
+func (r *fatalRecorder) Fatalf(format string, args ...any) {
+	r.fatal = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func TestBindMockNonInterface(t *testing.T) {
+	c := ioc.New()
+	c.MustSingleton(func() *mockUserStore { return &mockUserStore{} })
+
+	recorder := &fatalRecorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ioctest.BindMock(recorder, c, func(testing.TB) *mockUserStore { return &mockUserStore{} })
+	}()
+	<-done
+
+	if !strings.Contains(recorder.fatal, "only be bound for interfaces") {
+		t.Errorf("test failed: %q", recorder.fatal)
+	}
+}
@@ -0,0 +1,30 @@
+package ioctest
+
+import (
+	"fmt"
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/mylxsw/go-ioc"
+)
+
+// UseSeededRand replace the rand.Source binding of c with a source seeded with seed, and the ioc.IDGenerator
+// binding with UUIDs generated from another source seeded with seed, so random values and IDs are
+// reproducible across test runs
+func UseSeededRand(c ioc.Container, seed int64) rand.Source {
+	src := ioc.NewLockedSource(seed)
+	c.MustSingletonOverride(func() rand.Source { return src })
+
+	ids := ioc.RandUUIDGenerator(ioc.NewLockedSource(seed))
+	c.MustSingletonOverride(func() ioc.IDGenerator { return ids })
+
+	return src
+}
+
+// UseSequentialIDs replace the ioc.IDGenerator binding of c with a generator returns prefix-1, prefix-2...
+func UseSequentialIDs(c ioc.Container, prefix string) {
+	var seq atomic.Int64
+	c.MustSingletonOverride(func() ioc.IDGenerator {
+		return ioc.IDGeneratorFunc(func() string { return fmt.Sprintf("%s-%d", prefix, seq.Add(1)) })
+	})
+}
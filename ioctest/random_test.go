@@ -0,0 +1,48 @@
+package ioctest_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mylxsw/go-ioc"
+	"github.com/mylxsw/go-ioc/ioctest"
+)
+
+func TestUseSeededRand(t *testing.T) {
+	values := func() (int64, string) {
+		c := ioctest.New(t)
+		ioctest.UseSeededRand(c, 42)
+
+		var n int64
+		var id string
+		c.MustResolve(func(src rand.Source, ids ioc.IDGenerator) {
+			n, id = src.Int63(), ids.NewID()
+		})
+
+		return n, id
+	}
+
+	n1, id1 := values()
+	n2, id2 := values()
+	if n1 != n2 || id1 != id2 {
+		t.Errorf("test failed: %v != %v, %v != %v", n1, n2, id1, id2)
+	}
+
+	if len(id1) != 36 || id1[14] != '4' {
+		t.Errorf("test failed: %v", id1)
+	}
+}
+
+func TestUseSequentialIDs(t *testing.T) {
+	c := ioctest.New(t)
+	ioctest.UseSequentialIDs(c, "order")
+
+	ids := c.MustGet(new(ioc.IDGenerator)).(ioc.IDGenerator)
+	if id := ids.NewID(); id != "order-1" {
+		t.Errorf("test failed: %v", id)
+	}
+
+	if id := ids.NewID(); id != "order-2" {
+		t.Errorf("test failed: %v", id)
+	}
+}
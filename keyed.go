@@ -0,0 +1,162 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// namedKey is the key of a string keyed typed binding, bindings of the same name but different types coexist
+type namedKey struct {
+	name string
+	typ  reflect.Type
+}
+
+func (k namedKey) String() string {
+	return fmt.Sprintf("%s(%v)", k.name, k.typ)
+}
+
+// BindKeyed bind a singleton of type T under name, initialize is a constructor whose first return value is
+// assignable to T, or a value of T. initialize can be wrapped by WithOptions/WithCondition. Use GetKeyed to
+// resolve it with compile-time type
+//
+//	ioc.BindKeyed[*sql.DB](c, "primary-db", func() (*sql.DB, error) { return sql.Open("mysql", primaryDSN) })
+//	db, err := ioc.GetKeyed[*sql.DB](c, "primary-db")
+func BindKeyed[T any](b Binder, name string, initialize any) error {
+	if name == "" {
+		return buildInvalidArgsError("name can not be empty")
+	}
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if err := checkInitializeAssignable(initialize, typ); err != nil {
+		return err
+	}
+
+	return b.BindWithKey(namedKey{name: name, typ: typ}, initialize, false, false)
+}
+
+// checkInitializeAssignable check the value initialize creates (or initialize itself if it's a value) is
+// assignable to typ, initialize can be wrapped by WithOptions/WithCondition
+func checkInitializeAssignable(initialize any, typ reflect.Type) error {
+	initF := initialize
+	if cond, ok := initialize.(Conditional); ok {
+		initF = cond.getInitFunc()
+	}
+
+	initType := reflect.TypeOf(initF)
+	if initType == nil {
+		return buildInvalidArgsError("initialize is nil")
+	}
+
+	valueType := initType
+	if initType.Kind() == reflect.Func && typ.Kind() != reflect.Func {
+		if err := checkInitializeType(initType); err != nil {
+			return err
+		}
+
+		valueType = initType.Out(0)
+	}
+
+	if !valueType.AssignableTo(typ) {
+		return buildInvalidArgsError(fmt.Sprintf("%v is not assignable to %v", valueType, typ))
+	}
+
+	return nil
+}
+
+// GetKeyed get the instance of type T bound under name by BindKeyed
+func GetKeyed[T any](r Resolver, name string) (T, error) {
+	var res T
+
+	val, err := r.Get(namedKey{name: name, typ: reflect.TypeOf((*T)(nil)).Elem()})
+	if err != nil {
+		return res, err
+	}
+
+	if val != nil {
+		res = val.(T)
+	}
+
+	return res, nil
+}
+
+// MustGetKeyed get the instance of type T bound under name like GetKeyed, if failed, panic it
+func MustGetKeyed[T any](r Resolver, name string) T {
+	res, err := GetKeyed[T](r, name)
+	r.Must(err)
+
+	return res
+}
+
+// GetNamedAll return all named bindings of the type of key (bound by BindKeyed) in current container and its
+// ancestors as a map[string]T keyed by their names, a binding of a child shadows the one of an ancestor with
+// the same name. Consumers routing by name at runtime (such as workers of several queues) use it instead of
+// resolving names one by one, GetAllKeyed returns the map with compile-time type
+//
+//	ioc.BindKeyed[Queue](c, "orders", newOrdersQueue)
+//	ioc.BindKeyed[Queue](c, "emails", newEmailsQueue)
+//	queues, err := c.GetNamedAll(new(Queue)) // map[string]Queue{"orders": ..., "emails": ...}
+func (impl *container) GetNamedAll(key any) (any, error) {
+	normalized, err := normalizeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	typ, ok := normalized.(reflect.Type)
+	if !ok {
+		return nil, buildInvalidArgsError("the key of named bindings must be a type")
+	}
+
+	results := reflect.MakeMap(reflect.MapOf(reflect.TypeOf(""), typ))
+	for _, k := range impl.namedKeys(typ) {
+		val, err := impl.lookupInstance(k, nil)
+		if err != nil {
+			return nil, fmt.Errorf("resolve %s failed: %w", k, err)
+		}
+
+		value := reflect.ValueOf(val)
+		if !value.IsValid() {
+			value = reflect.Zero(typ)
+		}
+
+		results.SetMapIndex(reflect.ValueOf(k.name), value)
+	}
+
+	return results.Interface(), nil
+}
+
+// GetAllKeyed return all bindings of type T bound by BindKeyed keyed by their names, see GetNamedAll
+func GetAllKeyed[T any](r Resolver) (map[string]T, error) {
+	res, err := r.GetNamedAll(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(map[string]T), nil
+}
+
+// namedKeys return the keys of named bindings of typ in current container and its ancestors, a binding of a
+// child shadows the one of an ancestor with the same name
+func (impl *container) namedKeys(typ reflect.Type) []namedKey {
+	results := make([]namedKey, 0)
+	seen := make(map[string]bool)
+	for cc := impl; cc != nil; {
+		for _, e := range cc.sortedEntities() {
+			k, ok := e.key.(namedKey)
+			if !ok || k.typ != typ || seen[k.name] {
+				continue
+			}
+
+			seen[k.name] = true
+			results = append(results, k)
+		}
+
+		parent, ok := cc.Parent().(*container)
+		if !ok {
+			break
+		}
+
+		cc = parent
+	}
+
+	return results
+}
@@ -0,0 +1,74 @@
+package ioc
+
+import (
+	"reflect"
+	"strings"
+)
+
+// KeyMatcher is a strategy matching lookup keys with the keys of bindings. The built-in rules (the key itself,
+// its type, and the interface a pointer to interface points to) are always tried first, matchers configured by
+// WithKeyMatchers are consulted only when these rules miss the bindings of a container
+type KeyMatcher interface {
+	// MatchKey report whether the binding of bound is resolved for key, key is one of the lookup keys derived
+	// from the key requested (such as new(UserRepo) and its reflect.Type)
+	MatchKey(key any, bound any) bool
+}
+
+// KeyMatcherFunc is a func implements KeyMatcher
+type KeyMatcherFunc func(key any, bound any) bool
+
+// MatchKey report whether the binding of bound is resolved for key
+func (f KeyMatcherFunc) MatchKey(key any, bound any) bool {
+	return f(key, bound)
+}
+
+// WithKeyMatchers add matchers for the keys missed by the built-in rules, they are consulted in order for the
+// bindings of current container (ordered like Bindings), the first binding matched wins. Matchers of a container
+// apply to its own bindings, so children resolve the matched bindings of their parents as well. Unbind and
+// Reserve always use the built-in rules, matched bindings are never removed or reserved by accident
+//
+//	c := ioc.New(ioc.WithKeyMatchers(ioc.CaseInsensitiveKeys(), ioc.AssignableKeys()))
+func WithKeyMatchers(matchers ...KeyMatcher) Option {
+	return func(impl *container) {
+		impl.keyMatchers = append(impl.keyMatchers, matchers...)
+	}
+}
+
+// CaseInsensitiveKeys return a KeyMatcher matching string keys case-insensitively, such as "DSN" and "dsn"
+func CaseInsensitiveKeys() KeyMatcher {
+	return KeyMatcherFunc(func(key any, bound any) bool {
+		k, ok := key.(string)
+		b, boundOk := bound.(string)
+		return ok && boundOk && strings.EqualFold(k, b)
+	})
+}
+
+// AssignableKeys return a KeyMatcher matching interface keys with the bindings of types implementing them, so
+// new(Storage) resolves the binding of *FileStorage without binding Storage explicitly
+func AssignableKeys() KeyMatcher {
+	return KeyMatcherFunc(func(key any, bound any) bool {
+		k, ok := key.(reflect.Type)
+		b, boundOk := bound.(reflect.Type)
+		return ok && boundOk && k.Kind() == reflect.Interface && b.Implements(k)
+	})
+}
+
+// matchEntity find the entity of lookupKeys by the key matchers of current container, nil if none matches
+func (impl *container) matchEntity(lookupKeys []any) *Entity {
+	if len(impl.keyMatchers) == 0 {
+		return nil
+	}
+
+	entities := impl.sortedEntities()
+	for _, matcher := range impl.keyMatchers {
+		for _, lookupKey := range lookupKeys {
+			for _, e := range entities {
+				if matcher.MatchKey(lookupKey, e.key) {
+					return e
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package ioc
+
+import (
+	"os"
+	"strings"
+)
+
+// kubernetesServiceAccountDir is the directory where kubernetes mounts the service account of pod
+const kubernetesServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Value keys bound by BindKubernetesValues
+const (
+	KubernetesNamespaceKey      = "kubernetes.namespace"
+	KubernetesPodNameKey        = "kubernetes.pod_name"
+	KubernetesPodIPKey          = "kubernetes.pod_ip"
+	KubernetesNodeNameKey       = "kubernetes.node_name"
+	KubernetesServiceAccountKey = "kubernetes.service_account"
+)
+
+// KubernetesPod is the metadata of the pod which current process running in, the values come from
+// downward API environment variables (POD_NAMESPACE, POD_NAME, POD_IP, NODE_NAME, POD_SERVICE_ACCOUNT),
+// namespace falls back to the mounted service account namespace file
+type KubernetesPod struct {
+	Namespace      string
+	Name           string
+	IP             string
+	NodeName       string
+	ServiceAccount string
+}
+
+// InKubernetes return whether current process is running in a kubernetes cluster
+func InKubernetes() bool {
+	return os.Getenv("KUBERNETES_SERVICE_HOST") != ""
+}
+
+// OnKubernetes is a condition for WithCondition, the binding only takes effect when running in kubernetes
+//
+//	cc.MustSingleton(ioc.WithCondition(NewClusterConfig, ioc.OnKubernetes()))
+func OnKubernetes() func() bool {
+	return InKubernetes
+}
+
+// NotOnKubernetes is a condition for WithCondition, the binding only takes effect when not running in kubernetes
+func NotOnKubernetes() func() bool {
+	return func() bool { return !InKubernetes() }
+}
+
+// CurrentKubernetesPod return the metadata of current pod
+func CurrentKubernetesPod() KubernetesPod {
+	pod := KubernetesPod{
+		Namespace:      os.Getenv("POD_NAMESPACE"),
+		Name:           os.Getenv("POD_NAME"),
+		IP:             os.Getenv("POD_IP"),
+		NodeName:       os.Getenv("NODE_NAME"),
+		ServiceAccount: os.Getenv("POD_SERVICE_ACCOUNT"),
+	}
+
+	if pod.Name == "" {
+		pod.Name = os.Getenv("HOSTNAME")
+	}
+
+	if pod.Namespace == "" {
+		if data, err := os.ReadFile(kubernetesServiceAccountDir + "/namespace"); err == nil {
+			pod.Namespace = strings.TrimSpace(string(data))
+		}
+	}
+
+	return pod
+}
+
+// BindKubernetesValues bind the metadata of current pod to binder when running in kubernetes, KubernetesPod
+// is bound as a singleton, and every non-empty field is bound as a value with Kubernetes*Key, such as
+// KubernetesNamespaceKey. It does nothing if not running in kubernetes
+func BindKubernetesValues(binder Binder) error {
+	if !InKubernetes() {
+		return nil
+	}
+
+	pod := CurrentKubernetesPod()
+	if err := binder.Singleton(func() KubernetesPod { return pod }); err != nil {
+		return err
+	}
+
+	values := [][2]string{
+		{KubernetesNamespaceKey, pod.Namespace},
+		{KubernetesPodNameKey, pod.Name},
+		{KubernetesPodIPKey, pod.IP},
+		{KubernetesNodeNameKey, pod.NodeName},
+		{KubernetesServiceAccountKey, pod.ServiceAccount},
+	}
+
+	for _, kv := range values {
+		if kv[1] == "" {
+			continue
+		}
+
+		if err := bindBuiltinValue(binder, kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bindBuiltinValue bind a value defined by this package, it's not restricted by strict mode
+func bindBuiltinValue(binder Binder, key string, value any) error {
+	if impl, ok := containerOf(binder); ok {
+		return impl.bindValueOverride(key, attributed(binder, value), false)
+	}
+
+	return binder.BindValue(key, value)
+}
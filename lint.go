@@ -0,0 +1,282 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// IssueKind is the kind of issue reported by Lint
+type IssueKind string
+
+const (
+	// IssueUnresolvable a constructor depends on a key which is not bound
+	IssueUnresolvable IssueKind = "unresolvable"
+	// IssueScopedDependency a binding of an ancestor (global) container depends on a key which is only bound
+	// in current (request scoped) container, it can not be resolved when built in the ancestor
+	IssueScopedDependency IssueKind = "scoped-dependency"
+	// IssueCaptivePrototype a singleton depends on a prototype, the prototype instance becomes a hidden singleton
+	IssueCaptivePrototype IssueKind = "captive-prototype"
+	// IssueCaptiveScoped a singleton of an ancestor container depends on a key which is rebound in current (scoped)
+	// container, the singleton captures the ancestor's binding and is shared across all scopes
+	IssueCaptiveScoped IssueKind = "captive-scoped"
+	// IssueUnusedBinding a binding is never resolved and no other binding depends on it
+	IssueUnusedBinding IssueKind = "unused-binding"
+	// IssueSimilarKeys string keys which are nearly identical, such as "db_host" and "db-host"
+	IssueSimilarKeys IssueKind = "similar-keys"
+	// IssueDependencyCycle constructors depend on each other in a cycle, none of them can be created. The message
+	// suggests the dependencies which can be broken by lazy injection (Factory) or setter injection
+	IssueDependencyCycle IssueKind = "dependency-cycle"
+)
+
+// Issue is a suspicious wiring pattern found by Lint
+type Issue struct {
+	Kind    IssueKind
+	Key     any // the key of the binding which has the issue
+	Message string
+}
+
+func (issue Issue) String() string {
+	return fmt.Sprintf("[%s] %v: %s", issue.Kind, issue.Key, issue.Message)
+}
+
+// Lint analyze the bindings of container (and the bindings of its ancestors which current container relies on)
+// and report suspicious patterns, it does not create any instance. Unused bindings are reported based on
+// resolutions so far, so it's more accurate to lint after the application warmed up
+func Lint(c Container) []Issue {
+	impl, ok := containerOf(c)
+	if !ok {
+		return nil
+	}
+
+	issues := make([]Issue, 0)
+	depended := make(map[*Entity]bool)
+
+	entities := impl.sortedEntities()
+	for _, ancestor := range impl.Ancestors() {
+		if p, ok := ancestor.(*container); ok {
+			entities = append(entities, p.sortedEntities()...)
+		}
+	}
+
+	for _, e := range entities {
+		for _, dep := range e.dependencies() {
+			target := e.c.findEntity(dep)
+			if target == nil && e.c.fallbackHas(dep) {
+				continue
+			}
+
+			if target == nil {
+				if e.c != impl && impl.findEntity(dep) != nil {
+					issues = append(issues, Issue{
+						Kind:    IssueScopedDependency,
+						Key:     e.key,
+						Message: fmt.Sprintf("depends on %v which is only bound in the descendant container", dep),
+					})
+				} else if e.c == impl {
+					issues = append(issues, Issue{
+						Kind:    IssueUnresolvable,
+						Key:     e.key,
+						Message: fmt.Sprintf("depends on %v which is not bound", dep),
+					})
+				}
+
+				continue
+			}
+
+			depended[target] = true
+			if e.c != impl && !e.prototype {
+				if scoped := impl.findEntity(dep); scoped != nil && scoped != target && !scoped.builtin {
+					issues = append(issues, Issue{
+						Kind:    IssueCaptiveScoped,
+						Key:     e.key,
+						Message: fmt.Sprintf("singleton depends on %v which is rebound in the scoped container, the singleton captures the binding of its own container", dep),
+					})
+				}
+			}
+
+			if !e.prototype && target.prototype {
+				issues = append(issues, Issue{
+					Kind:    IssueCaptivePrototype,
+					Key:     e.key,
+					Message: fmt.Sprintf("singleton depends on prototype %v, the prototype instance is captured by the singleton", target.key),
+				})
+			}
+		}
+	}
+
+	for _, e := range entities {
+		for _, dep := range e.dependsOn {
+			if target := e.c.findEntity(dep); target != nil {
+				depended[target] = true
+			} else if e.c == impl && !e.c.fallbackHas(dep) {
+				issues = append(issues, Issue{
+					Kind:    IssueUnresolvable,
+					Key:     e.key,
+					Message: fmt.Sprintf("depends on %v (WithDependsOn) which is not bound", dep),
+				})
+			}
+		}
+	}
+
+	for _, e := range entities {
+		if e.c != impl || e.builtin || depended[e] || atomic.LoadInt64(&e.resolved) > 0 {
+			continue
+		}
+
+		issues = append(issues, Issue{
+			Kind:    IssueUnusedBinding,
+			Key:     e.key,
+			Message: "never resolved and no binding depends on it",
+		})
+	}
+
+	issues = append(issues, cycleIssues(entities)...)
+	return append(issues, similarKeyIssues(impl)...)
+}
+
+// dependencyEdge is a dependency of from on to through type typ
+type dependencyEdge struct {
+	from, to *Entity
+	typ      reflect.Type
+}
+
+// cycleIssues find the dependency cycles of entities, each cycle is reported once from the entity which comes
+// first in entities
+func cycleIssues(entities []*Entity) []Issue {
+	order := make(map[*Entity]int, len(entities))
+	for i, e := range entities {
+		order[e] = i
+	}
+
+	issues := make([]Issue, 0)
+	visited := make(map[*Entity]bool)
+	onStack := make(map[*Entity]int)
+	path := make([]dependencyEdge, 0)
+
+	var visit func(e *Entity)
+	visit = func(e *Entity) {
+		visited[e] = true
+		onStack[e] = len(path)
+		defer delete(onStack, e)
+
+		for _, dep := range e.eagerDependencies() {
+			target := e.c.findEntity(dep)
+			if target == nil {
+				continue
+			}
+
+			edge := dependencyEdge{from: e, to: target, typ: dep}
+			if start, ok := onStack[target]; ok {
+				cycle := append(append([]dependencyEdge{}, path[start:]...), edge)
+				if issue, ok := cycleIssue(cycle, order); ok {
+					issues = append(issues, issue)
+				}
+
+				continue
+			}
+
+			if !visited[target] {
+				path = append(path, edge)
+				visit(target)
+				path = path[:len(path)-1]
+			}
+		}
+	}
+
+	for _, e := range entities {
+		if !visited[e] {
+			visit(e)
+		}
+	}
+
+	return issues
+}
+
+// cycleIssue describe the cycle of edges, the cycle is rotated to start from the entity which comes first in
+// order, false is returned if the cycle includes entities not in order
+func cycleIssue(cycle []dependencyEdge, order map[*Entity]int) (Issue, bool) {
+	first := 0
+	for i, edge := range cycle {
+		if _, ok := order[edge.from]; !ok {
+			return Issue{}, false
+		}
+
+		if order[edge.from] < order[cycle[first].from] {
+			first = i
+		}
+	}
+	cycle = append(cycle[first:], cycle[:first]...)
+
+	keys := make([]string, 0, len(cycle)+1)
+	suggestions := make([]string, 0)
+	for _, edge := range cycle {
+		keys = append(keys, fmt.Sprint(edge.from.key))
+		if edge.typ.Kind() == reflect.Interface {
+			suggestions = append(suggestions, fmt.Sprintf("%v -> %v", edge.from.key, edge.typ))
+		}
+	}
+	keys = append(keys, fmt.Sprint(cycle[0].from.key))
+
+	msg := fmt.Sprintf("dependency cycle %s", strings.Join(keys, " -> "))
+	if len(suggestions) > 0 {
+		msg += fmt.Sprintf(", break one of the interface dependencies [%s] by depending on ioc.Factory of the interface (lazy proxy) or by setter injection after creation", strings.Join(suggestions, ", "))
+	} else {
+		msg += ", no dependency in the cycle is an interface, introduce an interface for one of them and inject it by ioc.Factory (lazy proxy) or by setter injection after creation"
+	}
+
+	return Issue{Kind: IssueDependencyCycle, Key: cycle[0].from.key, Message: msg}, true
+}
+
+// similarKeyIssues find string keys which are identical after ignoring case and separators
+func similarKeyIssues(impl *container) []Issue {
+	groups := make(map[string][]string)
+	for _, key := range impl.Keys() {
+		if k, ok := key.(string); ok {
+			normalized := strings.NewReplacer("-", "", "_", "", ".", "", " ", "").Replace(strings.ToLower(k))
+			groups[normalized] = append(groups[normalized], k)
+		}
+	}
+
+	issues := make([]Issue, 0)
+	for _, keys := range groups {
+		if len(keys) < 2 {
+			continue
+		}
+
+		issues = append(issues, Issue{
+			Kind:    IssueSimilarKeys,
+			Key:     keys[0],
+			Message: fmt.Sprintf("nearly identical keys: %s", strings.Join(keys, ", ")),
+		})
+	}
+
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Key.(string) < issues[j].Key.(string) })
+
+	return issues
+}
+
+// Validate check whether all constructors of current container can be resolved without creating any instance,
+// every unresolvable dependency is reported as an ErrArgsNotInstanced error (joined by errors.Join). If the
+// container is created with WithCaptiveDependencyCheck, singletons capturing prototypes or scoped bindings are
+// reported as ErrCaptiveDependency errors too. Dependency cycles are reported as ErrCircularDependency errors
+func (impl *container) Validate() error {
+	errs := make([]error, 0)
+	for _, issue := range Lint(impl) {
+		switch issue.Kind {
+		case IssueUnresolvable, IssueScopedDependency:
+			errs = append(errs, buildArgNotInstancedError(fmt.Sprintf("%v %s", issue.Key, issue.Message)))
+		case IssueDependencyCycle:
+			errs = append(errs, buildCircularDependencyError(issue.Message))
+		case IssueCaptivePrototype, IssueCaptiveScoped:
+			if impl.captiveCheck {
+				errs = append(errs, buildCaptiveDependencyError(fmt.Sprintf("%v %s", issue.Key, issue.Message)))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
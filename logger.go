@@ -0,0 +1,53 @@
+package ioc
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+)
+
+var loggerType = reflect.TypeOf((*slog.Logger)(nil))
+
+// logger return the logger used for the container's own output, it's the *slog.Logger bound
+// in container (or its parents), if not bound, slog.Default() is used
+func (impl *container) logger() *slog.Logger {
+	if val, err := impl.lookupInstance(loggerType, nil); err == nil {
+		if logger, ok := val.(*slog.Logger); ok && logger != nil {
+			return logger.With("component", "ioc")
+		}
+	}
+
+	return slog.Default().With("component", "ioc")
+}
+
+// LoggerFor derive a named logger for consumer from the *slog.Logger bound in container, the name is
+// consumer itself if it's a string, otherwise the name of consumer's type (pointers dereferenced) and the full
+// type of consumer is attached as the "type" attribute
+//
+//	cc.MustSingleton(func(r ioc.Resolver) *UserRepo {
+//		return &UserRepo{logger: ioc.LoggerFor(r, (*UserRepo)(nil))}
+//	})
+func LoggerFor(r Resolver, consumer any) *slog.Logger {
+	logger := slog.Default()
+	if val, err := r.Get(loggerType); err == nil {
+		if l, ok := val.(*slog.Logger); ok && l != nil {
+			logger = l
+		}
+	}
+
+	if name, ok := consumer.(string); ok {
+		return logger.With("logger", name)
+	}
+
+	typ := reflect.TypeOf(consumer)
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	name := fmt.Sprintf("%T", consumer)
+	if typ != nil && typ.Name() != "" {
+		name = typ.Name()
+	}
+
+	return logger.With("logger", name, "type", fmt.Sprintf("%T", consumer))
+}
@@ -0,0 +1,10 @@
+//go:build !tinygo
+
+package ioc
+
+import "reflect"
+
+// makeFunc create a func of typ implemented by fn like reflect.MakeFunc
+func makeFunc(typ reflect.Type, fn func(args []reflect.Value) []reflect.Value) (reflect.Value, error) {
+	return reflect.MakeFunc(typ, fn), nil
+}
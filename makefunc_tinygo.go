@@ -0,0 +1,15 @@
+//go:build tinygo
+
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// makeFunc fail with errors.ErrUnsupported, TinyGo can not create funcs at runtime, so the features built on it
+// (strategies, map multibindings, func fields of AutoWire and WrapValue of registration hooks) are not available
+func makeFunc(typ reflect.Type, _ func(args []reflect.Value) []reflect.Value) (reflect.Value, error) {
+	return reflect.Value{}, fmt.Errorf("%w: creating func %v at runtime is not supported by TinyGo", errors.ErrUnsupported, typ)
+}
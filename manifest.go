@@ -0,0 +1,115 @@
+package ioc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Manifest declare the bindings of every environment in Go, it's registered with WithManifest and loaded by
+// LoadManifest. All environments must provide the same key set, so a binding missing in one environment (such
+// as prod) is reported before the application starts
+//
+//	manifest := ioc.Manifest{
+//		Common: []ioc.Registerable{serviceModule{}},
+//		Environments: map[string][]ioc.Registerable{
+//			"dev":  {memoryStoreModule{}},
+//			"prod": {mysqlStoreModule{}},
+//		},
+//	}
+//
+//	c := ioc.New(ioc.WithManifest(manifest))
+//	err := c.LoadManifest(os.Getenv("APP_ENV"))
+type Manifest struct {
+	// Common modules loaded in every environment, before the modules of the environment
+	Common []Registerable
+	// Environments modules of each environment
+	Environments map[string][]Registerable
+}
+
+// WithManifest register the manifest which LoadManifest selects bindings from
+func WithManifest(manifest Manifest) Option {
+	return func(impl *container) {
+		impl.manifest = &manifest
+	}
+}
+
+// Validate check that all environments of manifest provide the same key set, the common modules and the modules
+// of each environment are registered into a scratch container, so no instance is created unless the modules
+// resolve something on their own. Keys are compared by identity, so keys of different types never match
+func (m Manifest) Validate() error {
+	if len(m.Environments) == 0 {
+		return buildInvalidArgsError("manifest has no environment")
+	}
+
+	envs := make([]string, 0, len(m.Environments))
+	keySets := make(map[string]map[any]bool)
+	union := make(map[any]bool)
+	for env, modules := range m.Environments {
+		scratch := New(WithDeferredEager()).(*container)
+		if err := scratch.Load(m.Common...); err != nil {
+			return fmt.Errorf("load common modules for environment %s failed: %w", env, err)
+		}
+
+		if err := scratch.Load(modules...); err != nil {
+			return fmt.Errorf("load environment %s failed: %w", env, err)
+		}
+
+		keys := make(map[any]bool)
+		for _, e := range scratch.sortedEntities() {
+			if !e.builtin {
+				keys[e.key] = true
+				union[e.key] = true
+			}
+		}
+
+		envs = append(envs, env)
+		keySets[env] = keys
+	}
+
+	sort.Strings(envs)
+
+	problems := make([]string, 0)
+	for _, env := range envs {
+		missing := make([]string, 0)
+		for key := range union {
+			if !keySets[env][key] {
+				missing = append(missing, fmt.Sprint(key))
+			}
+		}
+
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			problems = append(problems, fmt.Sprintf("%s misses [%s]", env, strings.Join(missing, ", ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		return buildManifestMismatchError(strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// LoadManifest validate the manifest registered with WithManifest and load the common modules and the
+// modules of environment env
+func (impl *container) LoadManifest(env string) error {
+	if impl.manifest == nil {
+		return buildInvalidArgsError("no manifest registered, use WithManifest to register one")
+	}
+
+	modules, ok := impl.manifest.Environments[env]
+	if !ok {
+		return buildInvalidArgsError(fmt.Sprintf("environment %q is not declared in manifest", env))
+	}
+
+	if err := impl.manifest.Validate(); err != nil {
+		return err
+	}
+
+	if err := impl.Load(impl.manifest.Common...); err != nil {
+		return err
+	}
+
+	return impl.Load(modules...)
+}
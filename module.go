@@ -0,0 +1,391 @@
+package ioc
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// ModuleNamer is implemented by modules which name themselves, the name is used to attribute bindings to the
+// module (see BindingInfo.Module), the type name of module is used for other modules
+type ModuleNamer interface {
+	ModuleName() string
+}
+
+// Registerable is implemented by modules which describe their bindings by themselves
+//
+//	type Module struct{}
+//
+//	func (Module) Register(binder ioc.Binder) error {
+//		return binder.Singleton(NewUserRepo)
+//	}
+type Registerable interface {
+	Register(binder Binder) error
+}
+
+// RegisterAll scan values, invoke Register for each value implementing Registerable,
+// values not implementing Registerable are ignored
+func (impl *container) RegisterAll(values ...any) error {
+	modules := make([]Registerable, 0, len(values))
+	for _, v := range values {
+		if module, ok := v.(Registerable); ok {
+			modules = append(modules, module)
+		}
+	}
+
+	return impl.Load(modules...)
+}
+
+// Load register all modules in order, stop at the first module failed. Bindings registered by a module are
+// attributed to it (see ModuleNamer), so a conflict between modules reports the names of both modules. A module
+// already loaded into current container (with the same ModuleName, or an equal comparable value such as the
+// same pointer) is skipped, so shared modules can be loaded by several modules depending on them
+func (impl *container) Load(modules ...Registerable) error {
+	for _, module := range modules {
+		if module == nil {
+			return buildInvalidArgsError("module is nil")
+		}
+
+		if err := impl.loadModule(module); err != nil {
+			return fmt.Errorf("register module %s failed: %w", moduleName(module), err)
+		}
+	}
+
+	return nil
+}
+
+// loadModule register module, bindings registered through the binder passed to its Register are attributed to
+// it, modules can be loaded by modules. The guard of module is marked before registering, so a module loaded by its own dependencies is a no-op too,
+// and it's unmarked if the registration failed
+func (impl *container) loadModule(module Registerable) (err error) {
+	guard, guarded := moduleGuard(module)
+
+	impl.lock.Lock()
+	if guarded && impl.loadedModules[guard] {
+		impl.lock.Unlock()
+		impl.logger().Debug("module already loaded", "module", moduleName(module))
+		return nil
+	}
+
+	if guarded {
+		if impl.loadedModules == nil {
+			impl.loadedModules = make(map[any]bool)
+		}
+		impl.loadedModules[guard] = true
+	}
+
+	impl.lock.Unlock()
+
+	completed := false
+	defer func() {
+		if guarded && (err != nil || !completed) {
+			impl.lock.Lock()
+			delete(impl.loadedModules, guard)
+			impl.lock.Unlock()
+		}
+	}()
+
+	err = module.Register(&moduleBinder{container: impl, module: moduleName(module)})
+	completed = true
+
+	return err
+}
+
+// moduleBinder is the Binder passed to Register of a module by Load, bindings registered through it are
+// attributed to the module, while bindings registered on the container meanwhile (such as by other goroutines)
+// are not. Other methods are promoted from the container
+type moduleBinder struct {
+	*container
+	module string
+}
+
+// containerOf return the container of b, which is the container or a moduleBinder of it
+func containerOf(b any) (*container, bool) {
+	switch v := b.(type) {
+	case *container:
+		return v, true
+	case *moduleBinder:
+		return v.container, true
+	}
+
+	return nil, false
+}
+
+// attributed wrap initialize (or value) so the binding created from it is attributed to the module of b, b is
+// not a moduleBinder if it's registered outside modules
+func attributed(b Binder, initialize any) any {
+	mb, ok := b.(*moduleBinder)
+	if !ok || initialize == nil {
+		return initialize
+	}
+
+	return WithOptions(initialize, func(e *Entity) { e.module = mb.module })
+}
+
+func (b *moduleBinder) P(initialize any) error {
+	return b.Prototype(initialize)
+}
+
+func (b *moduleBinder) S(initialize any) error {
+	return b.Singleton(initialize)
+}
+
+func (b *moduleBinder) V(key string, value any) error {
+	return b.BindValue(key, value)
+}
+
+func (b *moduleBinder) MP(initialize any) {
+	b.MustPrototype(initialize)
+}
+
+func (b *moduleBinder) MS(initialize any) {
+	b.MustSingleton(initialize)
+}
+
+func (b *moduleBinder) MV(key string, value any) {
+	b.MustBindValue(key, value)
+}
+
+func (b *moduleBinder) Prototype(initialize any) error {
+	return b.Bind(initialize, true, false)
+}
+
+func (b *moduleBinder) MustPrototype(initialize any) {
+	b.Must(b.Prototype(initialize))
+}
+
+func (b *moduleBinder) PrototypeWithKey(key any, initialize any) error {
+	return b.BindWithKey(key, initialize, true, false)
+}
+
+func (b *moduleBinder) MustPrototypeWithKey(key any, initialize any) {
+	b.Must(b.PrototypeWithKey(key, initialize))
+}
+
+func (b *moduleBinder) PrototypeOverride(initialize any) error {
+	return b.Bind(initialize, true, true)
+}
+
+func (b *moduleBinder) MustPrototypeOverride(initialize any) {
+	b.Must(b.PrototypeOverride(initialize))
+}
+
+func (b *moduleBinder) PrototypeWithKeyOverride(key any, initialize any) error {
+	return b.BindWithKey(key, initialize, true, true)
+}
+
+func (b *moduleBinder) MustPrototypeWithKeyOverride(key any, initialize any) {
+	b.Must(b.PrototypeWithKeyOverride(key, initialize))
+}
+
+func (b *moduleBinder) Singleton(initialize any) error {
+	return b.Bind(initialize, false, false)
+}
+
+func (b *moduleBinder) MustSingleton(initialize any) {
+	b.Must(b.Singleton(initialize))
+}
+
+func (b *moduleBinder) SingletonWithKey(key any, initialize any) error {
+	return b.BindWithKey(key, initialize, false, false)
+}
+
+func (b *moduleBinder) MustSingletonWithKey(key any, initialize any) {
+	b.Must(b.SingletonWithKey(key, initialize))
+}
+
+func (b *moduleBinder) SingletonOverride(initialize any) error {
+	return b.Bind(initialize, false, true)
+}
+
+func (b *moduleBinder) MustSingletonOverride(initialize any) {
+	b.Must(b.SingletonOverride(initialize))
+}
+
+func (b *moduleBinder) SingletonWithKeyOverride(key any, initialize any) error {
+	return b.BindWithKey(key, initialize, false, true)
+}
+
+func (b *moduleBinder) MustSingletonWithKeyOverride(key any, initialize any) {
+	b.Must(b.SingletonWithKeyOverride(key, initialize))
+}
+
+func (b *moduleBinder) BindValue(key string, value any) error {
+	return b.container.BindValue(key, attributed(b, value))
+}
+
+func (b *moduleBinder) MustBindValue(key string, value any) {
+	b.Must(b.BindValue(key, value))
+}
+
+func (b *moduleBinder) BindValueOverride(key string, value any) error {
+	return b.container.BindValueOverride(key, attributed(b, value))
+}
+
+func (b *moduleBinder) MustBindValueOverride(key string, value any) {
+	b.Must(b.BindValueOverride(key, value))
+}
+
+func (b *moduleBinder) Bind(initialize any, prototype bool, override bool) error {
+	return b.container.Bind(attributed(b, initialize), prototype, override)
+}
+
+func (b *moduleBinder) MustBind(initialize any, prototype bool, override bool) {
+	b.Must(b.Bind(initialize, prototype, override))
+}
+
+func (b *moduleBinder) BindWithKey(key any, initialize any, prototype bool, override bool) error {
+	return b.container.BindWithKey(key, attributed(b, initialize), prototype, override)
+}
+
+func (b *moduleBinder) MustBindWithKey(key any, initialize any, prototype bool, override bool) {
+	b.Must(b.BindWithKey(key, initialize, prototype, override))
+}
+
+func (b *moduleBinder) SingletonVersioned(key any, version string, initialize any) error {
+	return b.container.SingletonVersioned(key, version, attributed(b, initialize))
+}
+
+func (b *moduleBinder) PrototypeVersioned(key any, version string, initialize any) error {
+	return b.container.PrototypeVersioned(key, version, attributed(b, initialize))
+}
+
+func (b *moduleBinder) BindStrategy(key any, selector func(r Resolver) any) error {
+	return b.bindStrategy(b, key, selector)
+}
+
+func (b *moduleBinder) BindBuildInfo() error {
+	return b.bindBuildInfo(b)
+}
+
+func (b *moduleBinder) BindTemplate(name string, factory func(param string) any) error {
+	return b.bindTemplate(b, name, factory)
+}
+
+func (b *moduleBinder) LoadWiring(r io.Reader, codec WiringCodec) error {
+	return b.loadWiring(b, r, codec)
+}
+
+func (b *moduleBinder) PopulateConfig(cfgPtr any, sources ...ConfigSource) error {
+	return b.populateConfig(b, cfgPtr, sources)
+}
+
+// namedModule is the guard of modules implementing ModuleNamer
+type namedModule string
+
+// moduleGuard return the identity of module which detects repeated loads, it's the name of ModuleNamer or the
+// module itself if it's comparable, other modules (such as structs holding slices) have no guard
+func moduleGuard(module Registerable) (any, bool) {
+	if namer, ok := module.(ModuleNamer); ok && namer.ModuleName() != "" {
+		return namedModule(namer.ModuleName()), true
+	}
+
+	if reflect.ValueOf(module).Comparable() {
+		return module, true
+	}
+
+	return nil, false
+}
+
+// moduleName return the name of module, see ModuleNamer
+func moduleName(module Registerable) string {
+	if namer, ok := module.(ModuleNamer); ok && namer.ModuleName() != "" {
+		return namer.ModuleName()
+	}
+
+	return fmt.Sprintf("%T", module)
+}
+
+// moduleDesc describe the registrant of a binding in error messages
+func moduleDesc(module string) string {
+	if module == "" {
+		return "a binding outside modules"
+	}
+
+	return "module " + module
+}
+
+// MustLoad register all modules in order, if failed, panic it
+func (impl *container) MustLoad(modules ...Registerable) {
+	impl.Must(impl.Load(modules...))
+}
+
+// Conflict describe a binding registered by a module whose key is already bound, see WithConflictResolver
+type Conflict struct {
+	Key      any         // the key of bindings
+	Existing BindingInfo // the binding already registered, Existing.Module is the module which registered it
+	Module   string      // name of the module registering the new binding
+}
+
+// ConflictResolver decide how to settle a conflict of bindings, it's called outside the lock of container, so
+// it can inspect the container
+type ConflictResolver func(conflict Conflict) ConflictResolution
+
+type conflictAction int
+
+const (
+	conflictFail conflictAction = iota
+	conflictKeepFirst
+	conflictKeepLast
+	conflictRename
+)
+
+// ConflictResolution is the decision of ConflictResolver
+type ConflictResolution struct {
+	action conflictAction
+	key    any
+}
+
+var (
+	// FailOnConflict fail the registration with ErrRepeatedBind, as if there is no resolver
+	FailOnConflict = ConflictResolution{action: conflictFail}
+	// KeepFirst keep the existing binding and drop the new one
+	KeepFirst = ConflictResolution{action: conflictKeepFirst}
+	// KeepLast replace the existing binding with the new one, even if it's not overridable
+	KeepLast = ConflictResolution{action: conflictKeepLast}
+)
+
+// RenameTo bind the new binding to key instead, key is a string or a type (the value must be assignable to it)
+func RenameTo(key any) ConflictResolution {
+	return ConflictResolution{action: conflictRename, key: key}
+}
+
+// settleConflict apply the decision of conflict resolver on entity, err is the error of the conflict
+func (impl *container) settleConflict(entity *Entity, conflict Conflict, err error) (bool, error) {
+	resolution := impl.conflictResolver(conflict)
+	switch resolution.action {
+	case conflictKeepFirst:
+		impl.logger().Debug("conflicted binding dropped", "key", conflict.Key, "module", conflict.Module, "kept", conflict.Existing.Module)
+		return false, nil
+	case conflictKeepLast:
+		impl.lock.Lock()
+		defer impl.lock.Unlock()
+
+		if err := impl.checkRegistrable(entity); err != nil {
+			return false, err
+		}
+
+		impl.storeEntity(entity)
+		return true, nil
+	case conflictRename:
+		key, err := normalizeKey(resolution.key)
+		if err != nil {
+			return false, err
+		}
+
+		if typ, ok := key.(reflect.Type); ok {
+			if err := impl.isValidKeyKind(typ.Kind()); err != nil {
+				return false, err
+			}
+
+			if entity.typ == nil || !entity.typ.AssignableTo(typ) {
+				return false, buildInvalidArgsError(fmt.Sprintf("can not rename %v to %v, %v is not assignable to it", conflict.Key, key, entity.typ))
+			}
+		}
+
+		entity.key = key
+		return impl.save(entity)
+	}
+
+	return false, err
+}
@@ -0,0 +1,137 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// mapEntryKey is the key of an entry contributed to a map multibinding by InMap
+type mapEntryKey struct {
+	name string
+	key  string
+	typ  reflect.Type
+}
+
+func (k mapEntryKey) String() string {
+	return fmt.Sprintf("%s[%s](%v)", k.name, k.key, k.typ)
+}
+
+// InMap contribute a singleton of type V into the map multibinding name under key, consumers inject the whole
+// map as map[string]V (or resolve it by Get with the reflect.Type of the map). initialize is a constructor whose
+// first return value is assignable to V, or a value of V, it can be wrapped by WithOptions/WithCondition. Entries
+// contributed by ancestors are included in the map, an entry of a child overrides the one of an ancestor with
+// the same key. A value type can only belong to one map name in a container hierarchy
+//
+//	ioc.InMap[Codec](c, "codecs", "json", newJSONCodec)
+//	ioc.InMap[Codec](c, "codecs", "yaml", newYAMLCodec)
+//	c.MustResolve(func(codecs map[string]Codec) { ... })
+func InMap[V any](b Binder, name, key string, initialize any) error {
+	if name == "" || key == "" {
+		return buildInvalidArgsError("name and key can not be empty")
+	}
+
+	impl, ok := containerOf(b)
+	if !ok {
+		return buildInvalidArgsError(fmt.Sprintf("map multibinding is not supported by %T", b))
+	}
+
+	typ := reflect.TypeOf((*V)(nil)).Elem()
+	if err := checkInitializeAssignable(initialize, typ); err != nil {
+		return err
+	}
+
+	for _, entry := range impl.mapEntries(typ) {
+		if entry.name != name {
+			return buildInvalidArgsError(fmt.Sprintf("%v is already contributed to map %s, can not contribute it to map %s", typ, entry.name, name))
+		}
+	}
+
+	if err := b.BindWithKey(mapEntryKey{name: name, key: key, typ: typ}, initialize, false, false); err != nil {
+		return err
+	}
+
+	mapType := reflect.MapOf(reflect.TypeOf(""), typ)
+	if impl.lookupEntity([]any{mapType}, nil) != nil {
+		return nil
+	}
+
+	collect := func(origin *container) (any, error) {
+		return origin.collectMap(name, mapType)
+	}
+
+	// the initialize is only called when the map is resolved without a resolving container (Entity.Value)
+	fnType := reflect.FuncOf(nil, []reflect.Type{mapType, errorType}, false)
+	view, err := makeFunc(fnType, func([]reflect.Value) []reflect.Value {
+		val, err := collect(impl)
+		if err != nil {
+			return []reflect.Value{reflect.Zero(mapType), reflect.ValueOf(&err).Elem()}
+		}
+
+		return []reflect.Value{reflect.ValueOf(val), reflect.Zero(errorType)}
+	})
+	if err != nil {
+		return err
+	}
+
+	// map types are not valid keys for Bind, the map view is registered directly
+	return impl.bindWithOverride(mapType, mapType, WithOptions(view.Interface(), func(e *Entity) { e.strategy = collect }), true, false)
+}
+
+// MustInMap contribute a singleton into a map multibinding like InMap, if failed, panic it
+func MustInMap[V any](b Binder, name, key string, initialize any) {
+	if err := InMap[V](b, name, key, initialize); err != nil {
+		panic(err)
+	}
+}
+
+// mapEntries return keys of all map entries of value type typ in current container and its ancestors,
+// an entry of a child shadows the one of an ancestor with the same key
+func (impl *container) mapEntries(typ reflect.Type) []mapEntryKey {
+	results := make([]mapEntryKey, 0)
+	seen := make(map[string]bool)
+	for cc := impl; cc != nil; {
+		for _, e := range cc.sortedEntities() {
+			k, ok := e.key.(mapEntryKey)
+			if !ok || k.typ != typ || seen[k.key] {
+				continue
+			}
+
+			seen[k.key] = true
+			results = append(results, k)
+		}
+
+		parent, ok := cc.Parent().(*container)
+		if !ok {
+			break
+		}
+
+		cc = parent
+	}
+
+	return results
+}
+
+// collectMap resolve all entries of map name into a new map of mapType
+func (impl *container) collectMap(name string, mapType reflect.Type) (any, error) {
+	entries := impl.mapEntries(mapType.Elem())
+	results := reflect.MakeMapWithSize(mapType, len(entries))
+	for _, entry := range entries {
+		if entry.name != name {
+			continue
+		}
+
+		val, err := impl.lookupInstance(entry, nil)
+		if err != nil {
+			return nil, fmt.Errorf("resolve entry %s of map %s failed: %w", entry.key, name, err)
+		}
+
+		value := reflect.ValueOf(val)
+		if !value.IsValid() {
+			value = reflect.Zero(mapType.Elem())
+		}
+
+		results.SetMapIndex(reflect.ValueOf(entry.key), value)
+	}
+
+	return results.Interface(), nil
+}
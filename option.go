@@ -0,0 +1,234 @@
+package ioc
+
+import "time"
+
+// Option is a function to configure container
+type Option func(impl *container)
+
+// WithMaxLookupDepth limit the levels of parent containers to search when a key is not found
+// in current container, depth <= 0 means no limit
+func WithMaxLookupDepth(depth int) Option {
+	return func(impl *container) {
+		impl.maxLookupDepth = depth
+	}
+}
+
+// WithKeyCollisionCheck reject string keys which are identical to the name of a registered type
+// (and types whose name is identical to a registered string key), such as "ioc.Container",
+// to prevent confusing shadowing between value bindings and type bindings
+func WithKeyCollisionCheck() Option {
+	return func(impl *container) {
+		impl.keyCollisionCheck = true
+	}
+}
+
+// WithConstructorConcurrency limit how many constructors of the container may run concurrently, n <= 0 means no limit.
+// Only the constructor itself holds the slot, resolution of its dependencies does not
+func WithConstructorConcurrency(n int) Option {
+	return func(impl *container) {
+		if n > 0 {
+			impl.constructorSem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithConflictResolver settle the conflicts of bindings registered by modules (see Load) with resolver, instead
+// of failing with ErrRepeatedBind, so module sets of third parties which bind the same keys can be composed
+//
+//	c := ioc.New(ioc.WithConflictResolver(func(conflict ioc.Conflict) ioc.ConflictResolution {
+//		if conflict.Module == "metrics" {
+//			return ioc.KeepFirst
+//		}
+//		return ioc.FailOnConflict
+//	}))
+func WithConflictResolver(resolver ConflictResolver) Option {
+	return func(impl *container) {
+		impl.conflictResolver = resolver
+	}
+}
+
+// WithSharedCache share the singletons of keys (normalized like Get) with the siblings sharing the same keys, the
+// first child resolving a key creates the singleton and the others reuse it (such as compiled templates of
+// per-tenant containers). The constructors of shared singletons must not depend on bindings specific to a child,
+// shared singletons are disposed by the Shutdown of the parent instead of children
+//
+//	tenant := ioc.Extend(root, ioc.WithSharedCache(new(template.Template)))
+func WithSharedCache(keys ...any) Option {
+	return func(impl *container) {
+		if impl.sharedKeys == nil {
+			impl.sharedKeys = make(map[any]bool, len(keys))
+		}
+
+		for _, key := range keys {
+			if normalized, err := normalizeKey(key); err == nil {
+				impl.sharedKeys[normalized] = true
+			}
+		}
+	}
+}
+
+// WithDetached make the child container (created by Extend/NewChild) not shut down by the Shutdown of its
+// parent, it must be shut down on its own
+func WithDetached() Option {
+	return func(impl *container) {
+		impl.detached = true
+	}
+}
+
+// WithDeferredEager defer the instantiation of eager singletons (see WithEager) from bind time to Warmup
+func WithDeferredEager() Option {
+	return func(impl *container) {
+		impl.deferEager = true
+	}
+}
+
+// WithCallMemoization make the args of the same type share one instance within a single Call/Resolve, so a
+// prototype needed by two args of the callback is created only once for that invocation
+func WithCallMemoization() Option {
+	return func(impl *container) {
+		impl.callMemoization = true
+	}
+}
+
+// WithBindingStack keep all registrations of a key instead of replacing on override, plain Get returns the one
+// with the highest priority (see WithPriority, the later one wins for the same priority) while GetAllVersions
+// exposes all of them. It enables layered configuration such as defaults < env < flags
+func WithBindingStack() Option {
+	return func(impl *container) {
+		impl.stacks = make(map[any][]*Entity)
+	}
+}
+
+// WithCaptiveDependencyCheck make Validate report singletons depending on prototypes or scoped bindings (the
+// captive dependency problem, see IssueCaptivePrototype and IssueCaptiveScoped) as ErrCaptiveDependency errors
+func WithCaptiveDependencyCheck() Option {
+	return func(impl *container) {
+		impl.captiveCheck = true
+	}
+}
+
+// BindOption is a function to configure the entity of a binding, use WithOptions to attach options to a binding
+type BindOption func(e *Entity)
+
+// WithPriority set the priority of a binding, bindings with higher priority come first in Keys and ResolveAll,
+// bindings with the same priority are ordered by registration order
+func WithPriority(priority int) BindOption {
+	return func(e *Entity) {
+		e.priority = priority
+	}
+}
+
+// WithDisposer set a disposer for a binding, the disposer is called with the cached value when it is released
+func WithDisposer(disposer func(value any) error) BindOption {
+	return func(e *Entity) {
+		e.disposer = disposer
+	}
+}
+
+// WithIdleEviction drop the cached value of a singleton (after calling its disposer) when it has not been
+// resolved for ttl (measured by the Clock bound in container), the value will be created again on next resolution.
+// It has no effect on prototypes
+func WithIdleEviction(ttl time.Duration) BindOption {
+	return func(e *Entity) {
+		e.idleTTL = ttl
+	}
+}
+
+// WithMaxInstances limit the count of instances a prototype binding can create, when exceeded,
+// ErrMaxInstancesExceeded is returned unless a handler is set by OnMaxInstancesExceeded
+func WithMaxInstances(n int64) BindOption {
+	return func(e *Entity) {
+		e.maxInstances = n
+	}
+}
+
+// OnMaxInstancesExceeded set a handler invoked when the count of instances exceeds the limit of WithMaxInstances,
+// returning nil from handler allows the creation (such as only logging it), otherwise the error is returned
+func OnMaxInstancesExceeded(handler func(key any, count int64) error) BindOption {
+	return func(e *Entity) {
+		e.onInstanceExceeded = handler
+	}
+}
+
+// Privileged mark a binding as privileged, which is allowed to bind to keys reserved by Reserve
+func Privileged() BindOption {
+	return func(e *Entity) {
+		e.privileged = true
+	}
+}
+
+// WithEager instantiate a singleton immediately at bind time (or at Warmup if the container is created with
+// WithDeferredEager) instead of at the first resolution, the error of instantiation is returned by the bind
+// method. It has no effect on prototypes
+func WithEager() BindOption {
+	return func(e *Entity) {
+		e.eager = true
+	}
+}
+
+// SecretMarker mark a binding as secret, its value is redacted in Dump and error messages
+//
+//	c.MustBindValue("db.password", ioc.WithOptions(password, ioc.SecretMarker()))
+func SecretMarker() BindOption {
+	return func(e *Entity) {
+		e.secret = true
+	}
+}
+
+// WithLabels attach metric labels (such as team or subsystem) to a binding, they are reported with its
+// resolutions to the observer of WithResolutionObserver and in profiling workloads, so resolution metrics can be
+// grouped in dashboards. Labels of several WithLabels are merged, the later one wins
+//
+//	c.MustSingleton(ioc.WithOptions(NewOrderRepo, ioc.WithLabels(map[string]string{"team": "orders"})))
+func WithLabels(labels map[string]string) BindOption {
+	return func(e *Entity) {
+		if e.labels == nil {
+			e.labels = make(map[string]string, len(labels))
+		}
+
+		for k, v := range labels {
+			e.labels[k] = v
+		}
+	}
+}
+
+// WithDependsOn declare keys the binding depends on without injecting them (such as migrations which must run
+// before repositories), they are resolved before every creation of the binding, so Warmup and StartRunners
+// respect the order too. Lint and Validate report the keys which are not bound
+//
+//	c.MustSingleton(ioc.WithOptions(NewUserRepo, ioc.WithDependsOn(new(Migrations))))
+func WithDependsOn(keys ...any) BindOption {
+	return func(e *Entity) {
+		e.dependsOn = append(e.dependsOn, keys...)
+	}
+}
+
+// Primary mark a binding as the primary implementation of the interfaces its type implements, an interface field
+// tagged with `autowire:"@"` is injected with it when the interface itself is not bound. A child can mark
+// another primary implementation which takes precedence over the ones of ancestors
+//
+//	c.MustSingleton(ioc.WithOptions(NewMySQLUserRepo, ioc.Primary()))
+//	c.MustSingleton(NewMemoryUserRepo)
+func Primary() BindOption {
+	return func(e *Entity) {
+		e.primary = true
+	}
+}
+
+// NotThreadSafe mark a binding whose instances must not be shared between goroutines (such as clients without
+// internal locking), it can only be bound as a prototype, or as a singleton of a request scope (see
+// WithRequestScope), otherwise the bind method returns ErrNotThreadSafe. Use NotThreadSafeProxy to share a
+// singleton of an interface behind a lock
+func NotThreadSafe() BindOption {
+	return func(e *Entity) {
+		e.notThreadSafe = true
+	}
+}
+
+// WithDecrypt set a decrypt hook which is applied to the value on every resolution, so the container only
+// holds the encrypted value
+func WithDecrypt(decrypt func(value any) (any, error)) BindOption {
+	return func(e *Entity) {
+		e.decrypt = decrypt
+	}
+}
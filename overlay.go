@@ -0,0 +1,129 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Overlay is a temporary resolver created by WithValues, the values of overlay take precedence over the
+// bindings of container for the args of its callbacks only, constructors of the bindings still resolve their
+// dependencies from container. It doesn't construct any entity, so it's cheap enough to be created per operation
+type Overlay struct {
+	c      *container
+	values map[any]any
+	err    error
+}
+
+// WithValues create an Overlay which passes per-operation values (such as request ID or locale) to callbacks,
+// keys of values are types (reflect.Type or a value/pointer of the type like Get) or string keys
+//
+//	err := c.WithValues(map[any]any{reflect.TypeOf(RequestID("")): reqID}).Resolve(func(id RequestID, repo UserRepo) {
+//		...
+//	})
+func (impl *container) WithValues(values map[any]any) *Overlay {
+	overlay := &Overlay{c: impl, values: make(map[any]any, len(values))}
+	for key, val := range values {
+		normalized, err := normalizeKey(key)
+		if err != nil {
+			overlay.err = err
+			break
+		}
+
+		overlay.values[normalized] = val
+	}
+
+	return overlay
+}
+
+// Get get instance by key from overlay values first, then from container
+func (o *Overlay) Get(key any) (any, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	normalized, err := normalizeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if val, ok := o.values[normalized]; ok {
+		return val, nil
+	}
+
+	return o.c.Get(key)
+}
+
+// MustGet get instance by key like Get, if failed, panic it
+func (o *Overlay) MustGet(key any) any {
+	val, err := o.Get(key)
+	o.c.Must(err)
+
+	return val
+}
+
+// Resolve inject args for callback like Container.Resolve, the args are looked up from overlay values first
+func (o *Overlay) Resolve(callback any) error {
+	results, err := o.Call(callback)
+	if err != nil {
+		return err
+	}
+
+	if len(results) == 1 {
+		if err, ok := results[0].(error); ok && err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MustResolve inject args for callback like Resolve, if failed, panic it
+func (o *Overlay) MustResolve(callback any) {
+	o.c.Must(o.Resolve(callback))
+}
+
+// Call call the callback like Container.Call, the args are looked up from overlay values first
+func (o *Overlay) Call(callback any) ([]any, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	callbackValue := reflect.ValueOf(callback)
+	if !callbackValue.IsValid() || callbackValue.Kind() != reflect.Func {
+		return nil, buildInvalidArgsError(fmt.Sprintf("callback must be a func, got %T", callback))
+	}
+
+	callbackType := callbackValue.Type()
+	args := acquireArgs(callbackType.NumIn())
+	defer args.release()
+
+	for i := range args.values {
+		argType := callbackType.In(i)
+		val, ok := o.values[argType]
+		if !ok {
+			arg, err := o.c.instanceOfType(argType, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			args.values[i] = arg
+			continue
+		}
+
+		// overlay values are per-operation data such as tokens, they are never echoed in errors
+		converted, err := convertValue(val, argType)
+		if err != nil {
+			return nil, buildValueConversionError(fmt.Sprintf("can not convert overlay value %s (%T) to %v", redacted, val, argType))
+		}
+
+		args.values[i] = converted
+	}
+
+	returnValues := callbackValue.Call(args.values)
+	results := make([]any, len(returnValues))
+	for i, val := range returnValues {
+		results[i] = val.Interface()
+	}
+
+	return results, nil
+}
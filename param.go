@@ -0,0 +1,163 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// In is embedded in a struct to mark it as a parameter struct, when a constructor or callback requests a
+// parameter struct, its fields are injected one by one instead of looking up the struct itself, so
+// constructors with many dependencies stay readable
+//
+//	type ServerParams struct {
+//		ioc.In
+//
+//		Logger  *slog.Logger
+//		Addr    string        `name:"server.addr" default:":8080"`
+//		Timeout time.Duration `name:"server.timeout" default:"30s"`
+//		Tracer  Tracer        `optional:"true"`
+//	}
+//
+//	c.MustSingleton(func(p ServerParams) *Server { ... })
+//
+// Fields support the following tags:
+//   - name:"key" inject the binding of key (the value is converted to the field type like AutoWire)
+//   - optional:"true" leave the field zero value if the binding is not found
+//   - default:"value" use value (converted to the field type) if the binding is not found, only fields of
+//     primitive types (bool, numbers, string and time.Duration) support it
+//   - autowire:"-" skip the field
+type In struct{}
+
+var inType = reflect.TypeOf(In{})
+
+// isParamStruct return whether t is a parameter struct (a struct embeds In)
+func isParamStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.Anonymous && field.Type == inType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// paramField is the injection rule of a field of parameter struct
+type paramField struct {
+	index      int
+	name       string
+	typ        reflect.Type
+	key        any // the lookup key, type of the field by default
+	optional   bool
+	defaultVal *string
+}
+
+// paramFields parse the injection rules of fields of parameter struct t
+func paramFields(t reflect.Type) ([]paramField, error) {
+	fields := make([]paramField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if (field.Anonymous && field.Type == inType) || field.Tag.Get("autowire") == "-" {
+			continue
+		}
+
+		pf := paramField{index: i, name: field.Name, typ: field.Type, key: field.Type}
+		if name, ok := field.Tag.Lookup("name"); ok && name != "" {
+			pf.key = name
+		}
+
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if !isPrimitiveType(field.Type) {
+				return nil, buildInvalidArgsError(fmt.Sprintf("%v.%s: default tag is only supported by primitive fields", t, field.Name))
+			}
+
+			pf.defaultVal, pf.optional = &def, true
+		}
+
+		if field.Tag.Get("optional") == "true" {
+			pf.optional = true
+		}
+
+		fields = append(fields, pf)
+	}
+
+	return fields, nil
+}
+
+// isPrimitiveType return whether t is a bool, number, string or time.Duration (or the types defined on them)
+func isPrimitiveType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
+
+// paramStructOf create a parameter struct of type t and inject its fields
+func (impl *container) paramStructOf(t reflect.Type, provider func() []*Entity) (reflect.Value, error) {
+	fields, err := paramFields(t)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	res := reflect.New(t).Elem()
+	for _, pf := range fields {
+		val, err := impl.paramFieldValue(pf, provider)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%v.%s: %w", t, pf.name, err)
+		}
+
+		if err := setField(res.Field(pf.index), val); err != nil {
+			return reflect.Value{}, fmt.Errorf("%v.%s: %w", t, pf.name, err)
+		}
+	}
+
+	return res, nil
+}
+
+// paramFieldValue resolve the value of field pf, default value or zero value is used if the field is optional
+// and its key is not bound, a bound key failing for its dependencies is an error even if the field is optional
+func (impl *container) paramFieldValue(pf paramField, provider func() []*Entity) (reflect.Value, error) {
+	val, err := impl.lookupInstance(pf.key, provider)
+	if err == nil {
+		if _, ok := pf.key.(string); ok {
+			return impl.convertBoundValue(pf.key, val, pf.typ)
+		}
+
+		return reflect.ValueOf(val), nil
+	}
+
+	if !pf.optional || !errors.Is(err, ErrObjectNotFound) || impl.findEntity(pf.key) != nil {
+		return reflect.Value{}, wrapArgNotInstancedError(err)
+	}
+
+	if pf.defaultVal == nil {
+		return reflect.Zero(pf.typ), nil
+	}
+
+	return convertValue(*pf.defaultVal, pf.typ)
+}
+
+// paramDependencies return the types of required fields of parameter struct t which are looked up by type
+func paramDependencies(t reflect.Type) []reflect.Type {
+	fields, err := paramFields(t)
+	if err != nil {
+		return nil
+	}
+
+	deps := make([]reflect.Type, 0, len(fields))
+	for _, pf := range fields {
+		if typ, ok := pf.key.(reflect.Type); ok && !pf.optional {
+			deps = append(deps, typ)
+		}
+	}
+
+	return deps
+}
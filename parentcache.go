@@ -0,0 +1,196 @@
+package ioc
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// hierarchyGeneration is increased on every change of container hierarchy (see TryExtendFrom)
+var hierarchyGeneration uint64
+
+// parentCache cache the results (both found and not found) of looking up keys from the ancestors of a child
+// container, so request scoped children don't walk their parents on every miss. Entries are stamped with the
+// generations of the ancestors, any mutation of them invalidates the entries
+type parentCache struct {
+	lock    sync.RWMutex
+	entries map[any]parentCacheEntry
+}
+
+type parentCacheEntry struct {
+	entity *Entity // nil if the key is not bound in any ancestor
+	stamp  generationStamp
+}
+
+// generationStamp identify a state of all ancestors of a container, bindings of a container only grow its
+// generation, so the sum changes on every mutation as long as the hierarchy remains the same
+type generationStamp struct {
+	hierarchy uint64
+	sum       uint64
+}
+
+// bumpGeneration mark current container as mutated, caches of its descendants become stale
+func (impl *container) bumpGeneration() {
+	impl.generation.Add(1)
+}
+
+// Generation return the generation of bindings of current container, it's increased on every mutation of them
+// (bindings registered or unbound, overrides, interceptors, decorators, sources or the parent changed), so caches
+// built on the container (such as compiled plans or routers) can tell whether they are stale. Mutations of
+// ancestors are not counted
+func (impl *container) Generation() uint64 {
+	return impl.generation.Load()
+}
+
+// OnChange register listener which is called after the bindings of current container are mutated (see
+// Generation), outside the lock of container so it can inspect the container. Several mutations made
+// concurrently may be reported by one call. The returned function removes the listener
+func (impl *container) OnChange(listener func()) (remove func()) {
+	if listener == nil {
+		return func() {}
+	}
+
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
+	impl.changeSeq++
+	id := impl.changeSeq
+	impl.changeListeners = append(impl.changeListeners, changeListener{id: id, listener: listener})
+
+	return func() {
+		impl.lock.Lock()
+		defer impl.lock.Unlock()
+
+		for i, entry := range impl.changeListeners {
+			if entry.id == id {
+				impl.changeListeners = append(impl.changeListeners[:i:i], impl.changeListeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+type changeListener struct {
+	id       int
+	listener func()
+}
+
+// notifyChanges call the listeners of OnChange if the generation is increased since last notification, caller
+// must not hold the lock
+func (impl *container) notifyChanges() {
+	generation := impl.generation.Load()
+	for {
+		notified := impl.notified.Load()
+		if notified >= generation {
+			return
+		}
+
+		if impl.notified.CompareAndSwap(notified, generation) {
+			break
+		}
+	}
+
+	impl.lock.RLock()
+	listeners := impl.changeListeners
+	impl.lock.RUnlock()
+
+	for _, entry := range listeners {
+		entry.listener()
+	}
+}
+
+// bumpHierarchyGeneration mark the hierarchy of containers as changed, all caches become stale
+func bumpHierarchyGeneration() {
+	atomic.AddUint64(&hierarchyGeneration, 1)
+}
+
+// ancestorsStamp return the generation stamp of all ancestors, false if some ancestors are not created by this
+// package, their mutations can not be tracked
+func (impl *container) ancestorsStamp() (generationStamp, bool) {
+	stamp := generationStamp{hierarchy: atomic.LoadUint64(&hierarchyGeneration)}
+	for p := impl.Parent(); p != nil; p = p.Parent() {
+		pc, ok := p.(*container)
+		if !ok {
+			return generationStamp{}, false
+		}
+
+		stamp.sum += pc.generation.Load()
+	}
+
+	return stamp, true
+}
+
+// ancestorEntity find the entity of key from the ancestors of current container, the result is cached until
+// the ancestors mutated. The second return value is false if the result can not be cached, misses are not
+// cached if some ancestors have binding sources, the caller should walk the ancestors to consult the sources
+func (impl *container) ancestorEntity(key any, lookupKeys []any) (*Entity, bool) {
+	stamp, ok := impl.ancestorsStamp()
+	if !ok {
+		return nil, false
+	}
+
+	// pointers (such as new(UserRepo)) are cached by their types, so fresh pointers share one entry, unless
+	// the pointer itself is bound by some ancestor
+	cacheKey := key
+	if _, isType := key.(reflect.Type); !isType && reflect.TypeOf(key).Kind() == reflect.Ptr {
+		if impl.ancestorsBind(key) {
+			return nil, false
+		}
+
+		cacheKey = lookupKeys[1]
+	}
+
+	impl.parentCache.lock.RLock()
+	entry, ok := impl.parentCache.entries[cacheKey]
+	impl.parentCache.lock.RUnlock()
+
+	if ok && entry.stamp == stamp {
+		return entry.entity, true
+	}
+
+	var entity *Entity
+	var hasSources bool
+	for p := impl.Parent(); p != nil && entity == nil; p = p.Parent() {
+		pc := p.(*container)
+		entity = pc.lookupEntity(lookupKeys, nil)
+		hasSources = hasSources || len(pc.bindingSources()) > 0
+	}
+
+	if entity == nil && hasSources {
+		return nil, false
+	}
+
+	impl.parentCache.lock.Lock()
+	if impl.parentCache.entries == nil {
+		impl.parentCache.entries = make(map[any]parentCacheEntry)
+	}
+	impl.parentCache.entries[cacheKey] = parentCacheEntry{entity: entity, stamp: stamp}
+	impl.parentCache.lock.Unlock()
+
+	return entity, true
+}
+
+// ancestorsBind return whether key itself (instead of its type) is bound by some ancestor
+func (impl *container) ancestorsBind(key any) bool {
+	for p := impl.Parent(); p != nil; p = p.Parent() {
+		pc := p.(*container)
+
+		pc.lock.RLock()
+		_, ok := pc.entities[key]
+		pc.lock.RUnlock()
+
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parentCacheSize return the count of entries in the cache of lookups from ancestors
+func (impl *container) parentCacheSize() int {
+	impl.parentCache.lock.RLock()
+	defer impl.parentCache.lock.RUnlock()
+
+	return len(impl.parentCache.entries)
+}
@@ -0,0 +1,62 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// lookupPath resolve a dotted path key such as "config.db.host", the longest bound prefix ("config.db", then
+// "config") is resolved from container, and the rest of path traverses exported struct fields or map keys
+func (impl *container) lookupPath(path string, provider func() []*Entity) (any, error) {
+	pos := strings.LastIndex(path, ".")
+	if pos <= 0 || pos == len(path)-1 {
+		return nil, buildObjectNotFoundError(fmt.Sprintf("key=%v not found", path))
+	}
+
+	parent, err := impl.lookupInstance(path[:pos], provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return traversePath(parent, path[pos+1:], path)
+}
+
+// traversePath get the field or map entry named name from value
+func traversePath(value any, name string, path string) (any, error) {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return nil, buildObjectNotFoundError(fmt.Sprintf("key=%v not found: %s is nil", path, strings.TrimSuffix(path, "."+name)))
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		field, ok := v.Type().FieldByNameFunc(func(fieldName string) bool { return strings.EqualFold(fieldName, name) })
+		if ok && field.IsExported() {
+			return v.FieldByIndex(field.Index).Interface(), nil
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String {
+			if item := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key())); item.IsValid() {
+				return item.Interface(), nil
+			}
+		}
+	}
+
+	return nil, buildObjectNotFoundError(fmt.Sprintf("key=%v not found: %v has no field or key named %s", path, v.Type(), name))
+}
+
+// isPathKey return whether key can be resolved as a dotted path when it's not bound directly
+func (impl *container) isPathKey(key any, err error) (string, bool) {
+	path, ok := key.(string)
+	if !ok || !strings.Contains(path, ".") || !errors.Is(err, ErrObjectNotFound) {
+		return "", false
+	}
+
+	return path, impl.findEntity(path) == nil
+}
@@ -0,0 +1,38 @@
+package ioc
+
+import (
+	"reflect"
+	"sync"
+)
+
+// maxPooledArgs is the max capacity of args buffers kept in pool, larger buffers are left to GC
+const maxPooledArgs = 32
+
+var argsPool = sync.Pool{New: func() any { return &argsBuf{} }}
+
+// argsBuf is a pooled buffer of args for calling constructors and callbacks in hot paths
+type argsBuf struct {
+	values []reflect.Value
+}
+
+// acquireArgs get an args buffer with n zero values from pool
+func acquireArgs(n int) *argsBuf {
+	buf := argsPool.Get().(*argsBuf)
+	if cap(buf.values) < n {
+		buf.values = make([]reflect.Value, n)
+	}
+	buf.values = buf.values[:n]
+
+	return buf
+}
+
+// release clear the buffer and put it back to pool, the buffer must not be used after release
+func (buf *argsBuf) release() {
+	if cap(buf.values) > maxPooledArgs {
+		return
+	}
+
+	clear(buf.values)
+	buf.values = buf.values[:0]
+	argsPool.Put(buf)
+}
@@ -0,0 +1,90 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// autowireInterface resolve the interface typ for an `autowire:"@"` field, the binding of typ itself is used if
+// bound (errors of its dependencies are returned as is), otherwise the primary implementation (see Primary)
+func (impl *container) autowireInterface(typ reflect.Type) (reflect.Value, error) {
+	val, err := impl.instanceOfType(typ, nil)
+	if err == nil || !errors.Is(err, ErrObjectNotFound) || impl.findEntity(typ) != nil {
+		return val, err
+	}
+
+	primary, primaryErr := impl.primaryOf(typ)
+	if primaryErr != nil {
+		return reflect.Value{}, primaryErr
+	}
+
+	if primary == nil {
+		return reflect.Value{}, err
+	}
+
+	return impl.instanceOfKey(primary.key)
+}
+
+// primaryOf return the primary entity implementing the interface typ, the nearest container having one wins,
+// nil if there is none, several primary implementations in the same container fail with ErrInvalidArgs
+func (impl *container) primaryOf(typ reflect.Type) (*Entity, error) {
+	for cc := impl; cc != nil; {
+		var candidates []*Entity
+		for _, e := range cc.sortedEntities() {
+			if e.primary && e.typ != nil && e.typ.Implements(typ) {
+				candidates = append(candidates, e)
+			}
+		}
+
+		switch len(candidates) {
+		case 0:
+		case 1:
+			return candidates[0], nil
+		default:
+			keys := make([]string, len(candidates))
+			for i, e := range candidates {
+				keys[i] = fmt.Sprint(e.key)
+			}
+
+			return nil, buildInvalidArgsError(fmt.Sprintf("%v has several primary implementations: %s", typ, strings.Join(keys, ", ")))
+		}
+
+		parent, ok := cc.Parent().(*container)
+		if !ok {
+			break
+		}
+
+		cc = parent
+	}
+
+	return nil, nil
+}
+
+// autowireNamed resolve the binding named name for an `autowire:"@name"` field of type typ, it's the keyed
+// binding of typ (see BindKeyed), or the version name of typ (see SingletonVersioned)
+func (impl *container) autowireNamed(name string, typ reflect.Type) (reflect.Value, error) {
+	keys := []any{namedKey{name: name, typ: typ}}
+	if vk, err := newVersionedKey(typ, name); err == nil {
+		keys = append(keys, vk)
+	}
+
+	for _, key := range keys {
+		if impl.findEntity(key) != nil {
+			return impl.instanceOfKey(key)
+		}
+	}
+
+	return reflect.Value{}, buildObjectNotFoundError(fmt.Sprintf("no binding of %v is named %s, bind it by BindKeyed or as a version", typ, name))
+}
+
+// instanceOfKey resolve key as a reflect.Value for setField
+func (impl *container) instanceOfKey(key any) (reflect.Value, error) {
+	val, err := impl.lookupInstance(key, nil)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	return reflect.ValueOf(val), nil
+}
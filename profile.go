@@ -0,0 +1,161 @@
+package ioc
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Workload is a resolution workload recorded by profiling (see EnableProfiling), it can be replayed against
+// containers with alternative configurations by Replay
+type Workload struct {
+	Entries []WorkloadEntry
+}
+
+// WorkloadEntry is the resolutions of a key in a workload
+type WorkloadEntry struct {
+	Key    any
+	Labels map[string]string // labels of the binding, see WithLabels
+	Count  int64
+	// Elapsed total time spent on resolving the key, including the resolution of its dependencies
+	Elapsed time.Duration
+}
+
+// profiler record the resolutions of a container
+type profiler struct {
+	lock    sync.Mutex
+	entries map[any]*WorkloadEntry
+}
+
+func (p *profiler) record(e *Entity, elapsed time.Duration) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	entry, ok := p.entries[e.key]
+	if !ok {
+		entry = &WorkloadEntry{Key: e.key, Labels: e.labels}
+		p.entries[e.key] = entry
+	}
+
+	entry.Count++
+	entry.Elapsed += elapsed
+}
+
+// EnableProfiling start recording the resolutions of current container (keys, frequencies and elapsed time),
+// the previous recording is discarded
+func (impl *container) EnableProfiling() {
+	impl.profiler.Store(&profiler{entries: make(map[any]*WorkloadEntry)})
+}
+
+// DisableProfiling stop recording the resolutions and return the recorded workload
+func (impl *container) DisableProfiling() Workload {
+	workload := impl.Profile()
+	impl.profiler.Store(nil)
+
+	return workload
+}
+
+// Profile return the workload recorded so far, ordered by count (more first), empty if profiling is not enabled
+func (impl *container) Profile() Workload {
+	p := impl.profiler.Load()
+	if p == nil {
+		return Workload{}
+	}
+
+	p.lock.Lock()
+	entries := make([]WorkloadEntry, 0, len(p.entries))
+	for _, entry := range p.entries {
+		entries = append(entries, *entry)
+	}
+	p.lock.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+
+		return fmt.Sprint(entries[i].Key) < fmt.Sprint(entries[j].Key)
+	})
+
+	return Workload{Entries: entries}
+}
+
+// ReplayReport is the result of replaying a workload
+type ReplayReport struct {
+	// Entries the measured resolutions of every key, in the order of workload
+	Entries []WorkloadEntry
+	// Elapsed total time spent on the replay
+	Elapsed time.Duration
+}
+
+// Replay resolve every key of workload from c as many times as recorded, and measure the time spent, it's used
+// for evaluating the performance impact of wiring changes
+//
+//	c.EnableProfiling()
+//	// ... run the application
+//	workload := c.DisableProfiling()
+//
+//	report, err := ioc.Replay(buildContainer(ioc.WithConstructorConcurrency(4)), workload)
+func Replay(c Container, workload Workload) (ReplayReport, error) {
+	report := ReplayReport{Entries: make([]WorkloadEntry, 0, len(workload.Entries))}
+	for _, entry := range workload.Entries {
+		started := time.Now()
+		for i := int64(0); i < entry.Count; i++ {
+			if _, err := c.Get(entry.Key); err != nil {
+				return report, fmt.Errorf("replay %v failed: %w", entry.Key, err)
+			}
+		}
+
+		elapsed := time.Since(started)
+		report.Entries = append(report.Entries, WorkloadEntry{Key: entry.Key, Count: entry.Count, Elapsed: elapsed})
+		report.Elapsed += elapsed
+	}
+
+	return report, nil
+}
+
+// Resolution is a resolution of a binding reported to the observer of WithResolutionObserver
+type Resolution struct {
+	Key any
+	// Labels the labels of the binding (see WithLabels), it's shared by all resolutions and must not be modified
+	Labels map[string]string
+	// Elapsed time spent on the resolution, including the resolution of its dependencies
+	Elapsed time.Duration
+	Err     error
+}
+
+// WithResolutionObserver call observer after every resolution of the bindings of container (the resolution of
+// a binding bound by an ancestor is reported to the ancestor's observer), it's the hook for exporting
+// resolution metrics or traces to APM systems. observer is called synchronously, it should return quickly
+//
+//	c := ioc.New(ioc.WithResolutionObserver(func(r ioc.Resolution) {
+//		resolutionSeconds.With(r.Labels).Observe(r.Elapsed.Seconds())
+//	}))
+func WithResolutionObserver(observer func(Resolution)) Option {
+	return func(impl *container) {
+		impl.observer = observer
+	}
+}
+
+// profiled record the resolution of entity if profiling is enabled, and report it to the observer if any
+func (e *Entity) profiled(resolve func() (any, error)) (any, error) {
+	p, observer := e.c.profiler.Load(), e.c.observer
+	if p == nil && observer == nil {
+		return resolve()
+	}
+
+	started := time.Now()
+	val, err := resolve()
+	elapsed := time.Since(started)
+
+	if p != nil {
+		p.record(e, elapsed)
+	}
+
+	if observer != nil {
+		observer(Resolution{Key: e.key, Labels: e.labels, Elapsed: elapsed, Err: err})
+	}
+
+	return val, err
+}
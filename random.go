@@ -0,0 +1,109 @@
+package ioc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	mrand "math/rand"
+	"sync"
+	"time"
+)
+
+// IDGenerator generate unique IDs, it's bound in container by default with a random UUID (version 4)
+// implementation, tests can replace it with a deterministic one (see ioctest.UseSequentialIDs)
+type IDGenerator interface {
+	// NewID return a new unique ID
+	NewID() string
+}
+
+// IDGeneratorFunc is a func implements IDGenerator
+type IDGeneratorFunc func() string
+
+// NewID return a new unique ID
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+// UUIDGenerator return an IDGenerator which generates random UUIDs (version 4)
+func UUIDGenerator() IDGenerator {
+	return IDGeneratorFunc(func() string {
+		var uuid [16]byte
+		if _, err := rand.Read(uuid[:]); err != nil {
+			panic(err)
+		}
+
+		return formatUUID(uuid)
+	})
+}
+
+// formatUUID set the version and variant bits of uuid and format it as xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx
+func formatUUID(uuid [16]byte) string {
+	uuid[6] = (uuid[6] & 0x0f) | 0x40
+	uuid[8] = (uuid[8] & 0x3f) | 0x80
+
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+
+	return string(buf)
+}
+
+// RandUUIDGenerator return an IDGenerator which generates UUIDs (version 4) from src, a seeded src makes
+// the IDs reproducible
+func RandUUIDGenerator(src mrand.Source) IDGenerator {
+	r := mrand.New(src)
+	var lock sync.Mutex
+
+	return IDGeneratorFunc(func() string {
+		var uuid [16]byte
+
+		lock.Lock()
+		_, _ = r.Read(uuid[:])
+		lock.Unlock()
+
+		return formatUUID(uuid)
+	})
+}
+
+// NewLockedSource return a rand.Source seeded with seed which is safe for concurrent use, rand.Source
+// bound in container by default is created by it with a time based seed
+func NewLockedSource(seed int64) mrand.Source64 {
+	return &lockedSource{src: mrand.NewSource(seed).(mrand.Source64)}
+}
+
+type lockedSource struct {
+	lock sync.Mutex
+	src  mrand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.src.Seed(seed)
+}
+
+// defaultRandSource create the rand.Source bound in container by default
+func defaultRandSource() mrand.Source {
+	return NewLockedSource(time.Now().UnixNano())
+}
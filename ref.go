@@ -0,0 +1,69 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Reference is a forward reference of the binding of T, T is resolved by Get when the reference is used instead
+// of when it's created, so T can be registered after the constructors referencing it (such as by another module
+// loaded later), and two bindings can reference each other without a circular dependency. Constructors (and
+// callbacks) request it as an arg, or modules create it by Ref
+//
+//	c.MustSingleton(func(users ioc.Reference[*UserService]) *OrderService {
+//		return &OrderService{users: users}
+//	})
+type Reference[T any] struct {
+	c        *container
+	provider func() []*Entity
+}
+
+// Ref create a forward reference of T resolved from the container of b, it can be captured by constructors
+// before T is registered
+//
+//	func (OrderModule) Register(binder ioc.Binder) error {
+//		users := ioc.Ref[*UserService](binder) // registered by UserModule
+//		return binder.Singleton(func() *OrderService { return &OrderService{users: users} })
+//	}
+func Ref[T any](b Binder) Reference[T] {
+	impl, _ := containerOf(b)
+	return Reference[T]{c: impl}
+}
+
+// Get resolve the binding of T like Get of container, singletons are created once and cached by container
+func (r Reference[T]) Get() (T, error) {
+	var res T
+	if r.c == nil {
+		return res, buildInvalidArgsError(fmt.Sprintf("reference of %v is not created by container", r.target()))
+	}
+
+	val, err := r.c.lookupInstance(r.target(), r.provider)
+	if err != nil || val == nil {
+		return res, err
+	}
+
+	return val.(T), nil
+}
+
+// MustGet resolve the binding of T like Get, if failed, panic it
+func (r Reference[T]) MustGet() T {
+	val, err := r.Get()
+	if err != nil {
+		panic(err)
+	}
+
+	return val
+}
+
+// Bound return whether the binding of T is registered now
+func (r Reference[T]) Bound() bool {
+	return r.c != nil && r.c.findEntity(r.target()) != nil
+}
+
+func (r *Reference[T]) setContainer(c *container, provider func() []*Entity) {
+	r.c, r.provider = c, detachedProvider(provider)
+}
+
+func (r Reference[T]) target() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
@@ -0,0 +1,132 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ValueSource provides values to be bound in container by Reloader, such as configuration files or environment
+// variables, Values is called on every reload
+type ValueSource interface {
+	Values() (map[string]any, error)
+}
+
+// ValueSourceFunc is an adapter to allow the use of ordinary functions as ValueSource
+type ValueSourceFunc func() (map[string]any, error)
+
+// Values call f()
+func (f ValueSourceFunc) Values() (map[string]any, error) {
+	return f()
+}
+
+// ReloadEvent is published on the EventBus of container after every successful reload
+type ReloadEvent struct {
+	// Changed the keys of values changed by the reload, ordered by name
+	Changed []string
+}
+
+// Reloader re-reads value sources and refreshes the container on SIGHUP (see Watch) or Reload: values of sources are
+// rebound (later sources take precedence), refreshable singletons are re-created on next resolution and a
+// ReloadEvent is published
+//
+//	reloader, err := ioc.NewReloader(c, fileSource, envSource)
+//	if err != nil {
+//		return err
+//	}
+//
+//	if err := reloader.Refreshable(new(*sql.DB)).Reload(); err != nil {
+//		return err
+//	}
+//	go reloader.Watch(ctx)
+type Reloader struct {
+	lock        sync.Mutex
+	c           *container
+	sources     []ValueSource
+	refreshable []any
+	current     map[string]any
+}
+
+// NewReloader create a Reloader for container c (must be created by this package) with value sources
+func NewReloader(c Container, sources ...ValueSource) (*Reloader, error) {
+	impl, ok := containerOf(c)
+	if !ok || impl == nil {
+		return nil, buildInvalidArgsError(fmt.Sprintf("container must be created by ioc.New or ioc.Extend, got %T", c))
+	}
+
+	return &Reloader{c: impl, sources: sources}, nil
+}
+
+// Refreshable mark singletons of keys to be re-created after every reload, the disposer of the binding
+// (see WithDisposer) is called with the previous instance
+func (r *Reloader) Refreshable(keys ...any) *Reloader {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.refreshable = append(r.refreshable, keys...)
+	return r
+}
+
+// Reload re-read all value sources and refresh the container, nothing is changed if any source fails
+func (r *Reloader) Reload() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	values := make(map[string]any)
+	for _, source := range r.sources {
+		vals, err := source.Values()
+		if err != nil {
+			return fmt.Errorf("read value source failed: %w", err)
+		}
+
+		for k, v := range vals {
+			values[k] = v
+		}
+	}
+
+	changed := make([]string, 0)
+	for k, v := range values {
+		if old, ok := r.current[k]; ok && reflect.DeepEqual(old, v) {
+			continue
+		}
+
+		changed = append(changed, k)
+	}
+	sort.Strings(changed)
+
+	// all values are validated before any of them is bound, a failed reload leaves the container unchanged
+	entities := make([]*Entity, 0, len(changed))
+	for _, k := range changed {
+		e, err := r.c.newValueEntity(k, values[k], true)
+		if err != nil {
+			return fmt.Errorf("bind value %s failed: %w", k, err)
+		}
+
+		if e != nil {
+			entities = append(entities, e)
+		}
+	}
+
+	if err := r.c.registerAll(entities); err != nil {
+		return fmt.Errorf("bind values failed: %w", err)
+	}
+	r.current = values
+
+	for _, key := range r.refreshable {
+		e := r.c.findEntity(key)
+		if e == nil {
+			return buildObjectNotFoundError(fmt.Sprintf("refreshable key=%v not found", key))
+		}
+
+		e.refresh()
+	}
+
+	if bus, err := r.c.Get(new(EventBus)); err == nil {
+		bus.(EventBus).Publish(ReloadEvent{Changed: changed})
+	}
+
+	r.c.logger().Info("container reloaded", "changed", changed)
+
+	return nil
+}
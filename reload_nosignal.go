@@ -0,0 +1,11 @@
+//go:build js || plan9 || windows
+
+package ioc
+
+import "context"
+
+// Watch wait until ctx is done, SIGHUP is not supported on this platform, call Reload to reload the container
+func (r *Reloader) Watch(ctx context.Context) {
+	r.c.logger().Warn("SIGHUP is not supported on this platform, the container is only reloaded by Reload")
+	<-ctx.Done()
+}
@@ -0,0 +1,28 @@
+//go:build !js && !plan9 && !windows
+
+package ioc
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Watch reload the container on every SIGHUP until ctx is done, reload errors are logged
+func (r *Reloader) Watch(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			if err := r.Reload(); err != nil {
+				r.c.logger().Error("reload container failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var runnerType = reflect.TypeOf((*Runner)(nil)).Elem()
+
+// Runner is a long-running service managed by container, singletons implementing Runner are started by
+// StartRunners in dependency order (dependencies first) and stopped by StopRunners in reverse order
+type Runner interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// GraphNode is a binding in the dependency graph
+type GraphNode struct {
+	Key any
+	// Module name of the module which registered the binding (see Load), empty if not registered by a module
+	Module string
+	// Dependencies keys of the bindings this binding depends on, types which are not bound are kept as is
+	Dependencies []any
+}
+
+// Graph is the dependency graph of the bindings in a container
+type Graph struct {
+	// Nodes bindings of current container, ordered by priority (higher first) and registration order
+	Nodes []GraphNode
+	// StartOrder keys of Runner singletons in the order they are started by StartRunners
+	StartOrder []any
+}
+
+// Graph return the dependency graph of current container, it does not create any instance
+func (impl *container) Graph() Graph {
+	entities := impl.sortedEntities()
+
+	nodes := make([]GraphNode, 0, len(entities))
+	for _, e := range entities {
+		deps := make([]any, 0)
+		for _, dep := range e.dependencies() {
+			if target := impl.findEntity(dep); target != nil {
+				deps = append(deps, target.key)
+			} else {
+				deps = append(deps, dep)
+			}
+		}
+
+		for _, dep := range e.dependsOn {
+			if target := impl.findEntity(dep); target != nil {
+				deps = append(deps, target.key)
+			} else {
+				deps = append(deps, dep)
+			}
+		}
+
+		nodes = append(nodes, GraphNode{Key: e.key, Module: e.module, Dependencies: deps})
+	}
+
+	order := make([]any, 0)
+	for _, e := range impl.runnerOrder() {
+		order = append(order, e.key)
+	}
+
+	return Graph{Nodes: nodes, StartOrder: order}
+}
+
+// runnerOrder return the Runner singletons of current container in topological dependency order, runners
+// which depend on other runners (directly or through non-runner bindings) come after them
+func (impl *container) runnerOrder() []*Entity {
+	order := make([]*Entity, 0)
+	visited := make(map[*Entity]bool)
+
+	var visit func(e *Entity)
+	visit = func(e *Entity) {
+		if visited[e] {
+			return
+		}
+		visited[e] = true
+
+		for _, dep := range e.dependencies() {
+			if target := impl.findEntity(dep); target != nil && target.c == impl {
+				visit(target)
+			}
+		}
+
+		for _, dep := range e.dependsOn {
+			if target := impl.findEntity(dep); target != nil && target.c == impl {
+				visit(target)
+			}
+		}
+
+		if !e.prototype && e.typ != nil && e.typ.Implements(runnerType) {
+			order = append(order, e)
+		}
+	}
+
+	for _, e := range impl.sortedEntities() {
+		visit(e)
+	}
+
+	return order
+}
+
+// StartRunners start all Runner singletons of current container in dependency order (see Graph), it stops at
+// the first failure, runners already started are stopped by StopRunners
+func (impl *container) StartRunners(ctx context.Context) error {
+	for _, e := range impl.runnerOrder() {
+		val, err := e.resolve(nil)
+		if err != nil {
+			return err
+		}
+
+		runner := val.(Runner)
+		if err := runner.Start(ctx); err != nil {
+			return fmt.Errorf("start %v failed: %w", e.key, err)
+		}
+
+		impl.lock.Lock()
+		impl.started = append(impl.started, startedRunner{key: e.key, runner: runner})
+		impl.lock.Unlock()
+
+		impl.logger().Debug("runner started", "key", e.key)
+	}
+
+	return nil
+}
+
+// StopRunners stop the runners started by StartRunners in reverse order, errors are joined
+func (impl *container) StopRunners(ctx context.Context) error {
+	impl.lock.Lock()
+	started := impl.started
+	impl.started = nil
+	impl.lock.Unlock()
+
+	errs := make([]error, 0)
+	for i := len(started) - 1; i >= 0; i-- {
+		if err := started[i].runner.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("stop %v failed: %w", started[i].key, err))
+		}
+
+		impl.logger().Debug("runner stopped", "key", started[i].key)
+	}
+
+	return errors.Join(errs...)
+}
+
+type startedRunner struct {
+	key    any
+	runner Runner
+}
@@ -0,0 +1,72 @@
+package ioc
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// ScopeKind is the kind of scope a container represents
+type ScopeKind string
+
+const (
+	// ScopeRoot the root container, created by New/NewWithContext
+	ScopeRoot ScopeKind = "root"
+	// ScopeChild a child container, created by Extend/NewChild
+	ScopeChild ScopeKind = "child"
+	// ScopeRequest a child container which serves a single request, created with WithRequestScope
+	ScopeRequest ScopeKind = "request"
+	// ScopeTenant a child container which serves a tenant, created by TenantContainers
+	ScopeTenant ScopeKind = "tenant"
+)
+
+// scopeSeq is used to generate scope IDs
+var scopeSeq uint64
+
+// ScopeInfo describe the scope of the container building an object, it's bound in every container, so
+// shared constructors can adapt their behavior (e.g., no global caches in request scope)
+//
+//	c.MustPrototype(func(scope ioc.ScopeInfo) *Cache {
+//		if scope.Kind == ioc.ScopeRequest {
+//			return newNopCache()
+//		}
+//		return newCache()
+//	})
+type ScopeInfo struct {
+	Kind ScopeKind
+	// ID the identity of scope, it's the request ID for request scopes
+	ID string
+	// Depth the count of ancestors of the container
+	Depth int
+}
+
+// WithRequestScope mark the container (created by Extend/NewChild) as a request scope identified by id
+func WithRequestScope(id string) Option {
+	return withScope(ScopeInfo{Kind: ScopeRequest, ID: id})
+}
+
+func withScope(scope ScopeInfo) Option {
+	return func(impl *container) {
+		impl.scope = scope
+	}
+}
+
+// initScope fill the scope information of container which is not set by options
+func (impl *container) initScope() {
+	impl.scope.Depth = len(impl.Ancestors())
+
+	if impl.scope.Kind == "" {
+		impl.scope.Kind = ScopeChild
+		if impl.Parent() == nil {
+			impl.scope.Kind = ScopeRoot
+		}
+	}
+
+	if impl.scope.ID == "" {
+		impl.scope.ID = fmt.Sprintf("%s-%d", impl.scope.Kind, atomic.AddUint64(&scopeSeq, 1))
+	}
+}
+
+// Scope return the scope information of current container
+func (impl *container) Scope() ScopeInfo {
+	return impl.scope
+}
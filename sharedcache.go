@@ -0,0 +1,107 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// sharedCache hold the singletons shared by the children of a container, see WithSharedCache
+type sharedCache struct {
+	lock    sync.Mutex
+	entries map[any]*sharedEntry
+}
+
+// sharedEntry is a singleton shared by children, owner is the entity which created it
+type sharedEntry struct {
+	call  *initCall
+	owner *Entity
+}
+
+// getOrCreate return the shared singleton of key, create it by create if absent. Concurrent callers wait for the
+// creation of the first one, a failed creation is not cached, the next caller tries again
+func (s *sharedCache) getOrCreate(key any, owner *Entity, create func() (any, error)) (any, error) {
+	s.lock.Lock()
+	if entry, ok := s.entries[key]; ok {
+		s.lock.Unlock()
+		<-entry.call.done
+		return entry.call.value, entry.call.err
+	}
+
+	if s.entries == nil {
+		s.entries = make(map[any]*sharedEntry)
+	}
+
+	call := &initCall{done: make(chan struct{})}
+	s.entries[key] = &sharedEntry{call: call, owner: owner}
+	s.lock.Unlock()
+
+	defer func() {
+		if call.err != nil || call.value == nil {
+			s.lock.Lock()
+			delete(s.entries, key)
+			s.lock.Unlock()
+		}
+
+		close(call.done)
+	}()
+
+	call.err = buildConstructorPanicError(key, "initialize panicked")
+	call.value, call.err = create()
+
+	return call.value, call.err
+}
+
+// shutdown dispose all shared singletons by the disposers of their owners, the singletons not disposed when ctx
+// is done are reported as pending
+func (s *sharedCache) shutdown(ctx context.Context) error {
+	s.lock.Lock()
+	entries := s.entries
+	s.entries = nil
+	s.lock.Unlock()
+
+	errs := make([]error, 0)
+	pending := make([]any, 0)
+	for key, entry := range entries {
+		select {
+		case <-entry.call.done:
+		default:
+			continue
+		}
+
+		dispose := entry.owner.disposeFunc(entry.call.value)
+		if dispose == nil {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			pending = append(pending, key)
+			continue
+		}
+
+		if err := dispose(entry.call.value); err != nil {
+			errs = append(errs, fmt.Errorf("dispose shared %v failed: %w", key, err))
+		}
+	}
+
+	if len(pending) > 0 {
+		errs = append(errs, &ShutdownTimeoutError{Pending: pending})
+	}
+
+	return errors.Join(errs...)
+}
+
+// sharedCacheOf return the cache which the singleton of entity is shared by, nil if it's not shared
+func (e *Entity) sharedCacheOf() *sharedCache {
+	if e.prototype || !e.c.sharedKeys[e.key] {
+		return nil
+	}
+
+	parent, ok := e.c.Parent().(*container)
+	if !ok {
+		return nil
+	}
+
+	return &parent.shared
+}
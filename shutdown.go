@@ -0,0 +1,195 @@
+package ioc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// ShutdownTimeoutError is returned by Shutdown if some disposers did not finish in time
+type ShutdownTimeoutError struct {
+	// Pending keys of the bindings whose disposers did not finish (or did not start) before ctx is done,
+	// in disposal order
+	Pending []any
+}
+
+func (e *ShutdownTimeoutError) Error() string {
+	return fmt.Sprintf("%v: disposers of %v did not finish in time", ErrShutdownTimeout, e.Pending)
+}
+
+func (e *ShutdownTimeoutError) Unwrap() error {
+	return ErrShutdownTimeout
+}
+
+// Close release all instantiated singletons of current container, it's Shutdown without timeout
+func (impl *container) Close() error {
+	return impl.Shutdown(context.Background())
+}
+
+// Shutdown release all instantiated singletons of current container in reverse instantiation order (a singleton
+// is disposed before its dependencies, value bindings come last), disposers (see WithDisposer) are called one
+// by one, singletons implementing io.Closer without a disposer are closed. If ctx is done before all disposers
+// finish, a *ShutdownTimeoutError reporting the keys of blocking (and not started) disposers is returned, the
+// singletons not started are kept, so a later Shutdown can finish them. Errors of disposers are joined.
+// Resolutions from a container being shut down (or its descendants, if the key is bound by it) fail with
+// ErrScopeClosed.
+//
+// Children which instantiated singletons needing disposal are shut down before current container (the latest first), so
+// request scopes still draining never use resources of the parent which are already released, unless they are
+// created with WithDetached
+func (impl *container) Shutdown(ctx context.Context) error {
+	impl.closed.Store(true)
+	impl.untrack()
+
+	errs := make([]error, 0)
+	for _, child := range impl.takeChildren() {
+		if err := child.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown child container %s failed: %w", child.scope.ID, err))
+		}
+	}
+
+	entities := impl.sortedEntities()
+	sort.SliceStable(entities, func(i, j int) bool {
+		return atomic.LoadUint64(&entities[i].instanceSeq) > atomic.LoadUint64(&entities[j].instanceSeq)
+	})
+
+	for i, e := range entities {
+		value := e.release()
+		dispose := e.disposerOf(value)
+		if dispose == nil {
+			continue
+		}
+
+		started := time.Now()
+		done := make(chan error, 1)
+		go func() { done <- dispose(value) }()
+
+		select {
+		case err := <-done:
+			impl.logger().Debug("singleton disposed", "key", e.key, "elapsed", time.Since(started))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("dispose %v failed: %w", e.key, err))
+			}
+		case <-ctx.Done():
+			pending := []any{e.key}
+			for _, rest := range entities[i+1:] {
+				if rest.disposerOf(rest.cachedValue()) != nil {
+					pending = append(pending, rest.key)
+				}
+			}
+
+			impl.logger().Error("shutdown timeout", "pending", pending)
+			return errors.Join(append(errs, &ShutdownTimeoutError{Pending: pending})...)
+		}
+	}
+
+	if err := impl.shared.shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// disposerOf return the disposer for value of entity, singletons implementing io.Closer are closed if no
+// disposer is specified, nil if value needs no disposal. Shared singletons (see WithSharedCache) are disposed
+// by the parent which shares them
+func (e *Entity) disposerOf(value any) func(value any) error {
+	if e.sharedCacheOf() != nil {
+		return nil
+	}
+
+	return e.disposeFunc(value)
+}
+
+// disposeFunc return the disposer for value of entity regardless of sharing
+func (e *Entity) disposeFunc(value any) func(value any) error {
+	if value == nil || e.builtin {
+		return nil
+	}
+
+	if e.disposer != nil {
+		return e.disposer
+	}
+
+	if _, ok := value.(io.Closer); ok {
+		return func(value any) error { return value.(io.Closer).Close() }
+	}
+
+	return nil
+}
+
+// childSeq orders the children tracked by their parents
+var childSeq atomic.Uint64
+
+// track register current container as a child of its parent for the cascading Shutdown, it's called once the
+// container instantiates a singleton which needs disposal, so children with nothing to release are never
+// retained by the parent
+func (impl *container) track() {
+	if impl.detached || impl.tracked.Load() {
+		return
+	}
+
+	hierarchyLock.Lock()
+	defer hierarchyLock.Unlock()
+
+	parent, ok := impl.Parent().(*container)
+	if !ok || !impl.tracked.CompareAndSwap(false, true) {
+		return
+	}
+
+	parent.addChild(impl)
+}
+
+// untrack remove current container from the children of its parent
+func (impl *container) untrack() {
+	hierarchyLock.Lock()
+	defer hierarchyLock.Unlock()
+
+	if !impl.tracked.CompareAndSwap(true, false) {
+		return
+	}
+
+	if parent, ok := impl.Parent().(*container); ok {
+		parent.removeChild(impl)
+	}
+}
+
+func (impl *container) addChild(child *container) {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
+	if impl.children == nil {
+		impl.children = make(map[*container]uint64)
+	}
+
+	impl.children[child] = childSeq.Add(1)
+}
+
+func (impl *container) removeChild(child *container) {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
+	delete(impl.children, child)
+}
+
+// takeChildren remove all tracked children of current container and return them, the latest tracked first
+func (impl *container) takeChildren() []*container {
+	impl.lock.Lock()
+	children := impl.children
+	impl.children = nil
+	impl.lock.Unlock()
+
+	results := make([]*container, 0, len(children))
+	for child := range children {
+		child.tracked.Store(false)
+		results = append(results, child)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return children[results[i]] > children[results[j]] })
+
+	return results
+}
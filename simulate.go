@@ -0,0 +1,140 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ImpactReport describe the impact of overriding a binding, see SimulateOverride
+type ImpactReport struct {
+	Key any // the key of the binding to be overridden
+	// Exists whether key is bound in current container, otherwise the override adds a binding which shadows
+	// the one of ancestors (if any)
+	Exists bool
+	// Dependents keys of bindings in current container which depend on key directly or transitively,
+	// ordered by priority (higher first) and registration order
+	Dependents []any
+	// Stale keys of instantiated singletons in Dependents, they keep the instance created before the override
+	Stale []any
+	// Missing dependencies of the new initialize which can not be found in container
+	Missing []reflect.Type
+}
+
+// SimulateOverride report the bindings which would be affected if key were overridden by initialize in current
+// container without changing it, the error which the override would fail with is returned if any (such as
+// ErrRepeatedBind for a binding which is not overridable, or ErrFrozen)
+//
+//	report, err := c.SimulateOverride(new(UserRepo), newCachedUserRepo)
+//	if err == nil && len(report.Stale) > 0 {
+//		log.Printf("these singletons keep using the old UserRepo: %v", report.Stale)
+//	}
+func (impl *container) SimulateOverride(key any, initialize any) (ImpactReport, error) {
+	if !reflect.ValueOf(key).IsValid() {
+		return ImpactReport{}, buildInvalidArgsError("key is nil")
+	}
+
+	lookupKeys, _ := impl.resolveLookupKeys(key)
+	current := impl.lookupEntity(lookupKeys, nil)
+
+	entityKey, err := normalizeKey(key)
+	if err != nil {
+		return ImpactReport{}, err
+	}
+
+	prototype := false
+	if current != nil {
+		entityKey, prototype = current.key, current.prototype
+	}
+
+	initF := initialize
+	if cond, ok := initialize.(Conditional); ok {
+		initF = cond.getInitFunc()
+	}
+
+	valueType := reflect.TypeOf(initF)
+	if valueType == nil {
+		return ImpactReport{}, buildInvalidArgsError("initialize is nil")
+	}
+
+	keyType, isTypeKey := entityKey.(reflect.Type)
+	if valueType.Kind() == reflect.Func && !(isTypeKey && keyType.Kind() == reflect.Func) {
+		if err := checkInitializeType(valueType); err != nil {
+			return ImpactReport{}, err
+		}
+
+		valueType = valueType.Out(0)
+	}
+
+	if isTypeKey && !valueType.AssignableTo(keyType) {
+		return ImpactReport{}, buildInvalidArgsError(fmt.Sprintf("%v is not assignable to key type %v", valueType, keyType))
+	}
+
+	entity, err := impl.buildEntity(entityKey, valueType, initialize, prototype, true)
+	if err != nil {
+		return ImpactReport{}, err
+	}
+
+	if entity != nil {
+		impl.lock.RLock()
+		err = impl.checkSavable(entity)
+		impl.lock.RUnlock()
+
+		if err != nil {
+			return ImpactReport{}, err
+		}
+	}
+
+	report := ImpactReport{Key: entityKey, Exists: current != nil, Dependents: make([]any, 0), Stale: make([]any, 0), Missing: make([]reflect.Type, 0)}
+	if entity != nil {
+		for _, dep := range entity.dependencies() {
+			if impl.findEntity(dep) == nil {
+				report.Missing = append(report.Missing, dep)
+			}
+		}
+	}
+
+	entities := impl.sortedEntities()
+	affected := map[any]bool{entityKey: true}
+	for changed := true; changed; {
+		changed = false
+		for _, e := range entities {
+			if affected[e.key] || !impl.dependsOnAny(e, affected) {
+				continue
+			}
+
+			affected[e.key] = true
+			changed = true
+		}
+	}
+
+	for _, e := range entities {
+		if e.key == entityKey || !affected[e.key] {
+			continue
+		}
+
+		report.Dependents = append(report.Dependents, e.key)
+		if !e.prototype && e.info().Instantiated {
+			report.Stale = append(report.Stale, e.key)
+		}
+	}
+
+	return report, nil
+}
+
+// dependsOnAny return whether one of the dependencies of e is resolved to a key in keys by current container
+func (impl *container) dependsOnAny(e *Entity, keys map[any]bool) bool {
+	for _, dep := range e.dependencies() {
+		lookupKeys, _ := impl.resolveLookupKeys(dep)
+		for _, k := range lookupKeys {
+			if keys[k] {
+				return true
+			}
+		}
+
+		if target := impl.lookupEntity(lookupKeys, nil); target != nil && keys[target.key] {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,120 @@
+package ioc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// ValueSnapshot is a persisted copy of the value bindings of a container, see SaveSnapshot
+type ValueSnapshot struct {
+	CreatedAt time.Time      `json:"created_at"`
+	Values    map[string]any `json:"values"`
+}
+
+// SnapshotCodec serialize value snapshots, JSONSnapshotCodec is used if no codec is specified. The types of
+// values may not survive the codec (for example, numbers become float64 with JSON), consumers receive what
+// Decode returns
+type SnapshotCodec interface {
+	Encode(w io.Writer, snapshot ValueSnapshot) error
+	Decode(r io.Reader) (ValueSnapshot, error)
+}
+
+// JSONSnapshotCodec is a SnapshotCodec encoding snapshots as JSON
+var JSONSnapshotCodec SnapshotCodec = jsonSnapshotCodec{}
+
+type jsonSnapshotCodec struct{}
+
+func (jsonSnapshotCodec) Encode(w io.Writer, snapshot ValueSnapshot) error {
+	return json.NewEncoder(w).Encode(snapshot)
+}
+
+func (jsonSnapshotCodec) Decode(r io.Reader) (ValueSnapshot, error) {
+	var snapshot ValueSnapshot
+	err := json.NewDecoder(r).Decode(&snapshot)
+	return snapshot, err
+}
+
+// SaveSnapshot write the value bindings (see BindValue) of current container (not including parents) to w with
+// codec, so a fresh container can restore them by RestoreSnapshot instead of fetching them again. Constructors
+// are never persisted, secret values (see SecretMarker) are skipped
+//
+//	f, _ := os.Create("config.snapshot")
+//	defer f.Close()
+//	err := c.SaveSnapshot(f, nil)
+func (impl *container) SaveSnapshot(w io.Writer, codec SnapshotCodec) error {
+	if w == nil {
+		return buildInvalidArgsError("writer is nil")
+	}
+
+	if codec == nil {
+		codec = JSONSnapshotCodec
+	}
+
+	snapshot := ValueSnapshot{CreatedAt: impl.clock().Now(), Values: make(map[string]any)}
+	for _, e := range impl.sortedEntities() {
+		key, ok := e.key.(string)
+		if !ok || e.initializeFunc != nil || e.builtin || e.secret {
+			continue
+		}
+
+		snapshot.Values[key] = e.cachedValue()
+	}
+
+	if err := codec.Encode(w, snapshot); err != nil {
+		return fmt.Errorf("encode snapshot failed: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreSnapshot bind the values of the snapshot read from r (see SaveSnapshot) to current container, a snapshot
+// older than maxAge (measured by the Clock of container, maxAge <= 0 means no limit) is not restored and false
+// is returned, so the caller can fetch the values in the usual way. Values are bound like BindValue, restoring
+// a key already bound fails with ErrRepeatedBind and no value is bound
+func (impl *container) RestoreSnapshot(r io.Reader, codec SnapshotCodec, maxAge time.Duration) (bool, error) {
+	if r == nil {
+		return false, buildInvalidArgsError("reader is nil")
+	}
+
+	if codec == nil {
+		codec = JSONSnapshotCodec
+	}
+
+	snapshot, err := codec.Decode(r)
+	if err != nil {
+		return false, fmt.Errorf("decode snapshot failed: %w", err)
+	}
+
+	if maxAge > 0 && impl.clock().Now().Sub(snapshot.CreatedAt) > maxAge {
+		return false, nil
+	}
+
+	keys := make([]string, 0, len(snapshot.Values))
+	for key := range snapshot.Values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entities := make([]*Entity, 0, len(keys))
+	for _, key := range keys {
+		if err := impl.checkStrictValueBinding(key); err != nil {
+			return false, err
+		}
+
+		entity, err := impl.newValueEntity(key, snapshot.Values[key], false)
+		if err != nil {
+			return false, fmt.Errorf("restore %s failed: %w", key, err)
+		}
+
+		entities = append(entities, entity)
+	}
+
+	if err := impl.registerAll(entities); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,138 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// BindingSource provides bindings to a container on demand, such as a static map, generated code or a remote
+// registry. When a key is not bound in a container, its sources are consulted in order (before its parents),
+// the first binding found is registered in the container, so singletons stay singletons
+type BindingSource interface {
+	// Binding return the registration for key, ok is false if the source has no such binding. Only Initialize
+	// (or Value) and Prototype of the registration are required, Key defaults to key
+	Binding(key any) (reg Registration, ok bool, err error)
+}
+
+// MapSource is a static BindingSource, it maps keys (types or strings) to constructors or values of singletons
+//
+//	ioc.New(ioc.WithBindingSources(ioc.MapSource{
+//		reflect.TypeOf((*UserRepo)(nil)): newUserRepo,
+//		"version":                         "1.0.0",
+//	}))
+type MapSource map[any]any
+
+// Binding return the registration of key in the map
+func (m MapSource) Binding(key any) (Registration, bool, error) {
+	init, ok := m[key]
+	if !ok {
+		return Registration{}, false, nil
+	}
+
+	if reflect.TypeOf(init).Kind() == reflect.Func {
+		return Registration{Initialize: init}, true, nil
+	}
+
+	return Registration{Value: init}, true, nil
+}
+
+// WithBindingSources compose the container from binding sources, they are consulted in order for keys not bound
+func WithBindingSources(sources ...BindingSource) Option {
+	return func(impl *container) {
+		impl.sources = append(impl.sources, sources...)
+	}
+}
+
+// AddSource append a binding source to container, it's consulted after the existing sources. Caches of
+// descendants are invalidated, so they see the bindings of the new source
+func (impl *container) AddSource(source BindingSource) error {
+	if source == nil {
+		return buildInvalidArgsError("source is nil")
+	}
+
+	defer impl.notifyChanges()
+
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
+	impl.sources = append(impl.sources, source)
+	impl.bumpGeneration()
+
+	return nil
+}
+
+// bindingSources return the binding sources of container
+func (impl *container) bindingSources() []BindingSource {
+	impl.lock.RLock()
+	defer impl.lock.RUnlock()
+
+	return impl.sources
+}
+
+// sourceEntity find the binding of lookupKeys from the binding sources of container and register it,
+// nil if no source has the binding
+func (impl *container) sourceEntity(lookupKeys []any) (*Entity, error) {
+	for _, source := range impl.bindingSources() {
+		for _, key := range lookupKeys {
+			reg, ok, err := source.Binding(key)
+			if err != nil {
+				return nil, fmt.Errorf("binding source failed for key=%v: %w", key, err)
+			}
+
+			if !ok {
+				continue
+			}
+
+			if reg.Key == nil {
+				reg.Key = key
+			}
+
+			entity, err := impl.registrationEntity(reg)
+			if err != nil {
+				return nil, err
+			}
+
+			if err := impl.register(entity); err != nil {
+				if errors.Is(err, ErrRepeatedBind) {
+					// registered by a concurrent lookup
+					return impl.lookupEntity(lookupKeys, nil), nil
+				}
+
+				return nil, err
+			}
+
+			return entity, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// registrationEntity create an entity from reg
+func (impl *container) registrationEntity(reg Registration) (*Entity, error) {
+	if reg.Initialize == nil {
+		if reg.Value == nil {
+			return nil, buildInvalidArgsError(fmt.Sprintf("registration of %v has neither initialize nor value", reg.Key))
+		}
+
+		typ := reg.Type
+		if typ == nil {
+			typ = reflect.TypeOf(reg.Value)
+		}
+
+		return &Entity{key: reg.Key, typ: typ, value: reg.Value, overridable: reg.Overridable, c: impl}, nil
+	}
+
+	initType := reflect.TypeOf(reg.Initialize)
+	if initType.Kind() != reflect.Func || initType.NumOut() == 0 {
+		return nil, buildInvalidArgsError(fmt.Sprintf("initialize of %v must be a func returning values", reg.Key))
+	}
+
+	typ := reg.Type
+	if typ == nil {
+		typ = initType.Out(0)
+	}
+
+	return impl.newEntity(reg.Key, typ, reg.Initialize, reg.Prototype, reg.Overridable), nil
+}
@@ -0,0 +1,76 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// GetAllVersions return instances of all registrations of key, ordered by priority (higher first) and
+// registration order (later first), the first one is what Get returns. Without WithBindingStack, only the
+// effective registration is returned. If key is not bound in current container, parents are consulted
+func (impl *container) GetAllVersions(key interface{}) ([]interface{}, error) {
+	if !reflect.ValueOf(key).IsValid() {
+		return nil, buildInvalidArgsError("key is nil")
+	}
+
+	lookupKeys, _ := impl.resolveLookupKeys(key)
+	for current := impl; current != nil; {
+		if versions := current.versions(lookupKeys); len(versions) > 0 {
+			results := make([]interface{}, 0, len(versions))
+			for _, e := range versions {
+				val, err := e.resolve(nil)
+				if err != nil {
+					return nil, err
+				}
+
+				results = append(results, val)
+			}
+
+			return results, nil
+		}
+
+		parent, ok := current.Parent().(*container)
+		if !ok {
+			break
+		}
+		current = parent
+	}
+
+	return nil, buildObjectNotFoundError(fmt.Sprintf("key=%v not found", key))
+}
+
+// versions return all registrations matching lookupKeys in current container
+func (impl *container) versions(lookupKeys []any) []*Entity {
+	impl.lock.RLock()
+	defer impl.lock.RUnlock()
+
+	for _, lookupKey := range lookupKeys {
+		e, ok := impl.entities[lookupKey]
+		if !ok {
+			continue
+		}
+
+		if stack, ok := impl.stacks[lookupKey]; ok && stack[0] == e {
+			return append([]*Entity(nil), stack...)
+		}
+
+		return []*Entity{e}
+	}
+
+	return nil
+}
+
+// pushVersion add entity to stack, the stack is ordered by priority (higher first) and registration order (later first)
+func pushVersion(stack []*Entity, entity *Entity) []*Entity {
+	stack = append(stack, entity)
+	sort.SliceStable(stack, func(i, j int) bool {
+		if stack[i].priority != stack[j].priority {
+			return stack[i].priority > stack[j].priority
+		}
+
+		return stack[i].index > stack[j].index
+	})
+
+	return stack
+}
@@ -0,0 +1,10 @@
+package ioc
+
+import "sync/atomic"
+
+// containerStats is the counters of container behavior
+type containerStats struct {
+	resolutions       atomic.Int64 // count of resolutions of bindings
+	cacheHits         atomic.Int64 // count of resolutions served by cached singletons
+	constructorErrors atomic.Int64 // count of constructors returned errors
+}
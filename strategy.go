@@ -0,0 +1,93 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// BindStrategy bind key to a strategy which chooses an implementation on every resolution (such as A/B tests or
+// canary routing), selector returns the key of the chosen implementation which is resolved by Get, a string
+// which is a version of key (see SingletonVersioned) chooses that version. When the strategy is resolved
+// through a child container, the selector receives the child and the chosen implementation is resolved from it
+//
+//	c.SingletonVersioned(new(PaymentGateway), "stable", newStableGateway)
+//	c.SingletonVersioned(new(PaymentGateway), "canary", newCanaryGateway)
+//	c.BindStrategy(new(PaymentGateway), func(r ioc.Resolver) any {
+//		if rand.Intn(100) < 5 {
+//			return "canary"
+//		}
+//		return "stable"
+//	})
+func (impl *container) BindStrategy(key any, selector func(r Resolver) any) error {
+	return impl.bindStrategy(impl, key, selector)
+}
+
+// bindStrategy bind the strategy of key through b
+func (impl *container) bindStrategy(b Binder, key any, selector func(r Resolver) any) error {
+	if selector == nil {
+		return buildInvalidArgsError("selector is nil")
+	}
+
+	normalized, err := normalizeKey(key)
+	if err != nil {
+		return err
+	}
+
+	typ, ok := normalized.(reflect.Type)
+	if !ok {
+		return buildInvalidArgsError("the key of strategy must be a type")
+	}
+
+	strategy := func(origin *container) (any, error) {
+		val, err := origin.selectImplementation(key, typ, selector(origin))
+		if err != nil {
+			return nil, err
+		}
+
+		return val.Interface(), nil
+	}
+
+	// the initialize is only called when the entity is resolved without a resolving container (Entity.Value)
+	fnType := reflect.FuncOf(nil, []reflect.Type{typ, errorType}, false)
+	initialize, err := makeFunc(fnType, func([]reflect.Value) []reflect.Value {
+		val, err := strategy(impl)
+		if err != nil {
+			return []reflect.Value{reflect.Zero(typ), reflect.ValueOf(&err).Elem()}
+		}
+
+		return []reflect.Value{reflect.ValueOf(val), reflect.Zero(errorType)}
+	})
+	if err != nil {
+		return err
+	}
+
+	return b.BindWithKey(typ, WithOptions(initialize.Interface(), func(e *Entity) { e.strategy = strategy }), true, false)
+}
+
+// selectImplementation resolve the implementation chosen by the selector of strategy
+func (impl *container) selectImplementation(key any, typ reflect.Type, chosen any) (reflect.Value, error) {
+	if !reflect.ValueOf(chosen).IsValid() {
+		return reflect.Value{}, buildObjectNotFoundError(fmt.Sprintf("strategy of key=%v chose nothing", key))
+	}
+
+	var val any
+	var err error
+	if version, ok := chosen.(string); ok && impl.findEntity(versionedKey{key: typ, version: version}) != nil {
+		val, err = impl.GetVersion(typ, version)
+	} else {
+		val, err = impl.Get(chosen)
+	}
+
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	res := reflect.ValueOf(val)
+	if !res.IsValid() || !res.Type().AssignableTo(typ) {
+		return reflect.Value{}, buildInvalidArgsError(fmt.Sprintf("strategy of key=%v chose %v which is not a %v", key, chosen, typ))
+	}
+
+	return res, nil
+}
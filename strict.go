@@ -0,0 +1,83 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// WithStrictMode enforce opinionated registration checks for large codebases:
+//   - constructors must return (T) or (T, error)
+//   - interface keys must be bound by constructors returning the interface explicitly
+//   - value keys must be typed constants (such as `const DBHost ConfigKey = "db.host"`), so values must be bound
+//     by BindTypedValue instead of BindValue
+//
+// Violations return ErrStrictMode with remediation hints. Bindings after Freeze are rejected regardless of
+// strict mode
+func WithStrictMode() Option {
+	return func(impl *container) {
+		impl.strict = true
+	}
+}
+
+// BindTypedValue bind a value under a typed string key, in strict mode (see WithStrictMode), it's the only way
+// to bind values, and key must not be a plain string. The value is resolved by the string form of key
+//
+//	type ConfigKey string
+//
+//	const DBHost ConfigKey = "db.host"
+//
+//	ioc.BindTypedValue(c, DBHost, "127.0.0.1")
+func BindTypedValue[K ~string](b Binder, key K, value any) error {
+	impl, ok := containerOf(b)
+	if !ok {
+		return b.BindValue(string(key), value)
+	}
+
+	if impl.strict && reflect.TypeOf(key) == reflect.TypeOf("") {
+		return buildStrictModeError(fmt.Sprintf("value key %q is a plain string, hint: declare it as a typed constant, such as `const Key ConfigKey = %q`", key, key))
+	}
+
+	return impl.bindValueOverride(string(key), attributed(b, value), false)
+}
+
+// Freeze prevent any further binding changes of current container (bind, override and unbind), which return
+// ErrFrozen afterward
+func (impl *container) Freeze() {
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
+	impl.frozen = true
+}
+
+// checkStrictValueBinding check whether the value binding by BindValue is allowed in strict mode
+func (impl *container) checkStrictValueBinding(key string) error {
+	if !impl.strict {
+		return nil
+	}
+
+	return buildStrictModeError(fmt.Sprintf("value key %q is not a typed constant, hint: use ioc.BindTypedValue with a typed constant key instead of BindValue", key))
+}
+
+// checkStrictEntity check the entity against the rules of strict mode
+func (impl *container) checkStrictEntity(entity *Entity) error {
+	if !impl.strict || entity.builtin {
+		return nil
+	}
+
+	var initType reflect.Type
+	if entity.initializeFunc != nil {
+		initType = reflect.TypeOf(entity.initializeFunc)
+	}
+
+	if initType != nil && initType.Kind() == reflect.Func && initType.NumOut() == 2 && initType.Out(1) != errorType {
+		return buildStrictModeError(fmt.Sprintf("the second return value of constructor of key=%v is %v, hint: return (T, error)", entity.key, initType.Out(1)))
+	}
+
+	if keyType, ok := entity.key.(reflect.Type); ok && keyType.Kind() == reflect.Interface {
+		if initType == nil || initType.Kind() != reflect.Func || initType.NumOut() == 0 || initType.Out(0) != keyType {
+			return buildStrictModeError(fmt.Sprintf("interface %v is not bound explicitly, hint: bind it with a constructor returning %v", keyType, keyType))
+		}
+	}
+
+	return nil
+}
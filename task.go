@@ -0,0 +1,102 @@
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// TaskGroup run a set of tasks concurrently like errgroup, tasks are funcs like func(ctx context.Context,
+// deps...) error whose deps are injected from container. The ctx of tasks derives from the context.Context
+// bound in container, it's canceled when any task fails
+//
+//	group := ioc.NewTaskGroup(c)
+//	group.Add(func(ctx context.Context, consumer *OrderConsumer) error { return consumer.Run(ctx) })
+//	group.Add(func(ctx context.Context, server *http.Server) error { return server.ListenAndServe() })
+//	err := group.Run()
+type TaskGroup struct {
+	lock  sync.Mutex
+	c     Container
+	tasks []any
+	err   error
+}
+
+// NewTaskGroup create a TaskGroup resolves the deps of tasks from c
+func NewTaskGroup(c Container) *TaskGroup {
+	return &TaskGroup{c: c}
+}
+
+// Add register tasks to group, the first arg of a task must be context.Context and the only return value must
+// be error, invalid tasks make Run fail without running any task
+func (g *TaskGroup) Add(tasks ...any) *TaskGroup {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for _, task := range tasks {
+		if err := validateTask(task); err != nil && g.err == nil {
+			g.err = err
+		}
+
+		g.tasks = append(g.tasks, task)
+	}
+
+	return g
+}
+
+// validateTask check whether task is a func like func(ctx context.Context, deps...) error
+func validateTask(task any) error {
+	typ := reflect.TypeOf(task)
+	if typ == nil || typ.Kind() != reflect.Func {
+		return buildInvalidArgsError(fmt.Sprintf("task must be a func, got %T", task))
+	}
+
+	if typ.NumIn() == 0 || typ.In(0) != contextType || typ.NumOut() != 1 || typ.Out(0) != errorType {
+		return buildInvalidArgsError(fmt.Sprintf("task must be a func(ctx context.Context, deps...) error, got %v", typ))
+	}
+
+	return nil
+}
+
+// Run run all tasks concurrently and wait for them, the first error (a task failed or its deps could not be
+// resolved) is returned and cancels the ctx of the other tasks
+func (g *TaskGroup) Run() error {
+	g.lock.Lock()
+	tasks, err := g.tasks, g.err
+	g.lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	var parent context.Context
+	if err := g.c.Resolve(func(ctx context.Context) { parent = ctx }); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	overlay := g.c.WithValues(map[any]any{contextType: ctx})
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(task any) {
+			defer wg.Done()
+
+			if err := overlay.Resolve(task); err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("task %T failed: %w", task, err)
+					cancel()
+				})
+			}
+		}(task)
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
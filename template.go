@@ -0,0 +1,144 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// templateKey is the key of a binding template
+type templateKey struct {
+	name string
+}
+
+func (k templateKey) String() string {
+	return fmt.Sprintf("template(%s)", k.name)
+}
+
+// bindingTemplate creates and caches one instance per parameter
+type bindingTemplate struct {
+	factory func(param string) any
+
+	lock      sync.Mutex
+	instances map[string]*templateCall
+}
+
+// templateCall is the creation of the instance for a parameter, concurrent callers wait for done
+type templateCall struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// BindTemplate bind a template named name, GetTemplated creates an instance by factory for every distinct
+// parameter and caches it, so instances such as one producer per topic don't need to be registered one by one.
+// If factory returns an error, it's returned by GetTemplated and the instance is not cached. Cached instances
+// implementing io.Closer are closed when the template is released (such as on Shutdown)
+//
+//	c.BindTemplate("producer", func(topic string) any { return kafka.NewProducer(brokers, topic) })
+//	producer, err := c.GetTemplated("producer", "orders")
+func (impl *container) BindTemplate(name string, factory func(param string) any) error {
+	return impl.bindTemplate(impl, name, factory)
+}
+
+// bindTemplate bind the template name through b
+func (impl *container) bindTemplate(b Binder, name string, factory func(param string) any) error {
+	if name == "" {
+		return buildInvalidArgsError("template name can not be empty")
+	}
+
+	if factory == nil {
+		return buildInvalidArgsError("template factory is nil")
+	}
+
+	tpl := &bindingTemplate{factory: factory, instances: make(map[string]*templateCall)}
+	return b.BindWithKey(templateKey{name: name}, WithOptions(func() *bindingTemplate { return tpl }, WithDisposer(func(any) error {
+		return tpl.close()
+	})), false, false)
+}
+
+// GetTemplated get the instance of template name (see BindTemplate) for param, it's created on first use,
+// parents are consulted if the template is not bound in current container
+func (impl *container) GetTemplated(name string, param string) (any, error) {
+	val, err := impl.lookupInstance(templateKey{name: name}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return val.(*bindingTemplate).get(param)
+}
+
+// MustGetTemplated get the instance of template name for param like GetTemplated, if failed, panic it
+func (impl *container) MustGetTemplated(name string, param string) any {
+	val, err := impl.GetTemplated(name, param)
+	impl.Must(err)
+
+	return val
+}
+
+// get return the cached instance for param, create it if absent
+func (tpl *bindingTemplate) get(param string) (any, error) {
+	tpl.lock.Lock()
+	if call, ok := tpl.instances[param]; ok {
+		tpl.lock.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+
+	call := &templateCall{done: make(chan struct{})}
+	tpl.instances[param] = call
+	tpl.lock.Unlock()
+
+	// waiting callers are released and the failure is not cached even if factory panics
+	completed := false
+	defer func() {
+		if !completed {
+			call.err = buildConstructorPanicError(param, "factory of template panicked")
+		}
+
+		if call.err != nil {
+			tpl.lock.Lock()
+			delete(tpl.instances, param)
+			tpl.lock.Unlock()
+		}
+
+		close(call.done)
+	}()
+
+	call.value = tpl.factory(param)
+	if err, ok := call.value.(error); ok {
+		call.value, call.err = nil, fmt.Errorf("create instance for %s failed: %w", param, err)
+	}
+	completed = true
+
+	return call.value, call.err
+}
+
+// close close the cached instances implementing io.Closer (ordered by parameter) and clear the cache
+func (tpl *bindingTemplate) close() error {
+	tpl.lock.Lock()
+	instances := tpl.instances
+	tpl.instances = make(map[string]*templateCall)
+	tpl.lock.Unlock()
+
+	params := make([]string, 0, len(instances))
+	for param := range instances {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+
+	errs := make([]error, 0)
+	for _, param := range params {
+		call := instances[param]
+		<-call.done
+		if closer, ok := call.value.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errs = append(errs, fmt.Errorf("close instance for %s failed: %w", param, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,195 @@
+package ioc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TenantIDKey is the value key of tenant ID bound in tenant containers
+const TenantIDKey = "tenant.id"
+
+// TenantContainers manage one child container per tenant, created from a template module set on demand and
+// evicted (closed) after idle, so every tenant has its own singletons such as DB pools
+//
+//	tenants := ioc.NewTenantContainers(c, 30*time.Minute, tenantDBModule{})
+//	r, err := tenants.ForTenant(tenantID)
+type TenantContainers struct {
+	lock    sync.Mutex
+	parent  Container
+	modules []Registerable
+	idleTTL time.Duration
+	tenants map[string]*tenantContainer
+}
+
+type tenantContainer struct {
+	ready      chan struct{} // closed once the container is loaded (or failed to)
+	c          Container
+	err        error
+	clock      Clock
+	lastAccess time.Time     // guarded by the lock of TenantContainers
+	stop       chan struct{} // closed to stop the idle eviction, nil if it's not scheduled
+}
+
+// NewTenantContainers create a TenantContainers, tenant containers are children of parent loaded with modules,
+// idleTTL <= 0 means tenant containers are never evicted automatically
+func NewTenantContainers(parent Container, idleTTL time.Duration, modules ...Registerable) *TenantContainers {
+	return &TenantContainers{
+		parent:  parent,
+		modules: modules,
+		idleTTL: idleTTL,
+		tenants: make(map[string]*tenantContainer),
+	}
+}
+
+// ForTenant return the container of tenant id, it's created and loaded with the template modules if absent.
+// Modules are loaded outside the lock of TenantContainers, so a slow tenant doesn't block others, concurrent
+// callers of the same tenant wait for the first one. A failed tenant is not cached, the next call tries again
+func (tc *TenantContainers) ForTenant(id string) (Resolver, error) {
+	if id == "" {
+		return nil, buildInvalidArgsError("tenant id can not be empty")
+	}
+
+	tc.lock.Lock()
+	if t, ok := tc.tenants[id]; ok {
+		if t.clock != nil {
+			t.lastAccess = t.clock.Now()
+		}
+		tc.lock.Unlock()
+
+		<-t.ready
+		return t.c, t.err
+	}
+
+	t := &tenantContainer{ready: make(chan struct{})}
+	tc.tenants[id] = t
+	tc.lock.Unlock()
+
+	c, err := tc.load(id)
+
+	tc.lock.Lock()
+	t.c, t.err = c, err
+	if err != nil {
+		if tc.tenants[id] == t {
+			delete(tc.tenants, id)
+		}
+	} else {
+		t.clock = c.(*container).clock()
+		t.lastAccess = t.clock.Now()
+		if tc.idleTTL > 0 {
+			t.stop = make(chan struct{})
+			go tc.evictIdle(id, t, t.stop, t.clock.After(tc.idleTTL))
+		}
+	}
+	tc.lock.Unlock()
+	close(t.ready)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// load create the container of tenant id and load the template modules
+func (tc *TenantContainers) load(id string) (Container, error) {
+	c := Extend(tc.parent, withScope(ScopeInfo{Kind: ScopeTenant, ID: id}))
+	if err := bindBuiltinValue(c, TenantIDKey, id); err != nil {
+		return nil, err
+	}
+
+	if err := c.Load(tc.modules...); err != nil {
+		return nil, fmt.Errorf("load modules for tenant %s failed: %w", id, err)
+	}
+
+	return c, nil
+}
+
+// Tenants return the IDs of tenants whose containers are alive, ordered by ID
+func (tc *TenantContainers) Tenants() []string {
+	tc.lock.Lock()
+	defer tc.lock.Unlock()
+
+	ids := make([]string, 0, len(tc.tenants))
+	for id := range tc.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// Evict close and remove the container of tenant id, it's recreated on next ForTenant
+func (tc *TenantContainers) Evict(id string) error {
+	tc.lock.Lock()
+	t, ok := tc.tenants[id]
+	delete(tc.tenants, id)
+	tc.lock.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	<-t.ready
+	if t.err != nil {
+		return nil
+	}
+
+	tc.lock.Lock()
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+	tc.lock.Unlock()
+
+	return t.c.Close()
+}
+
+// Close close and remove the containers of all tenants
+func (tc *TenantContainers) Close() error {
+	errs := make([]error, 0)
+	for _, id := range tc.Tenants() {
+		if err := tc.Evict(id); err != nil {
+			errs = append(errs, fmt.Errorf("close tenant %s failed: %w", id, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// evictIdle evict the container of tenant once it has not been accessed for idleTTL, until stop is closed
+func (tc *TenantContainers) evictIdle(id string, t *tenantContainer, stop chan struct{}, timeout <-chan time.Time) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timeout:
+		}
+
+		tc.lock.Lock()
+		if tc.tenants[id] != t || t.stop != stop {
+			tc.lock.Unlock()
+			return
+		}
+
+		if idle := t.clock.Now().Sub(t.lastAccess); idle < tc.idleTTL {
+			timeout = t.clock.After(tc.idleTTL - idle)
+			tc.lock.Unlock()
+			continue
+		}
+
+		delete(tc.tenants, id)
+		t.stop = nil
+		tc.lock.Unlock()
+
+		logger := t.c.(*container).logger()
+		logger.Debug("idle tenant container evicted", "tenant", id)
+		if err := t.c.Close(); err != nil {
+			logger.Warn("close idle tenant container failed", "tenant", id, "error", err)
+		}
+
+		return
+	}
+}
@@ -0,0 +1,53 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// NotThreadSafeProxy mark a binding of interface T as NotThreadSafe, but allow it to be bound as a singleton
+// shared by all goroutines: proxy wraps the instance into an implementation of T which holds lock around every
+// call of inner. The binding must be keyed by T (such as a constructor returning T), Go can not implement
+// interfaces at runtime, so the proxy is written by hand
+//
+//	type lockedMailer struct {
+//		inner Mailer
+//		lock  sync.Locker
+//	}
+//
+//	func (m lockedMailer) Send(msg Message) error {
+//		m.lock.Lock()
+//		defer m.lock.Unlock()
+//		return m.inner.Send(msg)
+//	}
+//
+//	c.MustSingleton(ioc.WithOptions(newSMTPMailer, ioc.NotThreadSafeProxy(func(inner Mailer, lock sync.Locker) Mailer {
+//		return lockedMailer{inner: inner, lock: lock}
+//	})))
+func NotThreadSafeProxy[T any](proxy func(inner T, lock sync.Locker) T) BindOption {
+	return func(e *Entity) {
+		e.notThreadSafe = true
+		e.syncProxyType = reflect.TypeOf((*T)(nil)).Elem()
+		e.syncProxy = func(inner any, lock sync.Locker) any {
+			return proxy(inner.(T), lock)
+		}
+	}
+}
+
+// checkThreadSafety check whether a binding marked NotThreadSafe would be shared between goroutines
+func (impl *container) checkThreadSafety(entity *Entity) error {
+	if !entity.notThreadSafe || entity.prototype || impl.scope.Kind == ScopeRequest {
+		return nil
+	}
+
+	if entity.syncProxy == nil {
+		return buildNotThreadSafeError(fmt.Sprintf("key=%v is not thread safe, it can only be bound as a prototype or a singleton of a request scope", entity.key))
+	}
+
+	if key, ok := entity.key.(reflect.Type); !ok || key != entity.syncProxyType || key.Kind() != reflect.Interface {
+		return buildInvalidArgsError(fmt.Sprintf("the proxy of key=%v wraps %v, it can only be used by a binding of that interface", entity.key, entity.syncProxyType))
+	}
+
+	return nil
+}
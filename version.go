@@ -0,0 +1,87 @@
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// versionedKey is the key of a versioned binding
+type versionedKey struct {
+	key     any
+	version string
+}
+
+func (k versionedKey) String() string {
+	return fmt.Sprintf("%v@%s", k.key, k.version)
+}
+
+// newVersionedKey create the key of a versioned binding, key is normalized by normalizeKey
+func newVersionedKey(key any, version string) (versionedKey, error) {
+	if version == "" {
+		return versionedKey{}, buildInvalidArgsError("version can not be empty")
+	}
+
+	normalized, err := normalizeKey(key)
+	if err != nil {
+		return versionedKey{}, err
+	}
+
+	return versionedKey{key: normalized, version: version}, nil
+}
+
+// normalizeKey normalize key to a type (pointers to interfaces are resolved as the interfaces themselves)
+// unless it's a string
+func normalizeKey(key any) (any, error) {
+	if !reflect.ValueOf(key).IsValid() {
+		return nil, buildInvalidArgsError("key is nil")
+	}
+
+	if k, ok := key.(string); ok {
+		return k, nil
+	}
+
+	typ, ok := key.(reflect.Type)
+	if !ok {
+		typ = reflect.TypeOf(key)
+	}
+
+	if typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Interface {
+		typ = typ.Elem()
+	}
+
+	return typ, nil
+}
+
+// SingletonVersioned bind a singleton for key under version, so several implementations of the same key can
+// coexist (such as during a gradual API migration), use GetVersion to resolve a specific version
+//
+//	c.SingletonVersioned(new(PaymentGateway), "v2", func() PaymentGateway { return &gatewayV2{} })
+//	gateway, err := c.GetVersion(new(PaymentGateway), "v2")
+func (impl *container) SingletonVersioned(key any, version string, initialize any) error {
+	return impl.bindVersioned(key, version, initialize, false)
+}
+
+// PrototypeVersioned bind a prototype for key under version, see SingletonVersioned
+func (impl *container) PrototypeVersioned(key any, version string, initialize any) error {
+	return impl.bindVersioned(key, version, initialize, true)
+}
+
+func (impl *container) bindVersioned(key any, version string, initialize any, prototype bool) error {
+	vk, err := newVersionedKey(key, version)
+	if err != nil {
+		return err
+	}
+
+	return impl.BindWithKey(vk, initialize, prototype, false)
+}
+
+// GetVersion get the instance of key bound under version (see SingletonVersioned), parents are consulted if
+// it's not bound in current container
+func (impl *container) GetVersion(key any, version string) (any, error) {
+	vk, err := newVersionedKey(key, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return impl.lookupInstance(vk, nil)
+}
@@ -0,0 +1,194 @@
+package ioc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Wiring is a data file declaring which registered factory (see RegisterFactory) builds which key, so the
+// implementations can be re-wired between deploys without recompiling, see LoadWiring
+//
+//	{
+//	  "bindings": [
+//	    {"factory": "postgres"},
+//	    {"factory": "redis-cache", "version": "sessions", "prototype": true}
+//	  ]
+//	}
+type Wiring struct {
+	Bindings []WiringBinding `json:"bindings" yaml:"bindings"`
+}
+
+// WiringBinding is a binding declared by Wiring
+type WiringBinding struct {
+	// Factory the name of the factory registered by RegisterFactory
+	Factory string `json:"factory" yaml:"factory"`
+	// Version bind the factory as a version of the type it returns (see SingletonVersioned), the factory is
+	// bound to the type directly if empty
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Prototype bind the factory as a prototype instead of a singleton
+	Prototype bool `json:"prototype,omitempty" yaml:"prototype,omitempty"`
+	// Override bind the factory as an overridable binding, it's ignored for versions
+	Override bool `json:"override,omitempty" yaml:"override,omitempty"`
+}
+
+// WiringCodec decode wiring descriptors, JSONWiringCodec is used if no codec is specified, other formats (such
+// as YAML) are supported by implementing it with the library of choice
+type WiringCodec interface {
+	Decode(r io.Reader) (Wiring, error)
+}
+
+// JSONWiringCodec is a WiringCodec decoding JSON wiring descriptors
+var JSONWiringCodec WiringCodec = jsonWiringCodec{}
+
+type jsonWiringCodec struct{}
+
+func (jsonWiringCodec) Decode(r io.Reader) (Wiring, error) {
+	var wiring Wiring
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&wiring)
+	return wiring, err
+}
+
+// RegisterFactory register a constructor under name without binding it, wiring descriptors loaded by LoadWiring
+// choose factories by name. factory must return (T) or (T, error), factories of ancestors are visible to
+// children, a child can register a factory with the same name to replace it
+//
+//	c.MustRegisterFactory("postgres", NewPostgresUserRepo)
+//	c.MustRegisterFactory("memory", NewMemoryUserRepo)
+func (impl *container) RegisterFactory(name string, factory any) error {
+	if name == "" {
+		return buildInvalidArgsError("name of factory can not be empty")
+	}
+
+	factoryType := reflect.TypeOf(factory)
+	if factoryType == nil {
+		return buildInvalidArgsError("factory is nil")
+	}
+
+	if err := checkInitializeType(factoryType); err != nil {
+		return err
+	}
+
+	impl.lock.Lock()
+	defer impl.lock.Unlock()
+
+	if _, ok := impl.factories[name]; ok {
+		return buildRepeatedBindError(fmt.Sprintf("factory %s is already registered", name))
+	}
+
+	if impl.factories == nil {
+		impl.factories = make(map[string]any)
+	}
+
+	impl.factories[name] = factory
+	return nil
+}
+
+// MustRegisterFactory register a constructor under name like RegisterFactory, if failed, panic it
+func (impl *container) MustRegisterFactory(name string, factory any) {
+	impl.Must(impl.RegisterFactory(name, factory))
+}
+
+// LoadWiring bind the factories declared by the wiring descriptor read from r with codec (nil means
+// JSONWiringCodec). All factories are looked up before binding, an unknown factory fails with ErrObjectNotFound
+// and nothing is bound, bindings before a failed one (such as ErrRepeatedBind) are kept
+func (impl *container) LoadWiring(r io.Reader, codec WiringCodec) error {
+	return impl.loadWiring(impl, r, codec)
+}
+
+// loadWiring bind the factories declared by the wiring descriptor through b
+func (impl *container) loadWiring(b Binder, r io.Reader, codec WiringCodec) error {
+	if r == nil {
+		return buildInvalidArgsError("reader is nil")
+	}
+
+	if codec == nil {
+		codec = JSONWiringCodec
+	}
+
+	wiring, err := codec.Decode(r)
+	if err != nil {
+		return fmt.Errorf("decode wiring failed: %w", err)
+	}
+
+	factories := make([]any, len(wiring.Bindings))
+	for i, binding := range wiring.Bindings {
+		factory, ok := impl.factory(binding.Factory)
+		if !ok {
+			return buildObjectNotFoundError(fmt.Sprintf("factory %s of binding #%d is not registered, registered: %s", binding.Factory, i, strings.Join(impl.factoryNames(), ", ")))
+		}
+
+		factories[i] = factory
+	}
+
+	for i, binding := range wiring.Bindings {
+		switch {
+		case binding.Version == "":
+			err = b.Bind(factories[i], binding.Prototype, binding.Override)
+		case binding.Prototype:
+			err = b.PrototypeVersioned(reflect.TypeOf(factories[i]).Out(0), binding.Version, factories[i])
+		default:
+			err = b.SingletonVersioned(reflect.TypeOf(factories[i]).Out(0), binding.Version, factories[i])
+		}
+
+		if err != nil {
+			return fmt.Errorf("bind factory %s of binding #%d failed: %w", binding.Factory, i, err)
+		}
+	}
+
+	return nil
+}
+
+// factory return the factory registered under name in current container or its ancestors
+func (impl *container) factory(name string) (any, bool) {
+	for cc := impl; cc != nil; {
+		cc.lock.RLock()
+		factory, ok := cc.factories[name]
+		cc.lock.RUnlock()
+
+		if ok {
+			return factory, true
+		}
+
+		parent, ok := cc.Parent().(*container)
+		if !ok {
+			break
+		}
+
+		cc = parent
+	}
+
+	return nil, false
+}
+
+// factoryNames return the sorted names of factories visible to current container
+func (impl *container) factoryNames() []string {
+	seen := make(map[string]bool)
+	for cc := impl; cc != nil; {
+		cc.lock.RLock()
+		for name := range cc.factories {
+			seen[name] = true
+		}
+		cc.lock.RUnlock()
+
+		parent, ok := cc.Parent().(*container)
+		if !ok {
+			break
+		}
+
+		cc = parent
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}